@@ -0,0 +1,136 @@
+package client_http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdaptiveConcurrency - limits of WithAdaptiveConcurrency
+type AdaptiveConcurrency struct {
+	// InitialLimit - requests in flight allowed per host at start, 10 by default
+	InitialLimit int
+	// MinLimit - the limit never shrinks below it, 1 by default
+	MinLimit int
+	// MaxLimit - the limit never grows beyond it, 200 by default
+	MaxLimit int
+	// LatencyThreshold - time to the response headers considered overload, zero only
+	// uses errors as signal
+	LatencyThreshold time.Duration
+	// Backoff - factor applied to the limit on overload, 0.75 by default
+	Backoff float64
+}
+
+// WithAdaptiveConcurrency - limit the requests in flight of each host with AIMD: the
+// limit grows by one request every limit successful requests and shrinks by Backoff on
+// connection errors, 429 and 5xx responses or responses slower than LatencyThreshold.
+// Requests over the limit wait for a slot, a request holds its slot until its body is
+// closed
+func WithAdaptiveConcurrency(config AdaptiveConcurrency) Option {
+	return func(c *Client) error {
+		if config.InitialLimit == 0 {
+			config.InitialLimit = 10
+		}
+		if config.MinLimit == 0 {
+			config.MinLimit = 1
+		}
+		if config.MaxLimit == 0 {
+			config.MaxLimit = 200
+		}
+		if config.Backoff == 0 {
+			config.Backoff = 0.75
+		}
+		if config.MinLimit < 1 || config.MaxLimit < config.MinLimit ||
+			config.InitialLimit < config.MinLimit || config.InitialLimit > config.MaxLimit {
+			return fmt.Errorf("invalid adaptive concurrency limits [%d] - [%d] - [%d]", config.MinLimit, config.InitialLimit, config.MaxLimit)
+		}
+		if config.Backoff <= 0 || config.Backoff >= 1 {
+			return fmt.Errorf("invalid adaptive concurrency backoff [%v]", config.Backoff)
+		}
+		if config.LatencyThreshold < 0 {
+			return fmt.Errorf("invalid adaptive concurrency latency threshold [%v]", config.LatencyThreshold)
+		}
+
+		hosts := &adaptiveHosts{config: config, limiters: map[string]*adaptiveLimiter{}}
+		c.internalMiddlewares = append(c.internalMiddlewares, hosts.middleware)
+		return nil
+	}
+}
+
+// adaptiveHosts - one limiter per host
+type adaptiveHosts struct {
+	config AdaptiveConcurrency
+
+	mu       sync.Mutex
+	limiters map[string]*adaptiveLimiter
+}
+
+// limiter - limiter of host, created on first use
+func (h *adaptiveHosts) limiter(host string) *adaptiveLimiter {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	l, ok := h.limiters[host]
+	if !ok {
+		l = &adaptiveLimiter{config: &h.config, slots: newSemaphore(h.config.InitialLimit), limit: float64(h.config.InitialLimit)}
+		h.limiters[host] = l
+	}
+	return l
+}
+
+// middleware - wait for a slot of the request host and adjust its limit with the outcome
+func (h *adaptiveHosts) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		l := h.limiter(strings.ToLower(request.URL.Host))
+		if err := l.slots.acquire(request.Context(), requestPriority(request), -1); err != nil {
+			return nil, fmt.Errorf("concurrency limit wait cancelled [%w]", err)
+		}
+		var once sync.Once
+		release := func() { once.Do(l.slots.release) }
+
+		start := time.Now()
+		response, err := next(request)
+		if request.Context().Err() == nil {
+			l.adjust(response, err, time.Since(start))
+		}
+		if err != nil {
+			release()
+			return nil, err
+		}
+		response.Body = &closeNotifyBody{ReadCloser: response.Body, onClose: release}
+		return response, nil
+	}
+}
+
+// adaptiveLimiter - AIMD limit of the requests in flight of a host
+type adaptiveLimiter struct {
+	config *AdaptiveConcurrency
+	slots  *semaphore
+
+	mu    sync.Mutex
+	limit float64
+}
+
+// adjust - grow the limit after a healthy response, shrink it on overload
+func (l *adaptiveLimiter) adjust(response *http.Response, err error, latency time.Duration) {
+	overload := err != nil ||
+		response.StatusCode == http.StatusTooManyRequests || isServerError(response.StatusCode) ||
+		(l.config.LatencyThreshold > 0 && latency > l.config.LatencyThreshold)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if overload {
+		l.limit *= l.config.Backoff
+		if l.limit < float64(l.config.MinLimit) {
+			l.limit = float64(l.config.MinLimit)
+		}
+	} else {
+		l.limit += 1 / l.limit
+		if l.limit > float64(l.config.MaxLimit) {
+			l.limit = float64(l.config.MaxLimit)
+		}
+	}
+	l.slots.resize(int(l.limit))
+}
@@ -0,0 +1,44 @@
+package client_http
+
+import (
+	"context"
+	"net/http"
+)
+
+// Future - result of a request running in background
+type Future struct {
+	done     chan struct{}
+	response *Response
+	err      error
+}
+
+// Wait - block until the request finishes and return its result, can be called many times
+func (f *Future) Wait() (*Response, error) {
+	<-f.done
+	return f.response, f.err
+}
+
+// Done - closed when the request finishes, to select on several futures
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// DoAsync - start a request in background, cancel ctx to abort it
+func (c *Client) DoAsync(ctx context.Context, method, url string, payload []byte, headers ...HeaderParameters) *Future {
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.response, f.err = c.Do(ctx, method, url, payload, headers...)
+	}()
+	return f
+}
+
+// GetAsync - start a GET request in background
+func (c *Client) GetAsync(ctx context.Context, url string, headers ...HeaderParameters) *Future {
+	return c.DoAsync(ctx, http.MethodGet, url, nil, headers...)
+}
+
+// PostAsync - start a POST request in background
+func (c *Client) PostAsync(ctx context.Context, url string, payload []byte, headers ...HeaderParameters) *Future {
+	return c.DoAsync(ctx, http.MethodPost, url, payload, headers...)
+}
@@ -0,0 +1,176 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Token - access token attached as Authorization header
+type Token struct {
+	AccessToken string
+	// TokenType - authorization scheme, Bearer when empty
+	TokenType string
+	// Expiry - expiration time, zero means the token never expires
+	Expiry time.Time
+}
+
+// expired - true if the token expires within skew
+func (t *Token) expired(skew time.Duration) bool {
+	return !t.Expiry.IsZero() && time.Now().Add(skew).After(t.Expiry)
+}
+
+// authorization - value of the Authorization header
+func (t *Token) authorization() string {
+	if t.TokenType == "" {
+		return "Bearer " + t.AccessToken
+	}
+	return t.TokenType + " " + t.AccessToken
+}
+
+// TokenSource - provides tokens for requests, implementations may fetch or refresh them
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc - adapter to use a function as TokenSource
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token - call f
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// StaticTokenSource - TokenSource always returning the same bearer token
+func StaticTokenSource(accessToken string) TokenSource {
+	token := &Token{AccessToken: accessToken}
+	return TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		return token, nil
+	})
+}
+
+// reuseTokenSource - caches the token of source until it is about to expire
+type reuseTokenSource struct {
+	source TokenSource
+	skew   time.Duration
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// ReuseTokenSource - cache tokens of source and ask for a new one skew before expiry
+func ReuseTokenSource(source TokenSource, skew time.Duration) TokenSource {
+	return &reuseTokenSource{source: source, skew: skew}
+}
+
+// Token - cached token, refreshed when expired
+func (s *reuseTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && !s.token.expired(s.skew) {
+		return s.token, nil
+	}
+
+	token, err := s.source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// WithBearerToken - send Authorization: Bearer <token> on every request
+func WithBearerToken(accessToken string) Option {
+	return WithTokenSource(StaticTokenSource(accessToken))
+}
+
+// WithTokenSource - ask source for a token on every request and send it as Authorization header.
+// Wrap source with ReuseTokenSource to avoid fetching a new token each time
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) error {
+		if source == nil {
+			return fmt.Errorf("token source can't be nil")
+		}
+		c.tokenSource = source
+		return nil
+	}
+}
+
+// basicAuth - credentials sent on every request
+type basicAuth struct {
+	username string
+	password string
+}
+
+// WithBasicAuth - send username and password with basic authentication on every request,
+// credentials passed to a call (GetWithCredentials, DoWithCredentials...) take precedence
+func WithBasicAuth(username, password string) Option {
+	return func(c *Client) error {
+		if username == "" {
+			return fmt.Errorf("basic auth username can't be empty")
+		}
+		c.basicAuth = &basicAuth{username: username, password: password}
+		return nil
+	}
+}
+
+// BearerHeader - Authorization header for a single request
+func BearerHeader(accessToken string) HeaderParameters {
+	return HeaderParameters{Key: "Authorization", Value: "Bearer " + accessToken}
+}
+
+// APIKeyLocation - where WithAPIKey sends the key
+type APIKeyLocation int
+
+const (
+	// APIKeyInHeader - send the key as a request header
+	APIKeyInHeader APIKeyLocation = iota
+	// APIKeyInQuery - send the key as a query parameter
+	APIKeyInQuery
+)
+
+// apiKey - key sent on every request
+type apiKey struct {
+	name     string
+	value    string
+	location APIKeyLocation
+}
+
+// WithAPIKey - send value as header or query parameter name on every request,
+// a header or parameter with the same name set by the call takes precedence
+func WithAPIKey(name, value string, location APIKeyLocation) Option {
+	return func(c *Client) error {
+		if name == "" {
+			return fmt.Errorf("api key name can't be empty")
+		}
+		if location != APIKeyInHeader && location != APIKeyInQuery {
+			return fmt.Errorf("invalid api key location [%d]", location)
+		}
+		c.apiKey = &apiKey{name: name, value: value, location: location}
+		return nil
+	}
+}
+
+// apply - add the key to request unless already present
+func (k *apiKey) apply(request *http.Request) {
+	if k.location == APIKeyInHeader {
+		if request.Header.Get(k.name) == "" {
+			request.Header.Set(k.name, k.value)
+		}
+		return
+	}
+
+	if request.URL.Query().Get(k.name) != "" {
+		return
+	}
+	param := url.QueryEscape(k.name) + "=" + url.QueryEscape(k.value)
+	if request.URL.RawQuery == "" {
+		request.URL.RawQuery = param
+	} else {
+		request.URL.RawQuery += "&" + param
+	}
+}
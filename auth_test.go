@@ -0,0 +1,118 @@
+package client_http_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+const apiKeySecret = "s3cr3t-key"
+
+// logRecorder - Logger keeping every entry formatted as text
+type logRecorder struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *logRecorder) Log(level client_http.LogLevel, message string, fields map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fmt.Sprintf("%s %s %v", level, message, fields))
+}
+
+func (l *logRecorder) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.entries, "\n")
+}
+
+func TestAPIKeyRedacted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != apiKeySecret && r.Header.Get("X-Api-Key") != apiKeySecret {
+			t.Errorf("api key not sent")
+		}
+		switch r.URL.Path {
+		case "/large":
+			_, _ = w.Write(bytes.Repeat([]byte("x"), 64))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		location client_http.APIKeyLocation
+		key      string
+	}{
+		{name: "query", location: client_http.APIKeyInQuery, key: "api_key"},
+		{name: "header", location: client_http.APIKeyInHeader, key: "X-Api-Key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &logRecorder{}
+			var debug bytes.Buffer
+			har := client_http.NewHARRecorder(0)
+			c, err := client_http.NewHttpClient(
+				client_http.WithLogger(logs),
+				client_http.WithRequestLogging(),
+				client_http.WithDebug(&debug),
+				client_http.WithHAR(har),
+				client_http.WithFailOnErrorStatus(),
+				client_http.WithMaxResponseBytes(16),
+				// registered after the middlewares, the key is still redacted
+				client_http.WithAPIKey(tt.key, apiKeySecret, tt.location),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			ctx := context.Background()
+			var messages []string
+
+			_, err = c.Get(ctx, server.URL+"/missing?page=1")
+			var httpErr *client_http.HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("error = %v, want *HTTPError", err)
+			}
+			messages = append(messages, httpErr.URL, err.Error())
+
+			_, err = c.Get(ctx, server.URL+"/large")
+			var tooLarge *client_http.ResponseTooLargeError
+			if !errors.As(err, &tooLarge) {
+				t.Fatalf("error = %v, want *ResponseTooLargeError", err)
+			}
+			messages = append(messages, tooLarge.URL, err.Error())
+
+			_, err = c.Get(ctx, "http://127.0.0.1:1/unreachable")
+			if err == nil {
+				t.Fatal("expected a connection error")
+			}
+			messages = append(messages, err.Error())
+
+			var recorded bytes.Buffer
+			if _, err := har.WriteTo(&recorded); err != nil {
+				t.Fatal(err)
+			}
+			messages = append(messages, logs.String(), debug.String(), recorded.String())
+
+			for _, message := range messages {
+				if strings.Contains(message, apiKeySecret) {
+					t.Errorf("api key leaked in %q", message)
+				}
+			}
+			if tt.location == client_http.APIKeyInQuery && !strings.Contains(httpErr.URL, "api_key=[REDACTED]") {
+				t.Errorf("url = %q, want the redacted parameter", httpErr.URL)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalancingStrategy - how WithLoadBalancer picks the endpoint of a request
+type BalancingStrategy int
+
+const (
+	// RoundRobin - every endpoint in turn
+	RoundRobin BalancingStrategy = iota
+	// LeastPending - endpoint with the fewest requests in flight
+	LeastPending
+	// WeightedRandom - random endpoint, following the endpoint weights
+	WeightedRandom
+)
+
+// HealthCheck - active probes of WithLoadBalancer endpoints
+type HealthCheck struct {
+	// Path - path requested on every endpoint, a 2xx response is healthy
+	Path string
+	// Interval - time between probes, 10s by default
+	Interval time.Duration
+	// Timeout - max duration of a probe, 2s by default
+	Timeout time.Duration
+	// UnhealthyThreshold - consecutive failed probes ejecting an endpoint, 2 by default
+	UnhealthyThreshold int
+	// HealthyThreshold - consecutive successful probes readmitting an endpoint, 2 by default
+	HealthyThreshold int
+}
+
+// LoadBalancerConfig - endpoints of WithLoadBalancer
+type LoadBalancerConfig struct {
+	// Endpoints - upstream hosts, requests to the first one are balanced across all of them
+	Endpoints []Endpoint
+	Strategy  BalancingStrategy
+	// HealthCheck - active health checks, nil only ejects endpoints failing requests
+	HealthCheck *HealthCheck
+	// MaxFailures - consecutive failed requests (connection errors and 5xx) ejecting an
+	// endpoint, 3 by default
+	MaxFailures int
+	// EjectionTime - time an endpoint ejected by failed requests is skipped, 30s by default
+	EjectionTime time.Duration
+}
+
+// WithLoadBalancer - spread requests to the first endpoint across the endpoints. The
+// path of the endpoint url replaces the path of the first one, the base url is set to
+// the first endpoint when WithBaseURL is not used. Health checks run until Close
+func WithLoadBalancer(config LoadBalancerConfig) Option {
+	return func(c *Client) error {
+		if len(config.Endpoints) == 0 {
+			return fmt.Errorf("load balancer endpoints can't be empty")
+		}
+		origin, err := parseEndpoint(config.Endpoints[0])
+		if err != nil {
+			return err
+		}
+		b, err := newBalancer(origin.url, config)
+		if err != nil {
+			return err
+		}
+		if c.baseURL == "" {
+			c.baseURL = strings.TrimRight(origin.url.String(), "/")
+		}
+		c.routingMiddlewares = append(c.routingMiddlewares, b.middleware(c))
+		if b.healthCheck != nil {
+			c.background = append(c.background, func(ctx context.Context) { b.probe(ctx, c) })
+		}
+		return nil
+	}
+}
+
+// balancer - endpoints of an origin and the state of the strategy
+type balancer struct {
+	// origin - url of the requests sent to the endpoints
+	origin      *url.URL
+	strategy    BalancingStrategy
+	healthCheck *HealthCheck
+	maxFailures int
+	cooldown    time.Duration
+	next        uint64
+
+	mu        sync.RWMutex
+	endpoints []*endpoint
+}
+
+// newBalancer - balancer of the requests to origin, validating config
+func newBalancer(origin *url.URL, config LoadBalancerConfig) (*balancer, error) {
+	if config.Strategy < RoundRobin || config.Strategy > WeightedRandom {
+		return nil, fmt.Errorf("invalid balancing strategy [%d]", config.Strategy)
+	}
+
+	b := &balancer{origin: origin, strategy: config.Strategy, maxFailures: config.MaxFailures, cooldown: config.EjectionTime}
+	if b.maxFailures <= 0 {
+		b.maxFailures = 3
+	}
+	if b.cooldown <= 0 {
+		b.cooldown = 30 * time.Second
+	}
+	if err := b.setEndpoints(config.Endpoints); err != nil {
+		return nil, err
+	}
+	if config.HealthCheck != nil {
+		check := *config.HealthCheck
+		if check.Interval <= 0 {
+			check.Interval = 10 * time.Second
+		}
+		if check.Timeout <= 0 {
+			check.Timeout = 2 * time.Second
+		}
+		if check.UnhealthyThreshold <= 0 {
+			check.UnhealthyThreshold = 2
+		}
+		if check.HealthyThreshold <= 0 {
+			check.HealthyThreshold = 2
+		}
+		b.healthCheck = &check
+	}
+	return b, nil
+}
+
+// setEndpoints - replace the endpoints, the health of the ones already known is kept
+func (b *balancer) setEndpoints(endpoints []Endpoint) error {
+	b.mu.RLock()
+	known := map[string]*endpoint{}
+	for _, e := range b.endpoints {
+		known[e.url.String()] = e
+	}
+	b.mu.RUnlock()
+
+	updated := make([]*endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		parsed, err := parseEndpoint(e)
+		if err != nil {
+			return err
+		}
+		if existing, ok := known[parsed.url.String()]; ok && existing.weight == parsed.weight {
+			parsed = existing
+		}
+		updated = append(updated, parsed)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.endpoints = updated
+	return nil
+}
+
+// current - endpoints of the balancer
+func (b *balancer) current() []*endpoint {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.endpoints
+}
+
+// middleware - send requests to the origin to the endpoint picked by the strategy
+func (b *balancer) middleware(c *Client) Middleware {
+	return b.middlewareWith(c, nil)
+}
+
+// middlewareWith - balancing middleware, discover is called when there are no
+// endpoints yet
+func (b *balancer) middlewareWith(c *Client, discover func(ctx context.Context) error) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			if !sameOrigin(b.origin, request.URL) {
+				return next(request)
+			}
+
+			if len(b.current()) == 0 && discover != nil {
+				if err := discover(request.Context()); err != nil {
+					return nil, err
+				}
+			}
+			e := b.pick()
+			if e == nil {
+				return nil, fmt.Errorf("no endpoints available for [%s]", redactURL(b.origin.String()))
+			}
+			attempt, err := endpointAttempt(request, 0, b.origin, e.url)
+			if err != nil {
+				return nil, err
+			}
+
+			atomic.AddInt64(&e.pending, 1)
+			var once sync.Once
+			done := func() { once.Do(func() { atomic.AddInt64(&e.pending, -1) }) }
+
+			response, err := next(attempt)
+			if err != nil || isServerError(response.StatusCode) {
+				if request.Context().Err() == nil && e.failure(b.maxFailures, b.cooldown) {
+					c.logger.Log(LevelError, "endpoint ejected", map[string]interface{}{"endpoint": redactURL(e.url.String())})
+				}
+			} else {
+				e.success()
+			}
+			if err != nil {
+				done()
+				return nil, err
+			}
+			response.Body = &closeNotifyBody{ReadCloser: response.Body, onClose: done}
+			return response, nil
+		}
+	}
+}
+
+// pick - endpoint of the next request among the available ones, every endpoint is a
+// candidate when all of them are down. Nil when there are no endpoints
+func (b *balancer) pick() *endpoint {
+	endpoints := b.current()
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	candidates := make([]*endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		if !e.down(now) {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = endpoints
+	}
+
+	switch b.strategy {
+	case LeastPending:
+		// start at the round robin position so ties are spread
+		start := int(atomic.AddUint64(&b.next, 1) % uint64(len(candidates)))
+		best := candidates[start]
+		for i := 1; i < len(candidates); i++ {
+			e := candidates[(start+i)%len(candidates)]
+			if atomic.LoadInt64(&e.pending) < atomic.LoadInt64(&best.pending) {
+				best = e
+			}
+		}
+		return best
+	case WeightedRandom:
+		return weightedOrder(candidates)[0]
+	default:
+		return candidates[atomic.AddUint64(&b.next, 1)%uint64(len(candidates))]
+	}
+}
+
+// probe - check the health of every endpoint each interval until ctx is done
+func (b *balancer) probe(ctx context.Context, c *Client) {
+	ticker := time.NewTicker(b.healthCheck.Interval)
+	defer ticker.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, e := range b.current() {
+			wg.Add(1)
+			go func(e *endpoint) {
+				defer wg.Done()
+				healthy := b.check(ctx, c, e)
+				if changed := e.probed(healthy, b.healthCheck); changed {
+					message := "endpoint readmitted"
+					if !healthy {
+						message = "endpoint ejected by health check"
+					}
+					c.logger.Log(LevelInfo, message, map[string]interface{}{"endpoint": redactURL(e.url.String())})
+				}
+			}(e)
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check - request the health check path of e, bypassing the middlewares
+func (b *balancer) check(ctx context.Context, c *Client, e *endpoint) bool {
+	ctx, cancel := context.WithTimeout(ctx, b.healthCheck.Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, e.url.String()+"/"+strings.TrimLeft(b.healthCheck.Path, "/"), nil)
+	if err != nil {
+		return false
+	}
+	response, err := c.Instance.Do(request)
+	if err != nil {
+		return false
+	}
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+	_ = response.Body.Close()
+	return isSuccess(response.StatusCode)
+}
+
+// probed - count a health check result, true when the endpoint is ejected or readmitted
+func (e *endpoint) probed(healthy bool, check *HealthCheck) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch {
+	case healthy && e.probes < 0, !healthy && e.probes > 0:
+		e.probes = 0
+	}
+	if healthy {
+		e.probes++
+		if e.ejected && e.probes >= check.HealthyThreshold {
+			e.ejected = false
+			return true
+		}
+		return false
+	}
+	e.probes--
+	if !e.ejected && -e.probes >= check.UnhealthyThreshold {
+		e.ejected = true
+		return true
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package client_http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// BatchRequest - single request of a batch
+type BatchRequest struct {
+	Method  string
+	URL     string
+	Payload []byte
+	Headers []HeaderParameters
+}
+
+// BatchResult - outcome of the request at the same position of the batch
+type BatchResult struct {
+	Response *Response
+	Err      error
+}
+
+// DoBatch - execute requests with at most concurrency requests in flight (1 when lower).
+// Results are returned in the order of requests, a failed request does not stop the others.
+// Requests not started when ctx is done get the context error
+func (c *Client) DoBatch(ctx context.Context, requests []BatchRequest, concurrency int) []BatchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(requests) {
+		concurrency = len(requests)
+	}
+
+	results := make([]BatchResult, len(requests))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				r := requests[i]
+				if err := ctx.Err(); err != nil {
+					results[i] = BatchResult{Err: err}
+					continue
+				}
+				response, err := c.Do(ctx, r.Method, r.URL, r.Payload, r.Headers...)
+				results[i] = BatchResult{Response: response, Err: err}
+			}
+		}()
+	}
+
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// GetAll - GET every url with at most concurrency requests in flight, see DoBatch
+func (c *Client) GetAll(ctx context.Context, urls []string, concurrency int, headers ...HeaderParameters) []BatchResult {
+	requests := make([]BatchRequest, len(urls))
+	for i, url := range urls {
+		requests[i] = BatchRequest{Method: http.MethodGet, URL: url, Headers: headers}
+	}
+	return c.DoBatch(ctx, requests, concurrency)
+}
@@ -0,0 +1,185 @@
+package client_http_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+// seekerOnly - io.ReadSeeker hiding the io.ReaderAt of the wrapped reader
+type seekerOnly struct {
+	io.ReadSeeker
+}
+
+// tempFile - file holding content, positioned after a prefix that is not sent
+func tempFile(t *testing.T, content []byte) *os.File {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = file.Close() })
+	if _, err := file.Write(append([]byte("prefix"), content...)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Seek(int64(len("prefix")), io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestSeekableBody(t *testing.T) {
+	content := []byte("seekable body bytes")
+	sigV4 := client_http.WithAWSSigV4(client_http.AWSSigV4Config{Region: "eu-west-1", Service: "s3", Credentials: testAWSCredentials})
+	retry := client_http.WithRetry(client_http.RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, RetryableStatusCodes: []int{http.StatusServiceUnavailable}})
+
+	tests := []struct {
+		name    string
+		options []client_http.Option
+		// failures - 503 responses before the request is accepted
+		failures int32
+	}{
+		{name: "sigv4", options: []client_http.Option{sigV4}},
+		{name: "debug", options: []client_http.Option{client_http.WithDebug(ioutil.Discard)}},
+		{name: "retry", options: []client_http.Option{retry}, failures: 2},
+		{name: "sigv4 debug retry", options: []client_http.Option{sigV4, client_http.WithDebug(ioutil.Discard), retry}, failures: 1},
+	}
+	for _, tt := range tests {
+		for _, seeker := range []string{"file", "seeker"} {
+			t.Run(tt.name+" "+seeker, func(t *testing.T) {
+				var attempts int32
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, _ := ioutil.ReadAll(r.Body)
+					if !bytes.Equal(body, content) {
+						t.Errorf("body = %q, want %q", body, content)
+					}
+					if r.Header.Get("Authorization") != "" {
+						if err := verifySigV4(r, body, "eu-west-1", "s3", testAWSCredentials.SecretAccessKey); err != nil {
+							t.Error(err)
+						}
+					}
+					if atomic.AddInt32(&attempts, 1) <= tt.failures {
+						w.WriteHeader(http.StatusServiceUnavailable)
+					}
+				}))
+				defer server.Close()
+
+				c, err := client_http.NewHttpClient(tt.options...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer c.Close()
+
+				var body io.Reader = tempFile(t, content)
+				if seeker == "seeker" {
+					body = seekerOnly{body.(io.ReadSeeker)}
+				}
+				response, err := c.DoReader(context.Background(), http.MethodPut, server.URL, body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if response.StatusCode != http.StatusOK || atomic.LoadInt32(&attempts) != tt.failures+1 {
+					t.Fatalf("status = %d after %d attempts, want 200 after %d", response.StatusCode, atomic.LoadInt32(&attempts), tt.failures+1)
+				}
+			})
+		}
+	}
+}
+
+func TestPipeBody(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "piped" {
+			t.Errorf("body = %q, want piped", body)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithRetry(client_http.RetryConfig{
+		MaxAttempts:          3,
+		BaseDelay:            time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	go func() {
+		_, _ = writer.Write([]byte("piped"))
+		_ = writer.Close()
+	}()
+
+	// a pipe can't seek, it is sent once
+	response, err := c.DoReader(context.Background(), http.MethodPut, server.URL, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusServiceUnavailable || atomic.LoadInt32(&attempts) != 1 {
+		t.Fatalf("status = %d after %d attempts, want 503 after 1", response.StatusCode, atomic.LoadInt32(&attempts))
+	}
+}
+
+func TestHedgingWithBody(t *testing.T) {
+	// large enough for the first attempt to block on the socket while the hedge is sent
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1<<20)
+
+	for _, seeker := range []string{"file", "seeker"} {
+		t.Run(seeker, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) == 1 {
+					// the first attempt reads slowly and wins
+					head := make([]byte, 1024)
+					_, _ = io.ReadFull(r.Body, head)
+					time.Sleep(200 * time.Millisecond)
+					rest, _ := ioutil.ReadAll(r.Body)
+					if !bytes.Equal(append(head, rest...), content) {
+						w.WriteHeader(http.StatusBadRequest)
+					}
+					return
+				}
+				_, _ = io.Copy(ioutil.Discard, r.Body)
+				time.Sleep(time.Second)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			c, err := client_http.NewHttpClient(client_http.WithHedging(20*time.Millisecond, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			var body io.Reader = tempFile(t, content)
+			if seeker == "seeker" {
+				body = seekerOnly{body.(io.ReadSeeker)}
+			}
+			response, err := c.DoReader(context.Background(), http.MethodPut, server.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != http.StatusOK || atomic.LoadInt32(&attempts) != 2 {
+				t.Fatalf("status = %d after %d attempts, want 200 from the first attempt with the whole body", response.StatusCode, atomic.LoadInt32(&attempts))
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package client_http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RequestBuilder - fluent construction of a single request:
+//
+//	c.NewRequest().Method("POST").URL(u).Header("X-A", "b").Query("page", "2").BodyJSON(v).Do(ctx)
+//
+// The first error found while building is returned by Do
+type RequestBuilder struct {
+	client *Client
+
+	method  string
+	url     string
+	headers []HeaderParameters
+	query   url.Values
+	body    io.Reader
+
+	basicAuth bool
+	username  string
+	password  string
+	// insecure - skip TLS verification for this request
+	insecure bool
+	// retry - retry configuration of this request, see RetryOverride
+	retry *RetryConfig
+	// idempotent - the request can be retried although its method is not idempotent
+	idempotent bool
+	// priority - order of the request among the ones waiting for a slot, see MarkPriority
+	priority *Priority
+	// trailers - trailers sent after the body, see MarkTrailers
+	trailers *RequestTrailers
+	// schema - validator of the response, see MarkSchema
+	schema SchemaValidator
+
+	err error
+}
+
+// NewRequest - start building a GET request
+func (c *Client) NewRequest() *RequestBuilder {
+	return &RequestBuilder{client: c, method: http.MethodGet, query: url.Values{}}
+}
+
+// Method - http method of the request
+func (b *RequestBuilder) Method(method string) *RequestBuilder {
+	b.method = method
+	return b
+}
+
+// URL - absolute url or path relative to the client base url
+func (b *RequestBuilder) URL(url string) *RequestBuilder {
+	b.url = url
+	return b
+}
+
+// Header - set header key, replacing previous values
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.headers = append(b.headers, HeaderParameters{Key: key, Value: value})
+	return b
+}
+
+// AddHeader - add a value to header key, keeping previous values
+func (b *RequestBuilder) AddHeader(key, value string) *RequestBuilder {
+	b.headers = append(b.headers, AddHeader(key, value))
+	return b
+}
+
+// Headers - set several headers, see HeadersFrom and HeadersFromMap
+func (b *RequestBuilder) Headers(headers ...HeaderParameters) *RequestBuilder {
+	b.headers = append(b.headers, headers...)
+	return b
+}
+
+// Query - add a query parameter, repeated keys keep every value
+func (b *RequestBuilder) Query(key, value string) *RequestBuilder {
+	b.query.Add(key, value)
+	return b
+}
+
+// QueryValues - add all query parameters of values
+func (b *RequestBuilder) QueryValues(values url.Values) *RequestBuilder {
+	for key, vs := range values {
+		for _, v := range vs {
+			b.query.Add(key, v)
+		}
+	}
+	return b
+}
+
+// QueryStruct - add the query parameters of struct v, see EncodeQuery
+func (b *RequestBuilder) QueryStruct(v interface{}) *RequestBuilder {
+	values, err := EncodeQuery(v)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	return b.QueryValues(values)
+}
+
+// Body - raw payload of the request
+func (b *RequestBuilder) Body(payload []byte) *RequestBuilder {
+	b.body = bytes.NewReader(payload)
+	return b
+}
+
+// BodyReader - payload read from reader. bytes.Buffer, bytes.Reader, strings.Reader and
+// io.ReadSeeker bodies (files) are rewound for retries, redirects and hedging, seekers are
+// read from their current offset and not closed. Other readers, and files that can't seek
+// like pipes, are sent once without retries
+func (b *RequestBuilder) BodyReader(reader io.Reader) *RequestBuilder {
+	b.body = reader
+	return b
+}
+
+// BodyJSON - payload encoded as json, Content-Type is set to application/json
+func (b *RequestBuilder) BodyJSON(v interface{}) *RequestBuilder {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		b.setErr(fmt.Errorf("error encoding json request [%w]", err))
+		return b
+	}
+	b.body = bytes.NewReader(payload)
+	b.headers = append([]HeaderParameters{{Key: "Content-Type", Value: contentTypeJSON}}, b.headers...)
+	return b
+}
+
+// BodyForm - payload encoded as url encoded form from struct v (see EncodeForm) or
+// url.Values, Content-Type is set to application/x-www-form-urlencoded
+func (b *RequestBuilder) BodyForm(v interface{}) *RequestBuilder {
+	values, err := formValues(v)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.body = strings.NewReader(values.Encode())
+	b.headers = append([]HeaderParameters{{Key: "Content-Type", Value: contentTypeForm}}, b.headers...)
+	return b
+}
+
+// BodyEncoded - payload of v encoded as mediaType with the encoders of the client (see
+// WithEncoder), Content-Type is set to mediaType
+func (b *RequestBuilder) BodyEncoded(mediaType string, v interface{}) *RequestBuilder {
+	payload, err := b.client.encode(mediaType, v)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.body = bytes.NewReader(payload)
+	b.headers = append([]HeaderParameters{{Key: "Content-Type", Value: mediaType}}, b.headers...)
+	return b
+}
+
+// BasicAuth - authenticate the request with username and password
+func (b *RequestBuilder) BasicAuth(username, password string) *RequestBuilder {
+	b.basicAuth = true
+	b.username = username
+	b.password = password
+	return b
+}
+
+// BearerToken - authenticate the request with a bearer token
+func (b *RequestBuilder) BearerToken(accessToken string) *RequestBuilder {
+	b.headers = append(b.headers, BearerHeader(accessToken))
+	return b
+}
+
+// Retry - retry this request following config instead of the client configuration
+func (b *RequestBuilder) Retry(config RetryConfig) *RequestBuilder {
+	b.retry = &config
+	return b
+}
+
+// NoRetry - send this request once
+func (b *RequestBuilder) NoRetry() *RequestBuilder {
+	return b.Retry(RetryConfig{MaxAttempts: 1})
+}
+
+// Idempotent - allow retries of this non-idempotent request, see RetryConfig.RetryNonIdempotent
+func (b *RequestBuilder) Idempotent() *RequestBuilder {
+	b.idempotent = true
+	return b
+}
+
+// UserAgent - User-Agent of this request
+func (b *RequestBuilder) UserAgent(userAgent string) *RequestBuilder {
+	return b.Header("User-Agent", userAgent)
+}
+
+// Priority - priority of this request when waiting for a slot of its host
+func (b *RequestBuilder) Priority(priority Priority) *RequestBuilder {
+	b.priority = &priority
+	return b
+}
+
+// Trailers - send trailers after the body of this request
+func (b *RequestBuilder) Trailers(trailers RequestTrailers) *RequestBuilder {
+	b.trailers = &trailers
+	return b
+}
+
+// Schema - check the 2xx JSON response of this request with validator, see WithSchema
+func (b *RequestBuilder) Schema(validator SchemaValidator) *RequestBuilder {
+	b.schema = validator
+	return b
+}
+
+// Build - the http request as it will be sent, before client level settings
+func (b *RequestBuilder) Build(ctx context.Context) (*http.Request, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	url, err := AppendQuery(b.url, b.query)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.insecure {
+		ctx = InsecureSkipVerify(ctx)
+	}
+	if b.retry != nil {
+		ctx = RetryOverride(ctx, *b.retry)
+	}
+	if b.idempotent {
+		ctx = MarkIdempotent(ctx)
+	}
+	if b.priority != nil {
+		ctx = MarkPriority(ctx, *b.priority)
+	}
+	if b.trailers != nil {
+		ctx = MarkTrailers(ctx, *b.trailers)
+	}
+	if b.schema != nil {
+		ctx = MarkSchema(ctx, b.schema)
+	}
+
+	request, err := b.client.newStreamRequest(ctx, b.method, url, b.body, b.headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.basicAuth {
+		request.SetBasicAuth(b.username, b.password)
+	}
+
+	return request, nil
+}
+
+// Do - execute the request reading the whole response
+func (b *RequestBuilder) Do(ctx context.Context) (*Response, error) {
+	request, err := b.Build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.client.execute(request)
+}
+
+// Stream - execute the request returning the unread response body
+func (b *RequestBuilder) Stream(ctx context.Context) (*StreamResponse, error) {
+	request, err := b.Build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.client.stream(request)
+}
+
+// Raw - execute the request returning the unread *http.Response, see Client.DoRaw
+func (b *RequestBuilder) Raw(ctx context.Context) (*http.Response, error) {
+	request, err := b.Build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.client.SendRaw(request)
+}
+
+// setErr - keep the first building error
+func (b *RequestBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,193 @@
+package client_http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RejectedError - request refused by the bulkhead of its host without being sent
+type RejectedError struct {
+	Host string
+	// Reason - "queue full" or "wait timeout"
+	Reason string
+}
+
+// Error - host and reason
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("request to [%s] rejected by bulkhead - %s", e.Host, e.Reason)
+}
+
+// Bulkhead - limits of WithBulkhead
+type Bulkhead struct {
+	// MaxConcurrent - requests in flight per host
+	MaxConcurrent int
+	// MaxQueue - requests waiting for a slot per host, more are rejected. Zero rejects
+	// every request over MaxConcurrent
+	MaxQueue int
+	// MaxWait - max time waiting for a slot, zero waits until the request context is done
+	MaxWait time.Duration
+}
+
+// WithBulkhead - isolate hosts so a slow one can't take every goroutine and connection of
+// the client. Requests over the limits fail with *RejectedError, a request holds its slot
+// until its body is closed
+func WithBulkhead(config Bulkhead) Option {
+	return func(c *Client) error {
+		if config.MaxConcurrent < 1 || config.MaxQueue < 0 || config.MaxWait < 0 {
+			return fmt.Errorf("invalid bulkhead [%d] concurrent - [%d] queued - [%v] wait", config.MaxConcurrent, config.MaxQueue, config.MaxWait)
+		}
+		hosts := &bulkheadHosts{config: config, semaphores: map[string]*semaphore{}}
+		c.internalMiddlewares = append(c.internalMiddlewares, hosts.middleware)
+		return nil
+	}
+}
+
+// bulkheadHosts - one semaphore per host
+type bulkheadHosts struct {
+	config Bulkhead
+
+	mu         sync.Mutex
+	semaphores map[string]*semaphore
+}
+
+// semaphore - semaphore of host, created on first use
+func (h *bulkheadHosts) semaphore(host string) *semaphore {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.semaphores[host]
+	if !ok {
+		s = newSemaphore(h.config.MaxConcurrent)
+		h.semaphores[host] = s
+	}
+	return s
+}
+
+// middleware - send the request once a slot of its host is free
+func (h *bulkheadHosts) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		host := strings.ToLower(request.URL.Host)
+		s := h.semaphore(host)
+
+		ctx := request.Context()
+		if h.config.MaxWait > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, h.config.MaxWait)
+			defer cancel()
+		}
+		if err := s.acquire(ctx, requestPriority(request), h.config.MaxQueue); err != nil {
+			switch {
+			case errors.Is(err, errQueueFull):
+				return nil, &RejectedError{Host: host, Reason: "queue full"}
+			case request.Context().Err() == nil:
+				return nil, &RejectedError{Host: host, Reason: "wait timeout"}
+			}
+			return nil, fmt.Errorf("bulkhead wait cancelled [%w]", err)
+		}
+
+		var once sync.Once
+		release := func() { once.Do(s.release) }
+		response, err := next(request)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		response.Body = &closeNotifyBody{ReadCloser: response.Body, onClose: release}
+		return response, nil
+	}
+}
+
+// errQueueFull - no room left among the waiters of a semaphore
+var errQueueFull = errors.New("queue full")
+
+// semaphore - slots with a queue of waiters served by priority then arrival order, the
+// capacity can change while in use
+type semaphore struct {
+	mu       sync.Mutex
+	capacity int
+	inflight int
+	waiters  []*waiter
+}
+
+// waiter - request waiting for a slot, the slot is handed over by closing the channel
+type waiter struct {
+	slot     chan struct{}
+	priority Priority
+}
+
+// newSemaphore - semaphore of capacity slots
+func newSemaphore(capacity int) *semaphore {
+	return &semaphore{capacity: capacity}
+}
+
+// acquire - take a slot, waiting behind the waiters of the same or higher priority until
+// one is free or ctx is done. Negative maxQueue means no queue limit
+func (s *semaphore) acquire(ctx context.Context, priority Priority, maxQueue int) error {
+	s.mu.Lock()
+	if len(s.waiters) == 0 && s.inflight < s.capacity {
+		s.inflight++
+		s.mu.Unlock()
+		return nil
+	}
+	if maxQueue >= 0 && len(s.waiters) >= maxQueue {
+		s.mu.Unlock()
+		return errQueueFull
+	}
+	w := &waiter{slot: make(chan struct{}), priority: priority}
+	i := len(s.waiters)
+	for i > 0 && s.waiters[i-1].priority < priority {
+		i--
+	}
+	s.waiters = append(s.waiters, nil)
+	copy(s.waiters[i+1:], s.waiters[i:])
+	s.waiters[i] = w
+	s.mu.Unlock()
+
+	select {
+	case <-w.slot:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, queued := range s.waiters {
+			if queued == w {
+				s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// the slot was handed over while cancelling
+		s.inflight--
+		s.wake()
+		return ctx.Err()
+	}
+}
+
+// release - give back a slot
+func (s *semaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight--
+	s.wake()
+}
+
+// resize - change the number of slots, requests in flight beyond it keep their slot
+func (s *semaphore) resize(capacity int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.capacity = capacity
+	s.wake()
+}
+
+// wake - hand the free slots to the waiters, the lock must be held
+func (s *semaphore) wake() {
+	for len(s.waiters) > 0 && s.inflight < s.capacity {
+		close(s.waiters[0].slot)
+		s.waiters = s.waiters[1:]
+		s.inflight++
+	}
+}
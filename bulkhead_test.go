@@ -0,0 +1,73 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestBulkhead(t *testing.T) {
+	started := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-unblock
+		}
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithBulkhead(client_http.Bulkhead{
+		MaxConcurrent: 1,
+		MaxQueue:      1,
+		MaxWait:       200 * time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background(), server.URL+"/slow")
+		slow <- err
+	}()
+	<-started
+
+	queued := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background(), server.URL+"/queued")
+		queued <- err
+	}()
+	// let the second request take the only place of the queue
+	time.Sleep(50 * time.Millisecond)
+
+	var rejected *client_http.RejectedError
+	_, err = c.Get(context.Background(), server.URL+"/full")
+	if !errors.As(err, &rejected) || rejected.Reason != "queue full" {
+		t.Fatalf("error = %v, want rejected with a full queue", err)
+	}
+	if err := <-queued; !errors.As(err, &rejected) || rejected.Reason != "wait timeout" {
+		t.Fatalf("error = %v, want rejected after waiting 200ms", err)
+	}
+
+	// other hosts have their own slots
+	other := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	if _, err := c.Get(context.Background(), other+"/other"); err != nil {
+		t.Fatalf("error = %v, want the request to another host sent", err)
+	}
+
+	close(unblock)
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(context.Background(), server.URL+"/after"); err != nil {
+		t.Fatalf("error = %v, want the slot released with the body", err)
+	}
+}
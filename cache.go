@@ -0,0 +1,695 @@
+package client_http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheHeader - header set on responses served from the cache
+const cacheHeader = "X-From-Cache"
+
+// maxCacheEntrySize - responses with larger bodies are not stored
+const maxCacheEntrySize = 10 << 20
+
+// Cache - storage of the http cache, values are serialized responses. Implementations
+// must be safe for concurrent use, see NewMemoryCache and NewDiskCache
+type Cache interface {
+	// Get - value of key, false when it is not stored
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set - store value under key
+	Set(ctx context.Context, key string, value []byte) error
+	// Delete - drop key, missing keys are not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// WithCache - keep GET responses in an in-memory RFC 7234 private cache of up to maxBytes,
+// zero means no limit. Fresh responses are served without a request, stale ones are
+// revalidated with If-None-Match / If-Modified-Since and a 304 returns the cached body.
+// Stale responses are served as allowed by the RFC 5861 stale-while-revalidate and
+// stale-if-error directives
+func WithCache(maxBytes int64, opts ...CacheOption) Option {
+	return func(c *Client) error {
+		if maxBytes < 0 {
+			return fmt.Errorf("invalid cache size [%d]", maxBytes)
+		}
+		c.cache = newHTTPCache(NewMemoryCache(maxBytes), opts)
+		return nil
+	}
+}
+
+// WithCacheBackend - like WithCache storing responses in cache, so they survive restarts
+// (NewDiskCache) or are shared by replicas (clienthttpredis)
+func WithCacheBackend(cache Cache, opts ...CacheOption) Option {
+	return func(c *Client) error {
+		if cache == nil {
+			return fmt.Errorf("cache can't be nil")
+		}
+		c.cache = newHTTPCache(cache, opts)
+		return nil
+	}
+}
+
+// FromCache - the response was served from the cache, with or without revalidation
+func (r *Response) FromCache() bool {
+	return r.Headers.Get(cacheHeader) != ""
+}
+
+// httpCache - RFC 7234 caching of responses stored in a Cache by url
+type httpCache struct {
+	store Cache
+	now   func() time.Time
+	// staleWhileRevalidateDefault, staleIfErrorDefault - windows of responses without
+	// the RFC 5861 directives
+	staleWhileRevalidateDefault time.Duration
+	staleIfErrorDefault         time.Duration
+
+	mu sync.Mutex
+	// refreshing - urls being revalidated in background
+	refreshing map[string]bool
+}
+
+// CacheOption - configures the cache of WithCache and WithCacheBackend
+type CacheOption func(h *httpCache)
+
+// StaleWhileRevalidate - serve responses stale for up to window while they are revalidated
+// in background, used when the response has no stale-while-revalidate directive
+func StaleWhileRevalidate(window time.Duration) CacheOption {
+	return func(h *httpCache) {
+		h.staleWhileRevalidateDefault = window
+	}
+}
+
+// StaleIfError - serve responses stale for up to window when the origin fails or returns
+// 5xx, used when neither the request nor the response has a stale-if-error directive
+func StaleIfError(window time.Duration) CacheOption {
+	return func(h *httpCache) {
+		h.staleIfErrorDefault = window
+	}
+}
+
+// cacheEntry - stored response, immutable once stored
+type cacheEntry struct {
+	Key        string      `json:"key"`
+	StatusCode int         `json:"status_code"`
+	Status     string      `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	// Vary - request header values selected by the Vary header and Authorization
+	Vary map[string]string `json:"vary"`
+	// RequestTime, ResponseTime - when the request was sent and the response received
+	RequestTime  time.Time `json:"request_time"`
+	ResponseTime time.Time `json:"response_time"`
+}
+
+// newHTTPCache - http cache storing entries in store
+func newHTTPCache(store Cache, opts []CacheOption) *httpCache {
+	h := &httpCache{store: store, now: time.Now, refreshing: map[string]bool{}}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// middleware - serve fresh responses, revalidate stale ones and store cacheable
+// responses. Errors of the store are logged and handled as cache misses
+func (h *httpCache) middleware(c *Client) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			ctx := request.Context()
+			logError := func(message string, err error) {
+				c.logger.Log(LevelError, message, map[string]interface{}{"url": c.redactURL(request.URL.String()), "error": err})
+			}
+
+			if request.Method != http.MethodGet {
+				response, err := next(request)
+				if err == nil && !isSafeMethod(request.Method) && response.StatusCode < 400 {
+					if err := h.invalidate(request, response); err != nil {
+						logError("error invalidating cached response", err)
+					}
+				}
+				return response, err
+			}
+
+			requestCC := parseCacheControl(request.Header)
+			if len(requestCC) == 0 && strings.Contains(strings.ToLower(request.Header.Get("Pragma")), "no-cache") {
+				requestCC["no-cache"] = ""
+			}
+			if _, ok := requestCC["no-store"]; ok || hasConditionalHeaders(request) {
+				return next(request)
+			}
+
+			key := cacheKey(request.URL)
+			entry, err := h.get(ctx, key)
+			if err != nil {
+				logError("error reading cached response", err)
+			}
+			if entry != nil && !entry.matches(request) {
+				entry = nil
+			}
+
+			if entry != nil && h.fresh(entry, requestCC) {
+				return entry.response(request, h.now(), "HIT"), nil
+			}
+			if _, ok := requestCC["only-if-cached"]; ok {
+				return gatewayTimeout(request), nil
+			}
+
+			if entry != nil && h.staleWhileRevalidate(entry, requestCC) {
+				h.revalidate(c, next, request, entry, logError)
+				return entry.stale(request, h.now(), "110 - \"Response is Stale\""), nil
+			}
+
+			response, err := h.fetch(next, request, entry, logError)
+			if entry != nil && (err != nil || isServerError(response.StatusCode)) && h.staleIfError(entry, requestCC) {
+				if response != nil {
+					_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+					_ = response.Body.Close()
+				}
+				return entry.stale(request, h.now(), "111 - \"Revalidation Failed\""), nil
+			}
+			return response, err
+		}
+	}
+}
+
+// fetch - send request, conditional when entry is set, and store the response. A 304
+// returns the cached body with the updated headers
+func (h *httpCache) fetch(next RoundTripFunc, request *http.Request, entry *cacheEntry, logError func(string, error)) (*http.Response, error) {
+	ctx := request.Context()
+	key := cacheKey(request.URL)
+	if entry != nil {
+		request = request.Clone(ctx)
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+			request.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	requestTime := h.now()
+	response, err := next(request)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := h.now()
+
+	if entry != nil && response.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+		_ = response.Body.Close()
+
+		updated := entry.revalidated(response.Header, requestTime, responseTime)
+		if storable(updated.StatusCode, updated.Header) {
+			err = h.set(ctx, updated)
+		} else {
+			err = h.store.Delete(ctx, key)
+		}
+		if err != nil {
+			logError("error updating cached response", err)
+		}
+		return updated.response(request, h.now(), "REVALIDATED"), nil
+	}
+
+	if !storable(response.StatusCode, response.Header) {
+		// server errors keep the entry, it may still be served by stale-if-error
+		if entry != nil && !isServerError(response.StatusCode) {
+			if err := h.store.Delete(ctx, key); err != nil {
+				logError("error deleting cached response", err)
+			}
+		}
+		return response, nil
+	}
+
+	stored := &cacheEntry{
+		Key:          key,
+		StatusCode:   response.StatusCode,
+		Status:       response.Status,
+		Header:       response.Header.Clone(),
+		Vary:         varyValues(request, response.Header),
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+	}
+	response.Body = &cacheBody{body: response.Body, max: maxCacheEntrySize, done: func(body []byte) {
+		stored.Body = body
+		if err := h.set(context.Background(), stored); err != nil {
+			logError("error storing response", err)
+		}
+	}}
+	return response, nil
+}
+
+// revalidate - refresh the entry in background, once at a time per url. The request
+// keeps the values of its context but not its cancellation, it is cancelled when the
+// client is closed and Close waits for it
+func (h *httpCache) revalidate(c *Client, next RoundTripFunc, request *http.Request, entry *cacheEntry, logError func(string, error)) {
+	key := cacheKey(request.URL)
+	h.mu.Lock()
+	if h.refreshing[key] {
+		h.mu.Unlock()
+		return
+	}
+	h.refreshing[key] = true
+	h.mu.Unlock()
+
+	done := func() {
+		h.mu.Lock()
+		delete(h.refreshing, key)
+		h.mu.Unlock()
+	}
+
+	request = request.Clone(detachedContext{Context: request.Context(), lifetime: c.lifetime})
+	err := c.goBackground(func() {
+		defer done()
+
+		response, err := h.fetch(next, request, entry, logError)
+		if err != nil {
+			logError("error revalidating cached response", err)
+			return
+		}
+		// reading the body stores the new response
+		_, _ = io.Copy(ioutil.Discard, response.Body)
+		_ = response.Body.Close()
+	})
+	if err != nil {
+		// closed client, the stale response is served without revalidation
+		done()
+	}
+}
+
+// staleWhileRevalidate - entry is stale but can be served while it is revalidated, not
+// when the request asks for a fresher response
+func (h *httpCache) staleWhileRevalidate(entry *cacheEntry, requestCC map[string]string) bool {
+	for _, directive := range []string{"no-cache", "max-age", "min-fresh"} {
+		if _, ok := requestCC[directive]; ok {
+			return false
+		}
+	}
+	window, ok := cacheSeconds(parseCacheControl(entry.Header), "stale-while-revalidate")
+	if !ok {
+		window = h.staleWhileRevalidateDefault
+	}
+	return window > 0 && entry.staleAllowed() && entry.staleness(h.now()) <= window
+}
+
+// staleIfError - entry can be served when the origin fails, the request directive takes
+// precedence over the response one
+func (h *httpCache) staleIfError(entry *cacheEntry, requestCC map[string]string) bool {
+	window, ok := cacheSeconds(requestCC, "stale-if-error")
+	if !ok {
+		if window, ok = cacheSeconds(parseCacheControl(entry.Header), "stale-if-error"); !ok {
+			window = h.staleIfErrorDefault
+		}
+	}
+	return window > 0 && entry.staleAllowed() && entry.staleness(h.now()) <= window
+}
+
+// get - entry of key, nil when not cached
+func (h *httpCache) get(ctx context.Context, key string) (*cacheEntry, error) {
+	value, ok, err := h.store.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(value, entry); err != nil {
+		return nil, fmt.Errorf("error decoding cache entry [%s] - [%w]", key, err)
+	}
+	return entry, nil
+}
+
+// set - store entry
+func (h *httpCache) set(ctx context.Context, entry *cacheEntry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding cache entry [%s] - [%w]", entry.Key, err)
+	}
+	return h.store.Set(ctx, entry.Key, value)
+}
+
+// invalidate - drop the entries of the url, Location and Content-Location changed by
+// an unsafe request (RFC 7234 section 4.4)
+func (h *httpCache) invalidate(request *http.Request, response *http.Response) error {
+	ctx := request.Context()
+	if err := h.store.Delete(ctx, cacheKey(request.URL)); err != nil {
+		return err
+	}
+	for _, name := range []string{"Location", "Content-Location"} {
+		location := response.Header.Get(name)
+		if location == "" {
+			continue
+		}
+		u, err := request.URL.Parse(location)
+		if err == nil && u.Host == request.URL.Host {
+			if err := h.store.Delete(ctx, cacheKey(u)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fresh - entry can be served without revalidation under the request directives
+func (h *httpCache) fresh(entry *cacheEntry, requestCC map[string]string) bool {
+	if _, ok := requestCC["no-cache"]; ok {
+		return false
+	}
+
+	lifetime := entry.lifetime()
+	age := entry.age(h.now())
+
+	if maxAge, ok := cacheSeconds(requestCC, "max-age"); ok && age > maxAge {
+		return false
+	}
+	if minFresh, ok := cacheSeconds(requestCC, "min-fresh"); ok {
+		age += minFresh
+	}
+	if age < lifetime {
+		return true
+	}
+
+	// max-stale accepts stale responses unless the server requires revalidation
+	responseCC := parseCacheControl(entry.Header)
+	if _, ok := responseCC["must-revalidate"]; ok {
+		return false
+	}
+	if _, ok := responseCC["no-cache"]; ok {
+		return false
+	}
+	if value, ok := requestCC["max-stale"]; ok {
+		if value == "" {
+			return true
+		}
+		maxStale, ok := cacheSeconds(requestCC, "max-stale")
+		return ok && age-lifetime <= maxStale
+	}
+	return false
+}
+
+// lifetime - freshness lifetime of the entry (RFC 7234 section 4.2.1), max-age, then
+// Expires, then 10% of the time since Last-Modified up to one day
+func (e *cacheEntry) lifetime() time.Duration {
+	responseCC := parseCacheControl(e.Header)
+	if _, ok := responseCC["no-cache"]; ok {
+		return 0
+	}
+	if maxAge, ok := cacheSeconds(responseCC, "max-age"); ok {
+		return maxAge
+	}
+
+	date := e.date()
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+
+	if lastModified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil && heuristicallyCacheable(e.StatusCode) {
+		lifetime := date.Sub(lastModified) / 10
+		if lifetime > 24*time.Hour {
+			lifetime = 24 * time.Hour
+		}
+		return lifetime
+	}
+	return 0
+}
+
+// age - current age of the entry (RFC 7234 section 4.2.3)
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	apparentAge := e.ResponseTime.Sub(e.date())
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+
+	correctedAge := e.ResponseTime.Sub(e.RequestTime)
+	if seconds, err := strconv.ParseInt(strings.TrimSpace(e.Header.Get("Age")), 10, 64); err == nil && seconds > 0 {
+		correctedAge += time.Duration(seconds) * time.Second
+	}
+
+	if apparentAge > correctedAge {
+		correctedAge = apparentAge
+	}
+	return correctedAge + now.Sub(e.ResponseTime)
+}
+
+// staleness - time since the entry became stale, negative while it is fresh
+func (e *cacheEntry) staleness(now time.Time) time.Duration {
+	return e.age(now) - e.lifetime()
+}
+
+// staleAllowed - the server allows serving the entry stale
+func (e *cacheEntry) staleAllowed() bool {
+	responseCC := parseCacheControl(e.Header)
+	_, mustRevalidate := responseCC["must-revalidate"]
+	_, noCache := responseCC["no-cache"]
+	return !mustRevalidate && !noCache
+}
+
+// date - Date header of the entry, the response time when missing
+func (e *cacheEntry) date() time.Time {
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return date
+	}
+	return e.ResponseTime
+}
+
+// matches - request selects the entry, comparing the headers listed by Vary
+func (e *cacheEntry) matches(request *http.Request) bool {
+	for name, value := range e.Vary {
+		if requestVaryValue(request, name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// revalidated - copy of the entry with the headers of a 304 response
+func (e *cacheEntry) revalidated(header http.Header, requestTime, responseTime time.Time) *cacheEntry {
+	updated := *e
+	updated.Header = e.Header.Clone()
+	for name, values := range header {
+		if name == "Content-Length" || name == "Transfer-Encoding" {
+			continue
+		}
+		updated.Header[name] = values
+	}
+	updated.RequestTime = requestTime
+	updated.ResponseTime = responseTime
+	return &updated
+}
+
+// response - http response of the entry for request, marked with source
+func (e *cacheEntry) response(request *http.Request, now time.Time, source string) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+	header.Set(cacheHeader, source)
+	return &http.Response{
+		Status:        e.Status,
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       request,
+	}
+}
+
+// stale - response of the stale entry with a Warning header (RFC 7234 section 5.5)
+func (e *cacheEntry) stale(request *http.Request, now time.Time, warning string) *http.Response {
+	response := e.response(request, now, "STALE")
+	response.Header.Add("Warning", warning)
+	return response
+}
+
+// cacheBody - response body kept in memory, done is called with the whole body on EOF
+// when it is not larger than max
+type cacheBody struct {
+	body    io.ReadCloser
+	max     int64
+	content []byte
+	skip    bool
+	done    func(body []byte)
+}
+
+// Read - read and keep the content
+func (b *cacheBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if !b.skip {
+		b.content = append(b.content, p[:n]...)
+		if b.max > 0 && int64(len(b.content)) > b.max {
+			b.skip, b.content = true, nil
+		}
+	}
+	if err == io.EOF && !b.skip {
+		b.skip = true
+		b.done(b.content)
+	}
+	return n, err
+}
+
+// Close - close the body, partially read bodies are not stored
+func (b *cacheBody) Close() error {
+	b.skip = true
+	return b.body.Close()
+}
+
+// storable - response can be stored by a private cache (RFC 7234 section 3)
+func storable(statusCode int, header http.Header) bool {
+	responseCC := parseCacheControl(header)
+	if _, ok := responseCC["no-store"]; ok {
+		return false
+	}
+	if strings.TrimSpace(header.Get("Vary")) == "*" {
+		return false
+	}
+
+	_, maxAge := responseCC["max-age"]
+	explicit := maxAge || header.Get("Expires") != ""
+	if !heuristicallyCacheable(statusCode) && !(explicit && statusCode >= 200 && statusCode != http.StatusPartialContent && statusCode != http.StatusNotModified) {
+		return false
+	}
+
+	_, public := responseCC["public"]
+	return explicit || public || header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+// heuristicallyCacheable - status codes cacheable by default (RFC 7231 section 6.1)
+func heuristicallyCacheable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent, http.StatusMultipleChoices,
+		http.StatusMovedPermanently, http.StatusPermanentRedirect, http.StatusNotFound, http.StatusMethodNotAllowed,
+		http.StatusGone, http.StatusRequestURITooLong, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// parseCacheControl - directives of the Cache-Control headers, names are lower case and
+// quoted values are unquoted
+func parseCacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value := part, ""
+			if eq := strings.IndexByte(part, '='); eq >= 0 {
+				name, value = strings.TrimSpace(part[:eq]), strings.Trim(strings.TrimSpace(part[eq+1:]), `"`)
+			}
+			directives[strings.ToLower(name)] = value
+		}
+	}
+	return directives
+}
+
+// cacheSeconds - delta-seconds value of directive
+func cacheSeconds(directives map[string]string, directive string) (time.Duration, bool) {
+	value, ok := directives[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// varyValues - request values of the headers listed by Vary. Authorization is always
+// included so credentials never share entries
+func varyValues(request *http.Request, header http.Header) map[string]string {
+	values := map[string]string{"Authorization": requestVaryValue(request, "Authorization")}
+	for _, vary := range header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			if name = http.CanonicalHeaderKey(strings.TrimSpace(name)); name != "" {
+				values[name] = requestVaryValue(request, name)
+			}
+		}
+	}
+	return values
+}
+
+// requestVaryValue - value of the request header compared by Vary, Authorization is hashed
+func requestVaryValue(request *http.Request, name string) string {
+	value := strings.Join(request.Header.Values(name), ", ")
+	if name == "Authorization" && value != "" {
+		return sha256Hex([]byte(value))
+	}
+	return value
+}
+
+// hasConditionalHeaders - request manages validation or ranges itself and bypasses the cache
+func hasConditionalHeaders(request *http.Request) bool {
+	for _, name := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range", "Range"} {
+		if request.Header.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isServerError - 5xx status
+func isServerError(statusCode int) bool {
+	return statusCode >= 500 && statusCode <= 599
+}
+
+// isSafeMethod - methods not changing the server state
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// cacheKey - url of the entry without fragment
+func cacheKey(u *url.URL) string {
+	key := *u
+	key.Fragment = ""
+	key.RawFragment = ""
+	return key.String()
+}
+
+// gatewayTimeout - answer of only-if-cached requests without a usable entry
+func gatewayTimeout(request *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		Request:    request,
+	}
+}
+
+// detachedContext - values of a context without its deadline and cancellation, done
+// with lifetime instead
+type detachedContext struct {
+	context.Context
+	lifetime context.Context
+}
+
+// Deadline - no deadline
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+// Done - closed with lifetime
+func (d detachedContext) Done() <-chan struct{} { return d.lifetime.Done() }
+
+// Err - error of lifetime
+func (d detachedContext) Err() error { return d.lifetime.Err() }
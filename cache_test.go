@@ -0,0 +1,152 @@
+package client_http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+// cacheOrigin - origin of the cache tests counting the requests and the conditional
+// requests it receives. /fresh is fresh for 1s with an ETag, /no-store is never stored
+// and /flaky is fresh for 1s, failing requests with an X-Fail header
+type cacheOrigin struct {
+	requests    int32
+	conditional int32
+}
+
+func (o *cacheOrigin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&o.requests, 1)
+	switch r.URL.Path {
+	case "/fresh":
+		w.Header().Set("Cache-Control", "max-age=1")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&o.conditional, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("fresh"))
+	case "/no-store":
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write([]byte("no-store"))
+	case "/flaky":
+		if r.Header.Get("X-Fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=1")
+		_, _ = w.Write([]byte("flaky"))
+	}
+}
+
+func TestCacheFreshness(t *testing.T) {
+	origin := &cacheOrigin{}
+	server := httptest.NewServer(origin)
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithCache(0, client_http.StaleIfError(time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	get := func(path string, wantBody, wantSource string, headers ...client_http.HeaderParameters) {
+		t.Helper()
+		response, err := c.Get(context.Background(), server.URL+path, headers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(response.Body) != wantBody || response.Headers.Get("X-From-Cache") != wantSource {
+			t.Fatalf("%s: body %q from cache %q, want %q from cache %q", path, response.Body,
+				response.Headers.Get("X-From-Cache"), wantBody, wantSource)
+		}
+	}
+	requests := func(want int32) {
+		t.Helper()
+		if got := atomic.LoadInt32(&origin.requests); got != want {
+			t.Fatalf("%d requests to the origin, want %d", got, want)
+		}
+	}
+
+	get("/fresh", "fresh", "")
+	get("/fresh", "fresh", "HIT")
+	requests(1)
+
+	get("/no-store", "no-store", "")
+	get("/no-store", "no-store", "")
+	requests(3)
+
+	// no-cache in the request revalidates a fresh response
+	get("/fresh", "fresh", "REVALIDATED", client_http.HeaderParameters{Key: "Cache-Control", Value: "no-cache"})
+	requests(4)
+
+	get("/flaky", "flaky", "")
+	requests(5)
+
+	time.Sleep(1100 * time.Millisecond)
+
+	get("/fresh", "fresh", "REVALIDATED")
+	get("/fresh", "fresh", "HIT")
+	requests(6)
+	if got := atomic.LoadInt32(&origin.conditional); got != 2 {
+		t.Fatalf("%d conditional requests, want 2", got)
+	}
+
+	// the stale response is served while the origin fails
+	get("/flaky", "flaky", "STALE", client_http.HeaderParameters{Key: "X-Fail", Value: "1"})
+	requests(7)
+}
+
+func TestCacheRevalidationStoppedByClose(t *testing.T) {
+	var requests int32
+	revalidating := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		if atomic.AddInt32(&requests, 1) > 1 {
+			// the revalidation hangs until the client gives up
+			close(revalidating)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("stale"))
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithCache(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		response, err := c.Get(context.Background(), server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(response.Body) != "stale" {
+			t.Fatalf("body = %q, want stale", response.Body)
+		}
+	}
+	<-revalidating
+
+	start := time.Now()
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Close took %v, want the revalidation cancelled", elapsed)
+	}
+	stacks := make([]byte, 1<<20)
+	if strings.Contains(string(stacks[:runtime.Stack(stacks, true)]), "client_http.(*httpCache).revalidate") {
+		t.Fatal("revalidation still running after Close")
+	}
+}
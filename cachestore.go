@@ -0,0 +1,137 @@
+package client_http
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// MemoryCache - Cache keeping values in memory, the least recently used ones are evicted
+// beyond maxBytes
+type MemoryCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+// memoryItem - key and value of a MemoryCache element
+type memoryItem struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache - memory cache of up to maxBytes of values, zero means no limit
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	return &MemoryCache{maxBytes: maxBytes, lru: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Get - value of key
+func (m *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	m.lru.MoveToFront(element)
+	return element.Value.(*memoryItem).value, true, nil
+}
+
+// Set - store value, evicting the least recently used values beyond maxBytes
+func (m *MemoryCache) Set(_ context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+	m.entries[key] = m.lru.PushFront(&memoryItem{key: key, value: value})
+	m.size += int64(len(value))
+
+	for m.maxBytes > 0 && m.size > m.maxBytes && m.lru.Len() > 0 {
+		m.remove(m.lru.Back())
+	}
+	return nil
+}
+
+// Delete - drop key
+func (m *MemoryCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+	return nil
+}
+
+// remove - drop element, the lock must be held
+func (m *MemoryCache) remove(element *list.Element) {
+	item := m.lru.Remove(element).(*memoryItem)
+	delete(m.entries, item.key)
+	m.size -= int64(len(item.value))
+}
+
+// DiskCache - Cache keeping every value in a file of dir named by the hash of its key,
+// values survive restarts of the process
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache - disk cache in dir, created when it does not exist
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating cache dir [%s] - [%w]", dir, err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get - content of the file of key
+func (d *DiskCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	value, err := ioutil.ReadFile(d.path(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading cache file [%w]", err)
+	}
+	return value, true, nil
+}
+
+// Set - write the file of key, through a temporary file so readers never see a partial value
+func (d *DiskCache) Set(_ context.Context, key string, value []byte) error {
+	file, err := ioutil.TempFile(d.dir, "tmp-")
+	if err != nil {
+		return fmt.Errorf("error creating cache file [%w]", err)
+	}
+	_, err = file.Write(value)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), d.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("error writing cache file [%w]", err)
+	}
+	return nil
+}
+
+// Delete - remove the file of key
+func (d *DiskCache) Delete(_ context.Context, key string) error {
+	if err := os.Remove(d.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting cache file [%w]", err)
+	}
+	return nil
+}
+
+// path - file of key
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.dir, sha256Hex([]byte(key)))
+}
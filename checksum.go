@@ -0,0 +1,132 @@
+package client_http
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Checksum algorithms
+const (
+	ChecksumMD5    = "md5"
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+)
+
+// Checksum - expected digest of downloaded content
+type Checksum struct {
+	// Algorithm - ChecksumMD5, ChecksumSHA256 or ChecksumSHA512
+	Algorithm string
+	// Expected - hex encoded digest
+	Expected string
+}
+
+// ChecksumMismatchError - downloaded content does not match the expected digest
+type ChecksumMismatchError struct {
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+// Error - algorithm and both digests
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch expected [%s] actual [%s]", e.Algorithm, e.Expected, e.Actual)
+}
+
+// newHash - hash of algorithm
+func newHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm [%s]", algorithm)
+}
+
+// verifyChecksum - check file against the checksum of opts or the digest headers
+func verifyChecksum(file *os.File, opts *DownloadOptions, headers http.Header) error {
+	checksum := opts.Checksum
+	if checksum == nil && opts.VerifyDigestHeader {
+		checksum = digestFromHeaders(headers)
+	}
+	if checksum == nil {
+		return nil
+	}
+
+	h, err := newHash(checksum.Algorithm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("error verifying checksum [%w]", err)
+	}
+	if _, err := io.Copy(h, file); err != nil {
+		return fmt.Errorf("error verifying checksum [%w]", err)
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(actual, checksum.Expected) {
+		return &ChecksumMismatchError{Algorithm: strings.ToLower(checksum.Algorithm), Expected: strings.ToLower(checksum.Expected), Actual: actual}
+	}
+	return nil
+}
+
+// digestFromHeaders - strongest digest sent by the server in Repr-Digest (RFC 9530),
+// Digest (RFC 3230) or Content-MD5, nil when there is none
+func digestFromHeaders(headers http.Header) *Checksum {
+	digests := map[string]string{}
+
+	// Repr-Digest: sha-256=:base64:
+	for _, part := range strings.Split(headers.Get("Repr-Digest"), ",") {
+		if name, value, ok := cutDigest(part); ok {
+			digests[name] = strings.Trim(value, ":")
+		}
+	}
+	// Digest: SHA-256=base64
+	for _, part := range strings.Split(headers.Get("Digest"), ",") {
+		if name, value, ok := cutDigest(part); ok {
+			if _, found := digests[name]; !found {
+				digests[name] = value
+			}
+		}
+	}
+	if md5sum := headers.Get("Content-MD5"); md5sum != "" {
+		if _, found := digests[ChecksumMD5]; !found {
+			digests[ChecksumMD5] = md5sum
+		}
+	}
+
+	for _, algorithm := range []string{ChecksumSHA512, ChecksumSHA256, ChecksumMD5} {
+		encoded, ok := digests[algorithm]
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		return &Checksum{Algorithm: algorithm, Expected: hex.EncodeToString(raw)}
+	}
+	return nil
+}
+
+// cutDigest - algorithm (sha-256 as sha256) and value of a digest header entry
+func cutDigest(part string) (string, string, bool) {
+	i := strings.Index(part, "=")
+	if i <= 0 {
+		return "", "", false
+	}
+	name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(part[:i])), "-", "")
+	return name, strings.TrimSpace(part[i+1:]), true
+}
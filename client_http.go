@@ -1,258 +1,398 @@
 package client_http
 
 import (
-	"bytes"
-	"crypto/tls"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
 type Client struct {
 	Instance *http.Client
-}
 
-type Response struct {
-	Body []byte
-	Status string
-	StatusCode int
+	// transport - default pooled transport, configured by options
+	transport *http.Transport
+	// dialer - dialer of new connections of the transport
+	dialer *net.Dialer
+	// resolver - custom host lookup of new connections, nil uses the system resolver
+	resolver Resolver
+	// dnsCache - resolved addresses, nil when WithDNSCache is not used
+	dnsCache *dnsCache
+	// insecureHosts - hosts without certificate verification
+	insecureHosts map[string]bool
+	// insecure - client of requests marked with InsecureSkipVerify
+	insecure insecureState
+	// hostOverrides - address dialed by host or host:port, see WithHostOverride
+	hostOverrides map[string]string
+	// hostConnLimits - connection slots by host, see WithHostMaxConns
+	hostConnLimits map[string]chan struct{}
+	// pool - connection counters reported to ConnMetrics
+	pool *poolCounters
+	// roundTripper - custom transport set with WithTransport
+	roundTripper http.RoundTripper
+	// retry - retry configuration, nil when retries are disabled
+	retry *RetryConfig
+	// retryAfter - max Retry-After wait set with WithRetryAfter
+	retryAfter *time.Duration
+	// basicAuth - credentials of requests without Authorization header
+	basicAuth *basicAuth
+	// apiKey - key sent on every request, nil when WithAPIKey is not used
+	apiKey *apiKey
+	// tokenSource - provides the Authorization token of requests without credentials
+	tokenSource TokenSource
+	// middlewares - user middlewares, composed into chain on NewHttpClient
+	middlewares []Middleware
+	// routingMiddlewares - middlewares choosing the destination of requests, run before
+	// the other client features
+	routingMiddlewares []Middleware
+	// internalMiddlewares - middlewares of client features, run inside the user ones
+	internalMiddlewares []Middleware
+	// signingMiddlewares - sign the final request, run after every other middleware
+	signingMiddlewares []Middleware
+	chain              RoundTripFunc
+	// hooks - lifecycle callbacks, see WithHooks
+	hooks []Hooks
+	// cache - response cache, nil when WithCache is not used
+	cache *httpCache
+	// maxResponseBytes - cap of response bodies, zero means no limit
+	maxResponseBytes int64
+	// decompressors - content encodings decoded by the client, in Accept-Encoding order
+	decompressors map[string]Decompressor
+	encodings     []string
+	// decoders - response decoders by media type
+	decoders *decoderRegistry
+	// encoders - payload encoders by media type added with WithEncoder
+	encoders map[string]Encoder
+	// schemas - response validators by route, see WithSchema
+	schemas []schemaRoute
+	// http2 - HTTP/2 mode, nil keeps the default negotiation
+	http2 *http2Config
+	// transportWrappers - wrappers of the configured transport, see WithTransportWrapper
+	transportWrappers []func(http.RoundTripper) http.RoundTripper
+	// logger - destination of client logs
+	logger Logger
+	// baseURL - prefix of relative request urls
+	baseURL string
+	// requestIDHeader - header of the request id, empty when WithRequestID is not used
+	requestIDHeader string
+	// negotiation - Accept header and content type checks, see WithContentNegotiation
+	negotiation *negotiation
+	// userAgent - User-Agent of requests without one
+	userAgent string
+	// traceFormats - trace headers propagated from the context, see WithTracePropagation
+	traceFormats []TraceFormat
+	// profiles - settings by host, see WithHostProfile
+	profiles map[string]*hostProfile
+	// defaultHeaders - headers sent on every request unless set by the call
+	defaultHeaders []HeaderParameters
+	// failOnErrorStatus - return *HTTPError for non-2xx responses
+	failOnErrorStatus bool
+	// errorDecoder - maps non-2xx responses to errors, see WithErrorDecoder
+	errorDecoder ErrorDecoder
+	// timings - fill Response.Timings
+	timings bool
+	// queue - background deliveries of Submit, nil when WithDeliveryQueue is not used
+	queue *deliveryQueue
+	// background - tasks run from NewHttpClient until Close (health checks, discovery)
+	background []func(ctx context.Context)
+	// lifetime - done once the client is closed
+	lifetime context.Context
+	stop     context.CancelFunc
+	running  sync.WaitGroup
+	// closeMu - guards closed, running is not added to once Close waits for it
+	closeMu sync.Mutex
+	closed  bool
+	// closers - resources opened by options (key log file), closed by Close
+	closers []io.Closer
 }
 
+// HeaderParameters - header of a request, Value replaces the previous values of Key
+// unless Add is set, see AddHeader and HeadersFrom for repeated headers
 type HeaderParameters struct {
-	Key string
+	Key   string
 	Value string
+	// Add - append Value to the values of Key instead of replacing them
+	Add bool
 }
 
-func NewHttpClient(skipTLS bool) *Client {
+// NewHttpClient - create a Client with a pooled transport, defaults can be changed with options
+func NewHttpClient(opts ...Option) (*Client, error) {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	transport.MaxIdleConnsPerHost = 100
 	transport.MaxConnsPerHost = 1000
 	transport.MaxIdleConns = 1000
 
-	if skipTLS {
-		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	c := &Client{
+		Instance:  &http.Client{Timeout: 600 * time.Second},
+		transport: transport,
+		dialer:    &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		logger:    StdLogger(log.Default()),
+		decoders:  newDecoderRegistry(),
+		pool:      &poolCounters{},
+		userAgent: DefaultUserAgent,
+	}
+	transport.DialContext = c.dialContext
+
+	// apply options
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			_ = c.closeResources()
+			return nil, fmt.Errorf("error configuring client [%w]", err)
+		}
 	}
 
-	httpClient := &http.Client{Transport: transport, Timeout: 600 * time.Second}
-	return &Client{Instance: httpClient}
+	c.retry = c.retryConfig()
+	if c.negotiation != nil {
+		c.negotiation.accept = c.decoders.acceptHeader(c.negotiation.preferred)
+	}
+	c.configureInsecureHosts()
+	if err := c.configureHTTP2(); err != nil {
+		_ = c.closeResources()
+		return nil, err
+	}
+	c.Instance.Transport = c.transport
+	if c.roundTripper != nil {
+		c.Instance.Transport = c.roundTripper
+	}
+	for _, wrap := range c.transportWrappers {
+		c.Instance.Transport = wrap(c.Instance.Transport)
+	}
+	c.chain = c.buildChain()
+	c.start()
 
+	return c, nil
 }
 
-// GetResponseWithCredentials - Get response from url with credentials
-func (c *Client) GetResponseWithCredentials(url, username, password string) (*Response, error) {
-	// Get request for url
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("can't get request error [%v]", err)
+// start - run the background tasks
+func (c *Client) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.lifetime, c.stop = ctx, cancel
+	for _, task := range c.background {
+		task := task
+		_ = c.goBackground(func() { task(ctx) })
 	}
+}
 
-	// set Credentials
-	request.SetBasicAuth(username, password)
-
-	// Do request
-	response, err := c.Instance.Do(request)
-	if err != nil {
-		return nil, fmt.Errorf("can't do request error [%v]", err)
+// goBackground - run task until it returns, Close waits for it. ErrClientClosed once the
+// client is closed
+func (c *Client) goBackground(task func()) error {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.closed {
+		return ErrClientClosed
 	}
+	c.running.Add(1)
+	go func() {
+		defer c.running.Done()
+		task()
+	}()
+	return nil
+}
 
-	// defer closing body
-	defer Defer(func() {
-		if response.Body != nil {
-			err := response.Body.Close()
-			if err != nil {
-				fmt.Printf("can't close body error [%v]", err)
-			}
-		}
-	})
+// ErrClientClosed - returned by the background features of a client after Close, such as
+// Submit and Schedule
+var ErrClientClosed = errors.New("client closed")
+
+// Close - stop the background tasks and scheduled requests of the client, close the
+// idle connections and the files opened by options. The client can still send requests
+func (c *Client) Close() error {
+	c.closeMu.Lock()
+	c.closed = true
+	c.closeMu.Unlock()
+
+	c.stop()
+	c.running.Wait()
+	c.CloseIdleConnections()
+	return c.closeResources()
+}
 
-	// Read body response
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, fmt.Errorf("can't read body error [%v]", err)
+// closeResources - close the resources opened by options, returning the first error
+func (c *Client) closeResources() error {
+	c.closeMu.Lock()
+	closers := c.closers
+	c.closers = nil
+	c.closeMu.Unlock()
+
+	var first error
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil && first == nil {
+			first = fmt.Errorf("error closing client resource [%w]", err)
+		}
 	}
+	return first
+}
 
-	// Create Result
-	return &Response {
-		Body: body,
-		Status: response.Status,
-		StatusCode: response.StatusCode,
-	}, nil
+// GetResponseWithCredentials - Get response from url with credentials
+func (c *Client) GetResponseWithCredentials(ctx context.Context, url, username, password string) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodGet, url, username, password, nil)
+}
 
+// GetResponseWithPayloadAndAuth - Get response sending payload, authentication header
+func (c *Client) GetResponseWithPayloadAndAuth(ctx context.Context, url, username, password string, payload []byte) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodGet, url, username, password, payload)
+}
 
+// GetResponseWithPayloadAuthAndHeader - Get response sending payload, authentication header and headers
+func (c *Client) GetResponseWithPayloadAuthAndHeader(ctx context.Context, url, username, password string, payload []byte, headers []HeaderParameters) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodGet, url, username, password, payload, headers...)
 }
 
+// GetResponseWithPayloadAndHeaders - Get response using url, payload and custom headers
+func (c *Client) GetResponseWithPayloadAndHeaders(ctx context.Context, url string, payload []byte, headers []HeaderParameters) (*Response, error) {
+	return c.Do(ctx, http.MethodGet, url, payload, headers...)
+}
 
+// GetResponse - execute a simple request on url
+func (c *Client) GetResponse(ctx context.Context, url string) (*Response, error) {
+	return c.Do(ctx, http.MethodGet, url, nil)
+}
 
-// GetResponseWithPayloadAndAuth - Get response sending payload, authentication header
-func (c *Client) GetResponseWithPayloadAndAuth(url, username, password string, payload []byte) (*Response, error){
-	// Get request for url and payload
-	request, err := http.NewRequest("GET", url, bytes.NewReader(payload))
-	if err != nil {
-		return nil, fmt.Errorf("error on build request [%s] - [%v]", url, err)
+// execute - do request, read the whole body and build the Response.
+// The request context controls cancellation and deadline of the call
+func (c *Client) execute(request *http.Request) (*Response, error) {
+	var timings *connTrace
+	if c.timings {
+		request, timings = trace(request, nil)
 	}
 
-	// set Authentication headers
-	request.SetBasicAuth(username, password)
-
-	// Do request
-	response,err := c.Instance.Do(request)
+	// executing request
+	response, encoding, err := c.roundTrip(request)
 	if err != nil {
-		return nil, fmt.Errorf("error on make request [%v] ", err)
+		return nil, err
 	}
 
-	// defer body closing
+	// closing body response
 	defer Defer(func() {
 		if response.Body != nil {
 			err := response.Body.Close()
 			if err != nil {
-				fmt.Printf("error closing response.body [%v]", err)
+				c.logger.Log(LevelError, "error closing response body", map[string]interface{}{"error": err})
 			}
 		}
 	})
 
-	// reading body result
+	// reading body
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body")
+		var tooLarge *ResponseTooLargeError
+		if errors.As(err, &tooLarge) {
+			return nil, tooLarge
+		}
+		return nil, fmt.Errorf("error reading response body [%w]", err)
 	}
 
-	// returning response
-	return &Response{Body: body, Status: response.Status, StatusCode: response.StatusCode}, nil
+	result := &Response{
+		Body:       body,
+		Status:     response.Status,
+		StatusCode: response.StatusCode,
+		Headers:    response.Header,
 
+		ContentEncoding: encoding,
+		RequestID:       c.requestID(request),
+		Trailers:        response.Trailer,
 
-}
+		contentLength: response.ContentLength,
+		decoders:      c.decoders,
+	}
+	if timings != nil {
+		result.Timings = timings.timings()
+	}
 
-// GetResponseWithPayloadAuthAndHeader - Get response sending payload, authentication header and headers
-func (c *Client) GetResponseWithPayloadAuthAndHeader(url, username, password string, payload []byte, headers []HeaderParameters) (*Response, error){
-	// Get request for url and payload
-	request, err := http.NewRequest("GET", url, bytes.NewReader(payload))
-	if err != nil {
-		return nil, fmt.Errorf("error on build request [%s] - [%v]", url, err)
+	// error status
+	if !isSuccess(result.StatusCode) {
+		if err := c.statusError(request, result); err != nil {
+			return nil, err
+		}
 	}
 
-	// set Authentication headers
-	request.SetBasicAuth(username, password)
+	// return response
+	return result, nil
+}
 
-	// set additional headers
-	for _, h := range headers {
-		request.Header.Set(h.Key, h.Value)
+// roundTrip - apply client settings, send request and prepare the body for reading, the
+// caller must close the response body. It returns the original Content-Encoding when
+// the body was decompressed
+func (c *Client) roundTrip(request *http.Request) (*http.Response, string, error) {
+	// client level settings
+	if err := c.prepare(request); err != nil {
+		return nil, "", err
 	}
 
-	// Do request
-	response,err := c.Instance.Do(request)
+	response, err := c.send(request)
 	if err != nil {
-		return nil, fmt.Errorf("error on make request [%v] ", err)
-	}
-
-	// defer body closing
-	defer Defer(func() {
-		if response.Body != nil {
-			err := response.Body.Close()
-			if err != nil {
-				fmt.Printf("error closing response.body [%v]", err)
-			}
+		if id := c.requestID(request); id != "" {
+			return nil, "", fmt.Errorf("error executing request for url [%s] request id [%s] =  [%w]", c.redactURL(request.URL.String()), id, err)
 		}
-	})
+		return nil, "", fmt.Errorf("error executing request for url [%s] =  [%w]", c.redactURL(request.URL.String()), err)
+	}
 
-	// reading body result
-	body, err := ioutil.ReadAll(response.Body)
+	encoding, err := c.decompress(response)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body")
+		_ = response.Body.Close()
+		return nil, "", err
 	}
 
-	// returning response
-	return &Response{Body: body, Status: response.Status, StatusCode: response.StatusCode}, nil
+	if err := c.limitResponse(request, response); err != nil {
+		return nil, "", err
+	}
 
+	if err := c.validateSchema(request, response); err != nil {
+		return nil, "", err
+	}
 
+	return response, encoding, nil
 }
 
-// GetResponseWithPayloadAndHeaders - Get response using url, payload and custom headers
-func (c *Client) GetResponseWithPayloadAndHeaders(url string, payload []byte, headers []HeaderParameters) (*Response, error) {
-	// create request
-	request, err := http.NewRequest("GET", url, bytes.NewReader(payload))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request for url [%s] - [%v]", url, err)
-	}
+// prepare - apply client level settings to request, per request values take precedence
+func (c *Client) prepare(request *http.Request) error {
+	c.setRequestID(request)
+	c.propagateTrace(request)
 
-	// set additional headers
-	for _, h := range headers {
-		request.Header.Set(h.Key, h.Value)
+	if accept := c.acceptEncoding(); accept != "" && request.Header.Get("Accept-Encoding") == "" {
+		request.Header.Set("Accept-Encoding", accept)
 	}
 
-
-	// do request
-	response, err := c.Instance.Do(request)
-	if err != nil {
-		return nil, fmt.Errorf("error doing request [%v]", err)
+	if p := c.profile(request); p != nil {
+		if err := p.apply(request); err != nil {
+			return err
+		}
 	}
 
-	// closing body
-	defer Defer(func() {
-		if response.Body != nil {
-			err := response.Body.Close()
-			if err != nil {
-				fmt.Printf("error closing response body [%v]", err)
-			}
-		}
-	})
+	applyMissingHeaders(request.Header, c.defaultHeaders)
 
-	// reading data
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil{
-		return nil, fmt.Errorf("error reading response body [%v]", err)
+	if c.negotiation != nil && request.Header.Get("Accept") == "" {
+		request.Header.Set("Accept", c.negotiation.accept)
 	}
 
-	// return response
-	return &Response{
-		Body:       body,
-		Status:     response.Status,
-		StatusCode: response.StatusCode,
-	}, nil
-
-}
+	if c.userAgent != "" && request.Header.Get("User-Agent") == "" {
+		request.Header.Set("User-Agent", c.userAgent)
+	}
 
-// GetResponse - execute a simple request on url
-func (c *Client) GetResponse(url string) (*Response, error) {
-	// creating request
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request for url [%s] =  [%v]", url, err)
+	if c.apiKey != nil {
+		c.apiKey.apply(request)
 	}
 
-	// executing request
-	response, err:= c.Instance.Do(request)
-	if err != nil {
-		return nil, fmt.Errorf("error executing request for url [%s] =  [%v]", url, err)
+	if c.basicAuth != nil && request.Header.Get("Authorization") == "" {
+		request.SetBasicAuth(c.basicAuth.username, c.basicAuth.password)
 	}
 
-	// closing body response
-	defer Defer(func() {
-		if response.Body != nil {
-			err := response.Body.Close()
-			if err != nil {
-				fmt.Printf("error closing response body [%v]", err)
-			}
+	if c.tokenSource != nil && request.Header.Get("Authorization") == "" {
+		token, err := c.tokenSource.Token(request.Context())
+		if err != nil {
+			return fmt.Errorf("error getting authorization token [%w]", err)
 		}
-	})
-
-	// reading body
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil{
-		return nil, fmt.Errorf("error reading response body [%v]", err)
+		request.Header.Set("Authorization", token.authorization())
 	}
 
-
-	// return response
-	return &Response{
-		Body:       body,
-		Status:     response.Status,
-		StatusCode: response.StatusCode,
-	}, nil
-
+	return nil
 }
 
 func Defer(f func()) {
 	defer f()
 }
-
@@ -0,0 +1,150 @@
+// Package clienthttp3 - experimental HTTP/3 (QUIC) transport for client_http.
+// It lives in its own module so the client does not depend on quic-go
+package clienthttp3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/erikwco/client_http"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// config - HTTP/3 settings
+type config struct {
+	quic            *quic.Config
+	fallbackTimeout time.Duration
+}
+
+// Option - configures the HTTP/3 transport
+type Option func(c *config)
+
+// WithQUICConfig - QUIC settings of new connections, quic-go defaults otherwise
+func WithQUICConfig(quicConfig *quic.Config) Option {
+	return func(c *config) {
+		c.quic = quicConfig
+	}
+}
+
+// WithFallbackTimeout - how long a host that failed to connect over QUIC is sent
+// HTTP/2 or HTTP/1.1 before trying HTTP/3 again, 5 minutes by default
+func WithFallbackTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.fallbackTimeout = timeout
+	}
+}
+
+// WithHTTP3 - send https requests over HTTP/3. When the QUIC connection can't be
+// established (UDP blocked, server without HTTP/3) the request is sent through the
+// regular transport and the host keeps using it for the fallback timeout. Experimental
+func WithHTTP3(opts ...Option) client_http.Option {
+	cfg := &config{fallbackTimeout: 5 * time.Minute}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return client_http.WithTransportWrapper(func(next http.RoundTripper) http.RoundTripper {
+		t := &transport{
+			fallback:        next,
+			fallbackTimeout: cfg.fallbackTimeout,
+			broken:          map[string]time.Time{},
+		}
+		t.h3 = &http3.Transport{
+			TLSClientConfig: tlsConfig(next),
+			QUICConfig:      cfg.quic,
+			Dial:            dial,
+		}
+		return t
+	})
+}
+
+// transport - HTTP/3 round tripper falling back to the regular transport
+type transport struct {
+	h3              *http3.Transport
+	fallback        http.RoundTripper
+	fallbackTimeout time.Duration
+
+	mu sync.Mutex
+	// broken - hosts that failed to connect over QUIC and when to try again
+	broken map[string]time.Time
+}
+
+// RoundTrip - send request over HTTP/3, or through the fallback transport for plain
+// http, hosts marked as broken and failed QUIC handshakes
+func (t *transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.URL.Scheme != "https" || t.isBroken(request.URL.Host) {
+		return t.fallback.RoundTrip(request)
+	}
+
+	response, err := t.h3.RoundTrip(request)
+	var dialErr *dialError
+	if err != nil && errors.As(err, &dialErr) && request.Context().Err() == nil {
+		t.markBroken(request.URL.Host)
+		return t.fallback.RoundTrip(request)
+	}
+	return response, err
+}
+
+// CloseIdleConnections - close idle QUIC and fallback connections
+func (t *transport) CloseIdleConnections() {
+	t.h3.CloseIdleConnections()
+	if closer, ok := t.fallback.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// isBroken - true if host failed over QUIC within the fallback timeout
+func (t *transport) isBroken(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	until, ok := t.broken[host]
+	if ok && time.Now().After(until) {
+		delete(t.broken, host)
+		return false
+	}
+	return ok
+}
+
+// markBroken - send host through the fallback transport for the fallback timeout
+func (t *transport) markBroken(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.broken[host] = time.Now().Add(t.fallbackTimeout)
+}
+
+// dialError - QUIC connection failure, nothing was sent to the server
+type dialError struct {
+	err error
+}
+
+func (e *dialError) Error() string {
+	return e.err.Error()
+}
+
+func (e *dialError) Unwrap() error {
+	return e.err
+}
+
+// dial - open a QUIC connection to addr, failures are wrapped as *dialError
+func dial(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.EarlyConnection, error) {
+	conn, err := quic.DialAddrEarly(ctx, addr, tlsConfig, quicConfig)
+	if err != nil {
+		return nil, &dialError{err: err}
+	}
+	return conn, nil
+}
+
+// tlsConfig - TLS configuration of the client transport (root CAs, client
+// certificates), nil when next is not an *http.Transport
+func tlsConfig(next http.RoundTripper) *tls.Config {
+	if t, ok := next.(*http.Transport); ok && t.TLSClientConfig != nil {
+		return t.TLSClientConfig.Clone()
+	}
+	return nil
+}
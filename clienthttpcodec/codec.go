@@ -0,0 +1,44 @@
+// Package clienthttpcodec - MessagePack and CBOR payloads for client_http.
+// It lives in its own module so the client does not depend on the codecs
+package clienthttpcodec
+
+import (
+	"github.com/erikwco/client_http"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// ContentTypeMsgpack - media type of MessagePack payloads
+	ContentTypeMsgpack = "application/msgpack"
+	// ContentTypeCBOR - media type of CBOR payloads (RFC 8949)
+	ContentTypeCBOR = "application/cbor"
+)
+
+// WithMsgpack - encode payloads sent as application/msgpack and decode MessagePack
+// responses (application/msgpack, application/x-msgpack) in Response.Decode. Structs use
+// the `msgpack` tag
+func WithMsgpack() client_http.Option {
+	return withCodec(msgpack.Marshal, msgpack.Unmarshal, ContentTypeMsgpack, "application/x-msgpack")
+}
+
+// WithCBOR - encode payloads sent as application/cbor and decode CBOR responses in
+// Response.Decode. Structs use the `cbor` tag, or `json` when absent
+func WithCBOR() client_http.Option {
+	return withCodec(cbor.Marshal, cbor.Unmarshal, ContentTypeCBOR)
+}
+
+// withCodec - register encoder for the first media type and decoder for all of them
+func withCodec(encoder client_http.Encoder, decoder client_http.Decoder, mediaTypes ...string) client_http.Option {
+	return func(c *client_http.Client) error {
+		if err := client_http.WithEncoder(mediaTypes[0], encoder)(c); err != nil {
+			return err
+		}
+		for _, mediaType := range mediaTypes {
+			if err := client_http.WithDecoder(mediaType, decoder)(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/erikwco/client_http/clienthttpcodec
+
+go 1.20
+
+require (
+	github.com/erikwco/client_http v1.0.0
+	github.com/fxamacker/cbor/v2 v2.7.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+// builds against this tree, users of the module get the required release
+replace github.com/erikwco/client_http => ../
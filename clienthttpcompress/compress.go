@@ -0,0 +1,30 @@
+// Package clienthttpcompress - brotli and zstd response decompression for client_http.
+// It lives in its own module so the client does not depend on the codecs
+package clienthttpcompress
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/andybalholm/brotli"
+	"github.com/erikwco/client_http"
+	"github.com/klauspost/compress/zstd"
+)
+
+// WithBrotli - decompress br encoded responses
+func WithBrotli() client_http.Option {
+	return client_http.WithDecompressor("br", func(body io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(brotli.NewReader(body)), nil
+	})
+}
+
+// WithZstd - decompress zstd encoded responses
+func WithZstd() client_http.Option {
+	return client_http.WithDecompressor("zstd", func(body io.Reader) (io.ReadCloser, error) {
+		decoder, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	})
+}
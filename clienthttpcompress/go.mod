@@ -0,0 +1,17 @@
+module github.com/erikwco/client_http/clienthttpcompress
+
+go 1.20
+
+require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/erikwco/client_http v1.0.0
+	github.com/klauspost/compress v1.17.7
+)
+
+require (
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+// builds against this tree, users of the module get the required release
+replace github.com/erikwco/client_http => ../
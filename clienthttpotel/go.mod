@@ -0,0 +1,20 @@
+module github.com/erikwco/client_http/clienthttpotel
+
+go 1.20
+
+require (
+	github.com/erikwco/client_http v1.0.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+// builds against this tree, users of the module get the required release
+replace github.com/erikwco/client_http => ../
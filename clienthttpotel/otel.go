@@ -0,0 +1,122 @@
+// Package clienthttpotel - OpenTelemetry tracing for client_http.
+// It lives in its own module so the client does not depend on OpenTelemetry
+package clienthttpotel
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/erikwco/client_http"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/erikwco/client_http/clienthttpotel"
+
+// config - tracing settings
+type config struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	redact     []string
+}
+
+// Option - configures the tracing middleware
+type Option func(c *config)
+
+// WithTracerProvider - provider used to create spans, the global provider by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// WithPropagator - propagator used to inject the span context, W3C trace context by default
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// WithRedactedParams - query parameters whose values are replaced in url.full, such as
+// the parameter of client_http.WithAPIKey
+func WithRedactedParams(params ...string) Option {
+	return func(c *config) {
+		c.redact = append(c.redact, params...)
+	}
+}
+
+// Middleware - create a client span for every request attempt, record method, url,
+// status and client_http.MarkLabels labels (as label.<name>) attributes and inject the
+// traceparent header. Register it with client_http.WithMiddleware
+func Middleware(opts ...Option) client_http.Middleware {
+	cfg := &config{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	tracer := cfg.provider.Tracer(instrumentationName)
+
+	return func(next client_http.RoundTripFunc) client_http.RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			ctx, span := tracer.Start(request.Context(), "HTTP "+request.Method,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("http.request.method", request.Method),
+					attribute.String("url.full", redactedURL(request, cfg.redact)),
+					attribute.String("server.address", request.URL.Hostname()),
+				),
+			)
+			defer span.End()
+			for name, value := range client_http.LabelsFrom(ctx) {
+				span.SetAttributes(attribute.String("label."+name, value))
+			}
+
+			request = request.WithContext(ctx)
+			request.Header = request.Header.Clone()
+			cfg.propagator.Inject(ctx, propagation.HeaderCarrier(request.Header))
+
+			response, err := next(request)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return response, err
+			}
+
+			span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+			if response.StatusCode >= 500 {
+				span.SetStatus(codes.Error, fmt.Sprintf("status [%d]", response.StatusCode))
+			}
+			return response, nil
+		}
+	}
+}
+
+// redactedURL - request url without user credentials and the values of params
+func redactedURL(request *http.Request, params []string) string {
+	u := *request.URL
+	u.User = nil
+	if len(params) > 0 && u.RawQuery != "" {
+		pairs := strings.Split(u.RawQuery, "&")
+		for i, pair := range pairs {
+			key, _, _ := strings.Cut(pair, "=")
+			name, err := url.QueryUnescape(key)
+			if err != nil {
+				continue
+			}
+			for _, param := range params {
+				if name == param {
+					pairs[i] = key + "=[REDACTED]"
+				}
+			}
+		}
+		u.RawQuery = strings.Join(pairs, "&")
+	}
+	return u.String()
+}
@@ -0,0 +1,23 @@
+module github.com/erikwco/client_http/clienthttpprom
+
+go 1.20
+
+require (
+	github.com/erikwco/client_http v1.0.0
+	github.com/prometheus/client_golang v1.19.0
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/protobuf v1.32.0 // indirect
+)
+
+// builds against this tree, users of the module get the required release
+replace github.com/erikwco/client_http => ../
@@ -0,0 +1,141 @@
+// Package clienthttpprom - Prometheus metrics for client_http.
+// It lives in its own module so the client does not depend on Prometheus
+package clienthttpprom
+
+import (
+	"github.com/erikwco/client_http"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector - prometheus.Collector implementing client_http.Metrics, exposes
+// request count, error count and latency labeled by method, host and status class.
+// As client_http.ConnMetrics it exposes new connections, connection phase latency
+// (dns, connect, tls) and the open, active and idle connections of the pool
+type Collector struct {
+	// labels - names of the client_http.MarkLabels labels added to the request metrics
+	labels []string
+
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+
+	newConns *prometheus.CounterVec
+	phases   *prometheus.HistogramVec
+	conns    *prometheus.GaugeVec
+}
+
+// NewCollector - collector with metrics prefixed by namespace, buckets are the latency
+// histogram buckets in seconds (prometheus.DefBuckets when empty)
+func NewCollector(namespace string, buckets ...float64) *Collector {
+	return NewCollectorWithLabels(namespace, nil, buckets...)
+}
+
+// NewCollectorWithLabels - collector whose request metrics are also labeled by the
+// client_http.MarkLabels labels in labels (tenant, operation...), for per tenant
+// latency and error breakdowns. Requests without one of them report it empty. Keep
+// the label values bounded, every combination is a time series
+func NewCollectorWithLabels(namespace string, labels []string, buckets ...float64) *Collector {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	requestLabels := append([]string{"method", "host", "status_class"}, labels...)
+	errorLabels := append([]string{"method", "host"}, labels...)
+
+	return &Collector{
+		labels: labels,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "Requests sent by the http client.",
+		}, requestLabels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "errors_total",
+			Help:      "Requests that failed without a response.",
+		}, errorLabels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the requests sent by the http client.",
+			Buckets:   buckets,
+		}, requestLabels),
+		newConns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "new_connections_total",
+			Help:      "Connections dialed by the http client.",
+		}, []string{"host"}),
+		phases: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "connection_phase_duration_seconds",
+			Help:      "Latency of the dns, connect and tls phases of new connections.",
+			Buckets:   buckets,
+		}, []string{"host", "phase"}),
+		conns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "connections",
+			Help:      "Connections of the http client pool by state.",
+		}, []string{"state"}),
+	}
+}
+
+// ObserveRequest - implements client_http.Metrics
+func (c *Collector) ObserveRequest(metric client_http.RequestMetric) {
+	labels := make([]string, len(c.labels))
+	for i, name := range c.labels {
+		labels[i] = metric.Labels[name]
+	}
+	values := append([]string{metric.Method, metric.Host, metric.StatusClass}, labels...)
+
+	c.requests.WithLabelValues(values...).Inc()
+	c.latency.WithLabelValues(values...).Observe(metric.Duration.Seconds())
+	if metric.Err != nil {
+		c.errors.WithLabelValues(append([]string{metric.Method, metric.Host}, labels...)...).Inc()
+	}
+}
+
+// ObserveConn - implements client_http.ConnMetrics
+func (c *Collector) ObserveConn(metric client_http.ConnMetric) {
+	c.conns.WithLabelValues("open").Set(float64(metric.Pool.Open))
+	c.conns.WithLabelValues("active").Set(float64(metric.Pool.Active))
+	c.conns.WithLabelValues("idle").Set(float64(metric.Pool.Idle))
+	if metric.Reused {
+		return
+	}
+
+	c.newConns.WithLabelValues(metric.Host).Inc()
+	if metric.DNS > 0 {
+		c.phases.WithLabelValues(metric.Host, "dns").Observe(metric.DNS.Seconds())
+	}
+	if metric.Connect > 0 {
+		c.phases.WithLabelValues(metric.Host, "connect").Observe(metric.Connect.Seconds())
+	}
+	if metric.TLS > 0 {
+		c.phases.WithLabelValues(metric.Host, "tls").Observe(metric.TLS.Seconds())
+	}
+}
+
+// Describe - implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.errors.Describe(ch)
+	c.latency.Describe(ch)
+	c.newConns.Describe(ch)
+	c.phases.Describe(ch)
+	c.conns.Describe(ch)
+}
+
+// Collect - implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.errors.Collect(ch)
+	c.latency.Collect(ch)
+	c.newConns.Collect(ch)
+	c.phases.Collect(ch)
+	c.conns.Collect(ch)
+}
@@ -0,0 +1,16 @@
+module github.com/erikwco/client_http/clienthttpproto
+
+go 1.20
+
+require (
+	github.com/erikwco/client_http v1.0.0
+	google.golang.org/protobuf v1.33.0
+)
+
+require (
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+// builds against this tree, users of the module get the required release
+replace github.com/erikwco/client_http => ../
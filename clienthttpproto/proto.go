@@ -0,0 +1,77 @@
+// Package clienthttpproto - protobuf payloads for client_http.
+// It lives in its own module so the client does not depend on protobuf
+package clienthttpproto
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/erikwco/client_http"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentType - media type of protobuf payloads
+const ContentType = "application/x-protobuf"
+
+// mediaTypes - media types decoded as protobuf
+var mediaTypes = []string{ContentType, "application/protobuf", "application/vnd.google.protobuf"}
+
+// WithProtobuf - encode proto.Message payloads sent as application/x-protobuf and decode
+// protobuf responses into proto.Message values in Response.Decode
+func WithProtobuf() client_http.Option {
+	return func(c *client_http.Client) error {
+		if err := client_http.WithEncoder(ContentType, encode)(c); err != nil {
+			return err
+		}
+		for _, mediaType := range mediaTypes {
+			if err := client_http.WithDecoder(mediaType, decode)(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Do - execute a request sending in as protobuf (nil for no payload) and decoding a
+// successful (2xx) response into out, the client needs WithProtobuf. The Response is
+// returned for any status code
+func Do(ctx context.Context, c *client_http.Client, method, url string, in, out proto.Message, headers ...client_http.HeaderParameters) (*client_http.Response, error) {
+	// typed nil messages would be encoded as empty payloads
+	var payload, target interface{}
+	if in != nil {
+		payload = in
+	}
+	if out != nil {
+		target = out
+	}
+	return c.DoEncoded(ctx, method, url, ContentType, payload, target, headers...)
+}
+
+// Get - GET url decoding the protobuf response into out
+func Get(ctx context.Context, c *client_http.Client, url string, out proto.Message, headers ...client_http.HeaderParameters) (*client_http.Response, error) {
+	return Do(ctx, c, http.MethodGet, url, nil, out, headers...)
+}
+
+// Post - POST in as protobuf decoding the protobuf response into out
+func Post(ctx context.Context, c *client_http.Client, url string, in, out proto.Message, headers ...client_http.HeaderParameters) (*client_http.Response, error) {
+	return Do(ctx, c, http.MethodPost, url, in, out, headers...)
+}
+
+// encode - wire format of a proto.Message
+func encode(v interface{}) ([]byte, error) {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("can't encode [%T] as protobuf, a proto.Message is expected", v)
+	}
+	return proto.Marshal(message)
+}
+
+// decode - decode the wire format into a proto.Message
+func decode(data []byte, v interface{}) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("can't decode protobuf into [%T], a proto.Message is expected", v)
+	}
+	return proto.Unmarshal(data, message)
+}
@@ -0,0 +1,78 @@
+// Package clienthttpredis - Redis backend of the client_http response cache, shared by
+// every replica using the same Redis. It lives in its own module so the client does not
+// depend on Redis
+package clienthttpredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/erikwco/client_http"
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache - client_http.Cache storing values in Redis
+type Cache struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// Option - configures a Cache
+type Option func(c *Cache)
+
+// WithPrefix - prefix of the keys, "client_http:cache:" by default
+func WithPrefix(prefix string) Option {
+	return func(c *Cache) {
+		c.prefix = prefix
+	}
+}
+
+// WithTTL - expiration of the stored values, so responses nobody requests are evicted.
+// 24 hours by default, zero keeps values until Redis evicts them
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// New - cache using client, a *redis.Client, *redis.ClusterClient or *redis.Ring
+func New(client redis.UniversalClient, opts ...Option) *Cache {
+	c := &Cache{client: client, prefix: "client_http:cache:", ttl: 24 * time.Hour}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+var _ client_http.Cache = (*Cache)(nil)
+
+// Get - value of key
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading redis cache [%w]", err)
+	}
+	return value, true, nil
+}
+
+// Set - store value with the configured ttl
+func (c *Cache) Set(ctx context.Context, key string, value []byte) error {
+	if err := c.client.Set(ctx, c.prefix+key, value, c.ttl).Err(); err != nil {
+		return fmt.Errorf("error writing redis cache [%w]", err)
+	}
+	return nil
+}
+
+// Delete - drop key
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, c.prefix+key).Err(); err != nil {
+		return fmt.Errorf("error deleting redis cache [%w]", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Package clienthttpschema - JSON Schema validation of client_http responses.
+// It lives in its own module so the client does not depend on the validator
+package clienthttpschema
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/erikwco/client_http"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// inlineURL - url of schemas compiled from memory, used in violation messages
+const inlineURL = "inline://schema.json"
+
+// Validator - compiled JSON Schema (drafts 4, 6, 7, 2019-09 and 2020-12, the latest when
+// $schema is missing), use it with client_http.WithSchema or client_http.MarkSchema
+type Validator struct {
+	schema *jsonschema.Schema
+}
+
+// check Validator implements the client interface
+var _ client_http.SchemaValidator = (*Validator)(nil)
+
+// Compile - validator of the JSON Schema document schema
+func Compile(schema []byte) (*Validator, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(inlineURL, bytes.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf("error reading json schema [%w]", err)
+	}
+	compiled, err := compiler.Compile(inlineURL)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling json schema [%w]", err)
+	}
+	return &Validator{schema: compiled}, nil
+}
+
+// CompileFile - validator of the JSON Schema file at path, relative $ref are resolved
+// from its directory
+func CompileFile(path string) (*Validator, error) {
+	compiled, err := jsonschema.Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling json schema [%s] - [%w]", path, err)
+	}
+	return &Validator{schema: compiled}, nil
+}
+
+// MustCompile - like Compile but panics on errors, for package level validators
+func MustCompile(schema []byte) *Validator {
+	validator, err := Compile(schema)
+	if err != nil {
+		panic(err)
+	}
+	return validator
+}
+
+// Validate - check document, violations are returned as *jsonschema.ValidationError
+func (v *Validator) Validate(document interface{}) error {
+	return v.schema.Validate(document)
+}
@@ -0,0 +1,317 @@
+package clienthttptest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/erikwco/client_http"
+)
+
+// Mode - behaviour of a Recorder
+type Mode int
+
+const (
+	// ModeReplay - answer from the cassette, requests without interaction fail
+	ModeReplay Mode = iota
+	// ModeRecord - send requests to the real server and save them on Stop
+	ModeRecord
+	// ModeReplayOrRecord - replay when the cassette file exists, record otherwise
+	ModeReplayOrRecord
+)
+
+// redacted - value stored for redacted headers
+const redacted = "REDACTED"
+
+// Interaction - request and response pair of a cassette
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest - request of an interaction
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// RecordedResponse - response of an interaction
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body - payload stored as text, or base64 when it is not valid utf-8
+type Body []byte
+
+// MarshalJSON - text or {"base64": "..."} for binary content
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON - decode text or base64 content
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+	var encoded map[string]string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded["base64"])
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// Matcher - true if request must be answered with the recorded interaction
+type Matcher func(request *http.Request, body []byte, recorded RecordedRequest) bool
+
+// MatchMethodAndURL - default matcher, same method and url
+func MatchMethodAndURL(request *http.Request, _ []byte, recorded RecordedRequest) bool {
+	return request.Method == recorded.Method && request.URL.String() == recorded.URL
+}
+
+// MatchBody - same method, url and body
+func MatchBody(request *http.Request, body []byte, recorded RecordedRequest) bool {
+	return MatchMethodAndURL(request, body, recorded) && bytes.Equal(body, recorded.Body)
+}
+
+// RecorderOption - configures a Recorder
+type RecorderOption func(r *Recorder)
+
+// WithRedactedHeaders - headers saved as REDACTED, Authorization, Proxy-Authorization,
+// Cookie and Set-Cookie are always redacted
+func WithRedactedHeaders(headers ...string) RecorderOption {
+	return func(r *Recorder) {
+		for _, h := range headers {
+			r.redact[http.CanonicalHeaderKey(h)] = true
+		}
+	}
+}
+
+// WithMatcher - rule used to find the interaction of a request on replay
+func WithMatcher(matcher Matcher) RecorderOption {
+	return func(r *Recorder) {
+		r.matcher = matcher
+	}
+}
+
+// Recorder - VCR style transport, records real interactions to a cassette file
+// and replays them deterministically in tests. Register it with Option
+type Recorder struct {
+	path    string
+	mode    Mode
+	matcher Matcher
+	redact  map[string]bool
+	next    http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder - recorder for the cassette at path. Replay loads the cassette and fails
+// when it does not exist
+func NewRecorder(path string, mode Mode, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:    path,
+		mode:    mode,
+		matcher: MatchMethodAndURL,
+		redact: map[string]bool{
+			"Authorization":       true,
+			"Proxy-Authorization": true,
+			"Cookie":              true,
+			"Set-Cookie":          true,
+		},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeReplayOrRecord {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+
+	if r.mode == ModeReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("clienthttptest: error reading cassette [%s] - [%w]", path, err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("clienthttptest: error decoding cassette [%s] - [%w]", path, err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+	return r, nil
+}
+
+// Option - client option sending requests through the recorder, recorded requests
+// use the transport configured on the client
+func (r *Recorder) Option() client_http.Option {
+	return client_http.WithTransportWrapper(func(next http.RoundTripper) http.RoundTripper {
+		r.next = next
+		return r
+	})
+}
+
+// Mode - effective mode, ModeReplayOrRecord resolves to replay or record
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// RoundTrip - replay the recorded interaction or record a real one
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body []byte
+	if request.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(request.Body)
+		_ = request.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("clienthttptest: error reading request body [%w]", err)
+		}
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(request, body)
+	}
+	return r.record(request, body)
+}
+
+// replay - answer with the first unused interaction matching request
+func (r *Recorder) replay(request *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || !r.matcher(request, body, interaction.Request) {
+			continue
+		}
+		r.used[i] = true
+		recorded := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       request,
+		}, nil
+	}
+	return nil, fmt.Errorf("clienthttptest: no recorded interaction for %s %s in [%s]", request.Method, request.URL, r.path)
+}
+
+// record - send request to the real server and keep the interaction
+func (r *Recorder) record(request *http.Request, body []byte) (*http.Response, error) {
+	next := r.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	outgoing := request.Clone(request.Context())
+	if body != nil {
+		outgoing.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	response, err := next.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("clienthttptest: error reading response body [%w]", err)
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: RecordedRequest{
+			Method: request.Method,
+			URL:    request.URL.String(),
+			Header: r.redactHeader(request.Header),
+			Body:   body,
+		},
+		Response: RecordedResponse{
+			StatusCode: response.StatusCode,
+			Header:     r.redactHeader(response.Header),
+			Body:       responseBody,
+		},
+	})
+	return response, nil
+}
+
+// redactHeader - copy of header with the redacted values replaced
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	clone := header.Clone()
+	for key := range clone {
+		if r.redact[http.CanonicalHeaderKey(key)] {
+			clone[key] = []string{redacted}
+		}
+	}
+	return clone
+}
+
+// Stop - save the recorded interactions to the cassette, no-op on replay
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("clienthttptest: error encoding cassette [%w]", err)
+	}
+
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("clienthttptest: error creating cassette dir [%s] - [%w]", dir, err)
+		}
+	}
+	if err := ioutil.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("clienthttptest: error writing cassette [%s] - [%w]", r.path, err)
+	}
+	return nil
+}
+
+// Unused - interactions never replayed, useful to detect stale cassettes
+func (r *Recorder) Unused() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []string
+	for i, used := range r.used {
+		if !used {
+			request := r.interactions[i].Request
+			unused = append(unused, strings.TrimSpace(request.Method+" "+request.URL))
+		}
+	}
+	return unused
+}
@@ -0,0 +1,255 @@
+// Package clienthttptest - in-memory transport to unit test code using client_http
+// without starting http servers
+package clienthttptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erikwco/client_http"
+)
+
+// TestingT - subset of testing.TB used by the assertions
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Call - request received by the MockTransport
+type Call struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Stub - canned response for the requests matching it
+type Stub struct {
+	description string
+	match       func(request *http.Request) bool
+
+	status int
+	header http.Header
+	body   []byte
+	delay  time.Duration
+	err    error
+	// times - max requests served, zero means no limit
+	times int
+	used  int
+}
+
+// Reply - answer with status and body
+func (s *Stub) Reply(status int, body []byte) *Stub {
+	s.status = status
+	s.body = body
+	return s
+}
+
+// ReplyString - answer with status and a text body
+func (s *Stub) ReplyString(status int, body string) *Stub {
+	return s.Reply(status, []byte(body))
+}
+
+// ReplyJSON - answer with status and v encoded as json
+func (s *Stub) ReplyJSON(status int, v interface{}) *Stub {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("clienthttptest: error encoding json reply [%v]", err))
+	}
+	s.header.Set("Content-Type", "application/json")
+	return s.Reply(status, body)
+}
+
+// Header - add a response header
+func (s *Stub) Header(key, value string) *Stub {
+	s.header.Add(key, value)
+	return s
+}
+
+// Delay - wait before answering, the request context can abort the wait
+func (s *Stub) Delay(delay time.Duration) *Stub {
+	s.delay = delay
+	return s
+}
+
+// Fail - return err instead of a response, like a network failure
+func (s *Stub) Fail(err error) *Stub {
+	s.err = err
+	return s
+}
+
+// Times - serve at most n requests, later ones go to the next matching stub
+func (s *Stub) Times(n int) *Stub {
+	s.times = n
+	return s
+}
+
+// MockTransport - http.RoundTripper answering with registered stubs, safe for
+// concurrent use. Stubs are checked in registration order
+type MockTransport struct {
+	mu    sync.Mutex
+	stubs []*Stub
+	calls []Call
+}
+
+// NewMockTransport - transport without stubs, every request fails until one is registered
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+// NewClient - client_http.Client sending its requests to m
+func NewClient(m *MockTransport, opts ...client_http.Option) (*client_http.Client, error) {
+	return client_http.NewHttpClient(append(opts, client_http.WithTransport(m))...)
+}
+
+// On - stub requests with method (empty matches any) to rawURL. The query is only
+// compared when rawURL has one
+func (m *MockTransport) On(method, rawURL string) *Stub {
+	return m.OnFunc(method+" "+rawURL, func(request *http.Request) bool {
+		return matchMethod(method, request) && matchURL(rawURL, request)
+	})
+}
+
+// OnPrefix - stub requests with method (empty matches any) to urls starting with prefix
+func (m *MockTransport) OnPrefix(method, prefix string) *Stub {
+	return m.OnFunc(method+" "+prefix+"*", func(request *http.Request) bool {
+		return matchMethod(method, request) && strings.HasPrefix(request.URL.String(), prefix)
+	})
+}
+
+// OnFunc - stub requests accepted by match, description identifies it in assertions
+func (m *MockTransport) OnFunc(description string, match func(request *http.Request) bool) *Stub {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := &Stub{description: description, match: match, status: http.StatusOK, header: http.Header{}}
+	m.stubs = append(m.stubs, s)
+	return s
+}
+
+// RoundTrip - record request and answer with the first matching stub
+func (m *MockTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	call := Call{Method: request.Method, URL: request.URL.String(), Header: request.Header.Clone()}
+	if request.Body != nil {
+		body, err := ioutil.ReadAll(request.Body)
+		_ = request.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("clienthttptest: error reading request body [%w]", err)
+		}
+		call.Body = body
+	}
+
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+	stub := m.match(request)
+	m.mu.Unlock()
+
+	if stub == nil {
+		return nil, fmt.Errorf("clienthttptest: no stub for %s %s", request.Method, request.URL)
+	}
+
+	if stub.delay > 0 {
+		timer := time.NewTimer(stub.delay)
+		defer timer.Stop()
+		select {
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	if stub.err != nil {
+		return nil, stub.err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", stub.status, http.StatusText(stub.status)),
+		StatusCode:    stub.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        stub.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(stub.body)),
+		ContentLength: int64(len(stub.body)),
+		Request:       request,
+	}, nil
+}
+
+// match - first stub matching request with uses left, nil when none. Called with m.mu held
+func (m *MockTransport) match(request *http.Request) *Stub {
+	for _, s := range m.stubs {
+		if (s.times == 0 || s.used < s.times) && s.match(request) {
+			s.used++
+			return s
+		}
+	}
+	return nil
+}
+
+// Calls - requests received, in order
+func (m *MockTransport) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallCount - requests received with method (empty matches any) to rawURL
+func (m *MockTransport) CallCount(method, rawURL string) int {
+	count := 0
+	for _, call := range m.Calls() {
+		request, err := http.NewRequest(call.Method, call.URL, nil)
+		if err == nil && matchMethod(method, request) && matchURL(rawURL, request) {
+			count++
+		}
+	}
+	return count
+}
+
+// AssertCalled - fail t unless method and rawURL were requested
+func (m *MockTransport) AssertCalled(t TestingT, method, rawURL string) {
+	t.Helper()
+	if m.CallCount(method, rawURL) == 0 {
+		t.Errorf("expected call to %s %s, got %d calls", method, rawURL, len(m.Calls()))
+	}
+}
+
+// AssertNotCalled - fail t if method and rawURL were requested
+func (m *MockTransport) AssertNotCalled(t TestingT, method, rawURL string) {
+	t.Helper()
+	if n := m.CallCount(method, rawURL); n > 0 {
+		t.Errorf("unexpected %d calls to %s %s", n, method, rawURL)
+	}
+}
+
+// AssertExpectations - fail t for every stub that served no request
+func (m *MockTransport) AssertExpectations(t TestingT) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.stubs {
+		if s.used == 0 {
+			t.Errorf("stub %s was never called", s.description)
+		}
+	}
+}
+
+// matchMethod - true if method is empty or the method of request
+func matchMethod(method string, request *http.Request) bool {
+	return method == "" || strings.EqualFold(method, request.Method)
+}
+
+// matchURL - true if request goes to rawURL, the query is ignored unless rawURL has one
+func matchURL(rawURL string, request *http.Request) bool {
+	u := *request.URL
+	if !strings.Contains(rawURL, "?") {
+		u.RawQuery = ""
+	}
+	u.Fragment = ""
+	return u.String() == rawURL
+}
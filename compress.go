@@ -0,0 +1,213 @@
+package client_http
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Decompressor - wraps a body compressed with a content encoding
+type Decompressor func(body io.Reader) (io.ReadCloser, error)
+
+// WithDecompression - send Accept-Encoding with every registered encoding (gzip and
+// deflate built in) and decompress response bodies transparently. The original encoding
+// is kept in Response.ContentEncoding
+func WithDecompression() Option {
+	return func(c *Client) error {
+		c.registerDecompressor("gzip", gzipDecompressor)
+		c.registerDecompressor("deflate", deflateDecompressor)
+		return nil
+	}
+}
+
+// WithDecompressor - decompress bodies with encoding (br, zstd...) using decompressor,
+// it enables WithDecompression. See clienthttpcompress for brotli and zstd
+func WithDecompressor(encoding string, decompressor Decompressor) Option {
+	return func(c *Client) error {
+		if encoding == "" || decompressor == nil {
+			return fmt.Errorf("invalid decompressor for encoding [%s]", encoding)
+		}
+		if err := WithDecompression()(c); err != nil {
+			return err
+		}
+		c.registerDecompressor(encoding, decompressor)
+		return nil
+	}
+}
+
+// registerDecompressor - add or replace the decompressor of encoding
+func (c *Client) registerDecompressor(encoding string, decompressor Decompressor) {
+	encoding = strings.ToLower(encoding)
+	if c.decompressors == nil {
+		c.decompressors = map[string]Decompressor{}
+	}
+	if _, ok := c.decompressors[encoding]; !ok {
+		c.encodings = append(c.encodings, encoding)
+	}
+	c.decompressors[encoding] = decompressor
+}
+
+// acceptEncoding - value of the Accept-Encoding header, empty when decompression is off
+func (c *Client) acceptEncoding() string {
+	return strings.Join(c.encodings, ", ")
+}
+
+// decompress - replace the body of response with its decompressed content and return
+// the original encoding, empty when the body was not compressed
+func (c *Client) decompress(response *http.Response) (string, error) {
+	encoding := strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding")))
+	decompressor, ok := c.decompressors[encoding]
+	if encoding == "" || !ok {
+		return "", nil
+	}
+
+	response.Body = &decompressedBody{body: response.Body, decompressor: decompressor}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+
+	return encoding, nil
+}
+
+// decompressedBody - decompresses lazily so empty bodies (HEAD, 204) need no decoder
+type decompressedBody struct {
+	body         io.ReadCloser
+	decompressor Decompressor
+	reader       io.ReadCloser
+	err          error
+}
+
+// Read - read decompressed content
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		buffered := bufio.NewReader(b.body)
+		if _, err := buffered.Peek(1); err == io.EOF {
+			b.err = io.EOF
+		} else if b.reader, b.err = b.decompressor(buffered); b.err != nil {
+			b.err = fmt.Errorf("error decompressing response body [%w]", b.err)
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+// Close - close the decoder and the wrapped body
+func (b *decompressedBody) Close() error {
+	if b.reader != nil {
+		_ = b.reader.Close()
+	}
+	return b.body.Close()
+}
+
+// gzipDecompressor - gzip content encoding
+func gzipDecompressor(body io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(body)
+}
+
+// deflateDecompressor - deflate content encoding, zlib wrapped as the RFC says or raw
+// deflate as some servers send it
+func deflateDecompressor(body io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	// zlib header: CM 8 and check bits
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// WithRequestCompression - gzip request bodies of at least threshold bytes and send them
+// with Content-Encoding: gzip. When a host answers 415 Unsupported Media Type the request
+// is sent again uncompressed and bodies for that host are not compressed anymore.
+// Streamed bodies and requests that already set Content-Encoding are sent as they are
+func WithRequestCompression(threshold int64) Option {
+	return func(c *Client) error {
+		if threshold < 0 {
+			return fmt.Errorf("invalid compression threshold [%d]", threshold)
+		}
+		c.internalMiddlewares = append(c.internalMiddlewares, compressionMiddleware(threshold))
+		return nil
+	}
+}
+
+// compressionMiddleware - gzip request bodies falling back to identity on 415
+func compressionMiddleware(threshold int64) Middleware {
+	var unsupported sync.Map
+
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			if request.GetBody == nil || request.ContentLength < threshold || request.ContentLength == 0 ||
+				request.Header.Get("Content-Encoding") != "" {
+				return next(request)
+			}
+			if _, ok := unsupported.Load(request.URL.Host); ok {
+				return next(request)
+			}
+
+			compressed, err := gzipRequest(request)
+			if err != nil {
+				return nil, err
+			}
+
+			response, err := next(compressed)
+			if err != nil || response.StatusCode != http.StatusUnsupportedMediaType {
+				return response, err
+			}
+
+			// the server does not accept compressed bodies, send it again as it was
+			unsupported.Store(request.URL.Host, true)
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+			_ = response.Body.Close()
+
+			original, err := rewind(request)
+			if err != nil {
+				return nil, err
+			}
+			return next(original)
+		}
+	}
+}
+
+// gzipRequest - copy of request with its body gzip compressed
+func gzipRequest(request *http.Request) (*http.Request, error) {
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body [%w]", err)
+	}
+	defer Defer(func() {
+		_ = body.Close()
+	})
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := io.Copy(writer, body); err != nil {
+		return nil, fmt.Errorf("error compressing request body [%w]", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing request body [%w]", err)
+	}
+
+	payload := buffer.Bytes()
+	compressed := request.Clone(request.Context())
+	compressed.Header.Set("Content-Encoding", "gzip")
+	compressed.ContentLength = int64(len(payload))
+	compressed.Body = ioutil.NopCloser(bytes.NewReader(payload))
+	compressed.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(payload)), nil
+	}
+	return compressed, nil
+}
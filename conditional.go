@@ -0,0 +1,129 @@
+package client_http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ETag - entity tag of the response as sent by the server, weak tags keep their W/ prefix
+func (r *Response) ETag() string {
+	return r.Headers.Get("ETag")
+}
+
+// LastModified - Last-Modified time of the response, zero when missing or invalid
+func (r *Response) LastModified() time.Time {
+	t, err := http.ParseTime(r.Headers.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// NotModified - the server answered a conditional GET with 304
+func (r *Response) NotModified() bool {
+	return r.StatusCode == http.StatusNotModified
+}
+
+// PreconditionFailed - the server rejected a conditional update with 412, the resource
+// changed since its ETag was read
+func (r *Response) PreconditionFailed() bool {
+	return r.StatusCode == http.StatusPreconditionFailed
+}
+
+// IfNoneMatchHeader - If-None-Match header for a conditional GET
+func IfNoneMatchHeader(etag string) HeaderParameters {
+	return HeaderParameters{Key: "If-None-Match", Value: etag}
+}
+
+// IfMatchHeader - If-Match header for a conditional PUT, PATCH or DELETE, the server
+// answers 412 when the resource does not have etag anymore
+func IfMatchHeader(etag string) HeaderParameters {
+	return HeaderParameters{Key: "If-Match", Value: etag}
+}
+
+// IfModifiedSinceHeader - If-Modified-Since header for a conditional GET
+func IfModifiedSinceHeader(t time.Time) HeaderParameters {
+	return HeaderParameters{Key: "If-Modified-Since", Value: t.UTC().Format(http.TimeFormat)}
+}
+
+// IfUnmodifiedSinceHeader - If-Unmodified-Since header for a conditional update
+func IfUnmodifiedSinceHeader(t time.Time) HeaderParameters {
+	return HeaderParameters{Key: "If-Unmodified-Since", Value: t.UTC().Format(http.TimeFormat)}
+}
+
+// IfNoneMatch - send the request only if the resource does not have etag, see IfNoneMatchHeader
+func (b *RequestBuilder) IfNoneMatch(etag string) *RequestBuilder {
+	return b.Headers(IfNoneMatchHeader(etag))
+}
+
+// IfMatch - apply the request only if the resource still has etag, see IfMatchHeader
+func (b *RequestBuilder) IfMatch(etag string) *RequestBuilder {
+	return b.Headers(IfMatchHeader(etag))
+}
+
+// IfModifiedSince - send the body only if the resource changed after t
+func (b *RequestBuilder) IfModifiedSince(t time.Time) *RequestBuilder {
+	return b.Headers(IfModifiedSinceHeader(t))
+}
+
+// IfUnmodifiedSince - apply the request only if the resource did not change after t
+func (b *RequestBuilder) IfUnmodifiedSince(t time.Time) *RequestBuilder {
+	return b.Headers(IfUnmodifiedSinceHeader(t))
+}
+
+// GetIfChanged - conditional GET of url with the validators of previous. It returns
+// previous and false when the server answers 304, previous nil sends a plain GET
+func (c *Client) GetIfChanged(ctx context.Context, url string, previous *Response, headers ...HeaderParameters) (*Response, bool, error) {
+	if previous != nil {
+		headers = append(append([]HeaderParameters{}, headers...), validatorHeaders(previous)...)
+	}
+
+	response, err := c.Get(ctx, url, headers...)
+
+	// not modified is expected even when WithFailOnErrorStatus is enabled
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotModified {
+		return previous, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if response.NotModified() && previous != nil {
+		return previous, false, nil
+	}
+	return response, true, nil
+}
+
+// UpdateIfMatch - send payload with method (PUT, PATCH, DELETE) only if the resource still
+// has etag. A 412 answer is returned as *HTTPError, errors.Is(err, &HTTPError{StatusCode: 412})
+// detects a concurrent change
+func (c *Client) UpdateIfMatch(ctx context.Context, method, url, etag string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	headers = append(append([]HeaderParameters{}, headers...), IfMatchHeader(etag))
+	request, err := c.newRequest(ctx, method, url, payload, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.execute(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.PreconditionFailed() {
+		return nil, c.newHTTPError(request, response)
+	}
+	return response, nil
+}
+
+// validatorHeaders - If-None-Match and If-Modified-Since with the validators of response
+func validatorHeaders(response *Response) []HeaderParameters {
+	var headers []HeaderParameters
+	if etag := response.ETag(); etag != "" {
+		headers = append(headers, IfNoneMatchHeader(etag))
+	}
+	if lastModified := response.Header("Last-Modified"); lastModified != "" {
+		headers = append(headers, HeaderParameters{Key: "If-Modified-Since", Value: lastModified})
+	}
+	return headers
+}
@@ -0,0 +1,56 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestUpdateIfMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Match") != `"v2"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("ETag", `"v3"`)
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient(
+		client_http.WithAPIKey("api_key", apiKeySecret, client_http.APIKeyInQuery),
+		client_http.WithRequestID(""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := client_http.MarkRequestID(context.Background(), "req-7")
+
+	response, err := c.UpdateIfMatch(ctx, http.MethodPut, server.URL+"/items/1", `"v2"`, []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ETag() != `"v3"` {
+		t.Fatalf("etag = %s, want the new version", response.ETag())
+	}
+
+	_, err = c.UpdateIfMatch(ctx, http.MethodPut, server.URL+"/items/1", `"v1"`, []byte(`{}`))
+	var httpErr *client_http.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("error = %v, want 412 *HTTPError", err)
+	}
+	if !errors.Is(err, &client_http.HTTPError{StatusCode: http.StatusPreconditionFailed}) {
+		t.Error("errors.Is does not detect the 412")
+	}
+	if strings.Contains(httpErr.URL, apiKeySecret) || strings.Contains(err.Error(), apiKeySecret) {
+		t.Errorf("api key in error url %s", httpErr.URL)
+	}
+	if httpErr.RequestID != "req-7" {
+		t.Errorf("request id = %q, want req-7", httpErr.RequestID)
+	}
+}
@@ -0,0 +1,181 @@
+package client_http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WithCookieJar - keep cookies between requests using jar
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *Client) error {
+		if jar == nil {
+			return fmt.Errorf("cookie jar can't be nil")
+		}
+		c.Instance.Jar = jar
+		return nil
+	}
+}
+
+// WithCookies - keep cookies between requests in memory, login-then-fetch flows work across calls
+func WithCookies() Option {
+	return func(c *Client) error {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return fmt.Errorf("error creating cookie jar [%w]", err)
+		}
+		c.Instance.Jar = jar
+		return nil
+	}
+}
+
+// Cookies - cookies the jar would send to rawURL
+func (c *Client) Cookies(rawURL string) ([]*http.Cookie, error) {
+	if c.Instance.Jar == nil {
+		return nil, fmt.Errorf("client has no cookie jar")
+	}
+	u, err := url.Parse(c.resolveURL(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url [%s] - [%w]", rawURL, err)
+	}
+	return c.Instance.Jar.Cookies(u), nil
+}
+
+// SetCookies - store cookies in the jar as if rawURL had set them
+func (c *Client) SetCookies(rawURL string, cookies ...*http.Cookie) error {
+	if c.Instance.Jar == nil {
+		return fmt.Errorf("client has no cookie jar")
+	}
+	u, err := url.Parse(c.resolveURL(rawURL))
+	if err != nil {
+		return fmt.Errorf("invalid url [%s] - [%w]", rawURL, err)
+	}
+	c.Instance.Jar.SetCookies(u, cookies)
+	return nil
+}
+
+// PersistentJar - in memory cookie jar that can be saved to and loaded from a file,
+// to resume sessions between process restarts
+type PersistentJar struct {
+	path string
+	jar  *cookiejar.Jar
+
+	mu sync.Mutex
+	// entries - cookies received by url, used to rebuild the jar on load
+	entries map[string]map[string]*http.Cookie
+}
+
+// NewPersistentJar - jar saved in path, cookies already stored there are loaded
+func NewPersistentJar(path string) (*PersistentJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cookie jar [%w]", err)
+	}
+
+	j := &PersistentJar{path: path, jar: jar, entries: map[string]map[string]*http.Cookie{}}
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// SetCookies - implements http.CookieJar
+func (j *PersistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+	if j.entries[key] == nil {
+		j.entries[key] = map[string]*http.Cookie{}
+	}
+	for _, cookie := range cookies {
+		stored := *cookie
+		// MaxAge is relative to now, keep it as an absolute expiration
+		if stored.MaxAge > 0 {
+			stored.Expires = time.Now().Add(time.Duration(stored.MaxAge) * time.Second)
+			stored.MaxAge = 0
+		}
+		j.entries[key][stored.Name+";"+stored.Domain+";"+stored.Path] = &stored
+	}
+}
+
+// Cookies - implements http.CookieJar
+func (j *PersistentJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// Save - write the cookies that are still valid into the jar file
+func (j *PersistentJar) Save() error {
+	j.mu.Lock()
+	stored := map[string][]*http.Cookie{}
+	for key, cookies := range j.entries {
+		for _, cookie := range cookies {
+			if cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now())) {
+				continue
+			}
+			stored[key] = append(stored[key], cookie)
+		}
+	}
+	j.mu.Unlock()
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("error encoding cookies [%w]", err)
+	}
+
+	// write a temporary file and rename it to never leave a partial jar
+	tmp, err := ioutil.TempFile(filepath.Dir(j.path), filepath.Base(j.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("error saving cookies [%w]", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("error saving cookies [%w]", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("error saving cookies [%w]", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("error saving cookies [%w]", err)
+	}
+
+	return nil
+}
+
+// load - restore the cookies of the jar file, a missing file is an empty jar
+func (j *PersistentJar) load() error {
+	data, err := ioutil.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading cookies [%s] - [%w]", j.path, err)
+	}
+
+	stored := map[string][]*http.Cookie{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("error decoding cookies [%s] - [%w]", j.path, err)
+	}
+
+	for key, cookies := range stored {
+		u, err := url.Parse(key)
+		if err != nil {
+			continue
+		}
+		j.SetCookies(u, cookies)
+	}
+
+	return nil
+}
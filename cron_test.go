@@ -0,0 +1,67 @@
+package client_http_test
+
+import (
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestCronNext(t *testing.T) {
+	// monday
+	from := time.Date(2024, 1, 1, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{expr: "*/15 9-17 * * 1-5", from: from, want: time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{expr: "*/15 9-17 * * 1-5", from: time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC), want: time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)},
+		{expr: "*/15 9-17 * * 1-5", from: time.Date(2024, 1, 5, 17, 50, 0, 0, time.UTC), want: time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+		{expr: "@hourly", from: from, want: time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{expr: "@daily", from: from, want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{expr: "@yearly", from: from, want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "30 8 * * 6", from: from, want: time.Date(2024, 1, 6, 8, 30, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", from: from, want: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 12 1 * *", from: from, want: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 9 1 * *", from: from, want: time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", from: from, want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "5,35 0 1,15 6 *", from: from, want: time.Date(2024, 6, 1, 0, 5, 0, 0, time.UTC)},
+		// day of month or day of week when both are restricted
+		{expr: "0 0 13 * 5", from: from, want: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{expr: "@every 90s", from: from, want: time.Date(2024, 1, 1, 10, 9, 0, 0, time.UTC)},
+		{expr: "0 0 31 4 *", from: from, want: time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := client_http.ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC-3", -3*60*60)
+	schedule, err := client_http.ParseCron("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := schedule.Next(time.Date(2024, 1, 1, 10, 0, 0, 0, loc))
+	if want := time.Date(2024, 1, 2, 9, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@every -1s", "@every soon", "@often"} {
+		if _, err := client_http.ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) = nil error", expr)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package client_http
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// contentTypeCSV - media type of comma separated values
+const contentTypeCSV = "text/csv"
+
+// CSVOptions - format of a CSV response, nil means comma separated values with a header row
+type CSVOptions struct {
+	// Comma - field delimiter, ',' by default
+	Comma rune
+	// Comment - lines starting with it are ignored, none by default
+	Comment rune
+	// Columns - column names of responses without a header row
+	Columns []string
+	// LazyQuotes - accept quotes inside unquoted fields
+	LazyQuotes bool
+}
+
+// EachCSV - read the CSV body one record at a time calling handler with the values keyed
+// by column name, so large reports are never held in memory. It stops at the first
+// handler error, which is returned as is, and closes the body
+func (s *StreamResponse) EachCSV(opts *CSVOptions, handler func(record map[string]string) error) error {
+	defer Defer(s.closeBody)
+
+	return readCSV(s.Body, opts, func(line int, columns, record []string) error {
+		values := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if i < len(record) {
+				values[column] = record[i]
+			}
+		}
+		return handler(values)
+	})
+}
+
+// DecodeCSV - like StreamResponse.EachCSV decoding every record into the struct T. Columns
+// are matched with the `csv` tag of the fields, or their name ignoring case, the tag
+// options of EncodeQuery apply to times. Unknown columns are ignored and empty values leave
+// the field unset
+func DecodeCSV[T any](s *StreamResponse, opts *CSVOptions, handler func(row T) error) error {
+	defer Defer(s.closeBody)
+
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	if rt.Kind() != reflect.Struct {
+		return fmt.Errorf("can't decode csv into [%s], a struct is expected", rt)
+	}
+	fields := map[string]csvField{}
+	csvFields(rt, nil, fields)
+
+	var bindings []*csvField
+	return readCSV(s.Body, opts, func(line int, columns, record []string) error {
+		if bindings == nil {
+			bindings = bindColumns(columns, fields)
+		}
+
+		var row T
+		rv := reflect.ValueOf(&row).Elem()
+		for i, field := range bindings {
+			if field == nil || i >= len(record) {
+				continue
+			}
+			if err := decodeScalar(rv.FieldByIndex(field.index), record[i], field.opts); err != nil {
+				return fmt.Errorf("error decoding csv line [%d] column [%s] - [%w]", line, columns[i], err)
+			}
+		}
+		return handler(row)
+	})
+}
+
+// GetCSV - GET url and decode its CSV response record by record into T, see DecodeCSV.
+// Non-2xx responses are returned as *HTTPError
+func GetCSV[T any](ctx context.Context, c *Client, url string, opts *CSVOptions, handler func(row T) error, headers ...HeaderParameters) error {
+	csvHeaders := append([]HeaderParameters{{Key: "Accept", Value: contentTypeCSV}}, headers...)
+	request, err := c.newRequest(ctx, http.MethodGet, url, nil, csvHeaders)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.stream(request)
+	if err != nil {
+		return err
+	}
+	if !isSuccess(response.StatusCode) {
+		return c.streamHTTPError(request, &http.Response{
+			Status:     response.Status,
+			StatusCode: response.StatusCode,
+			Header:     response.Headers,
+			Body:       response.Body,
+		})
+	}
+	return DecodeCSV(response, opts, handler)
+}
+
+// readCSV - call handler with the column names and every record of body
+func readCSV(body io.Reader, opts *CSVOptions, handler func(line int, columns, record []string) error) error {
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+	reader := csv.NewReader(body)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	reader.Comment = opts.Comment
+	reader.LazyQuotes = opts.LazyQuotes
+	reader.ReuseRecord = true
+
+	columns := opts.Columns
+	if len(columns) == 0 {
+		header, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading csv header [%w]", err)
+		}
+		columns = append([]string{}, header...)
+		// byte order mark of spreadsheet exports
+		columns[0] = strings.TrimPrefix(columns[0], "\ufeff")
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading csv [%w]", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if err := handler(line, columns, record); err != nil {
+			return err
+		}
+	}
+}
+
+// csvField - struct field bound to a column
+type csvField struct {
+	index []int
+	opts  valueOptions
+}
+
+// csvFields - exported fields of t by column name, embedded structs are flattened
+func csvFields(t reflect.Type, index []int, fields map[string]csvField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, skip := parseValueTag(field, "csv")
+		if skip {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("csv") == "" {
+			csvFields(field.Type, fieldIndex, fields)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = csvField{index: fieldIndex, opts: opts}
+		}
+	}
+}
+
+// bindColumns - field of every column, exact names first then ignoring case
+func bindColumns(columns []string, fields map[string]csvField) []*csvField {
+	bindings := make([]*csvField, len(columns))
+	for i, column := range columns {
+		column = strings.TrimSpace(column)
+		if field, ok := fields[column]; ok {
+			bindings[i] = &field
+			continue
+		}
+		for name, field := range fields {
+			if strings.EqualFold(name, column) {
+				field := field
+				bindings[i] = &field
+				break
+			}
+		}
+	}
+	return bindings
+}
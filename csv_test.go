@@ -0,0 +1,119 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+type csvTimestamps struct {
+	Day time.Time `csv:"day" layout:"2006-01-02"`
+}
+
+type salesRow struct {
+	csvTimestamps
+	Region string  `csv:"region"`
+	Units  int     `csv:"units"`
+	Price  float64 `csv:"price"`
+	Note   *string `csv:"note"`
+	Ignore string  `csv:"-"`
+}
+
+func TestGetCSV(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sales":
+			w.Header().Set("Content-Type", "text/csv")
+			_, _ = w.Write([]byte("\ufeffDAY;Region;units;price;note;extra\n" +
+				"# generated report\n" +
+				"2024-03-01;north;3;1.5;;x\n" +
+				"2024-03-02;\"south; east\";;2;late;y\n"))
+		case "/invalid":
+			_, _ = w.Write([]byte("units\nmany\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	opts := &client_http.CSVOptions{Comma: ';', Comment: '#'}
+
+	var rows []salesRow
+	err = client_http.GetCSV(context.Background(), c, server.URL+"/sales", opts, func(row salesRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	late := "late"
+	want := []salesRow{
+		{csvTimestamps: csvTimestamps{Day: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, Region: "north", Units: 3, Price: 1.5},
+		{csvTimestamps: csvTimestamps{Day: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)}, Region: "south; east", Price: 2, Note: &late},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %+v, want %+v", rows, want)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = client_http.GetCSV(context.Background(), c, server.URL+"/sales", opts, func(row salesRow) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Fatalf("error = %v after %d rows, want the handler error after 1", err, calls)
+	}
+
+	err = client_http.GetCSV(context.Background(), c, server.URL+"/invalid", nil, func(row salesRow) error { return nil })
+	if err == nil {
+		t.Fatal("error = nil, want the invalid units")
+	}
+
+	var httpErr *client_http.HTTPError
+	err = client_http.GetCSV(context.Background(), c, server.URL+"/missing", nil, func(row salesRow) error { return nil })
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("error = %v, want 404 *HTTPError", err)
+	}
+}
+
+func TestEachCSV(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("1,a\n2,b\n"))
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	stream, err := c.GetStream(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]string
+	err = stream.EachCSV(&client_http.CSVOptions{Columns: []string{"id", "name"}}, func(record map[string]string) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{{"id": "1", "name": "a"}, {"id": "2", "name": "b"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("records = %v, want %v", records, want)
+	}
+}
@@ -0,0 +1,115 @@
+package client_http
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// Decoder - decodes a response body into v
+type Decoder func(data []byte, v interface{}) error
+
+// decoderRegistry - decoders by media type, in registration order
+type decoderRegistry struct {
+	decoders   map[string]Decoder
+	mediaTypes []string
+}
+
+// newDecoderRegistry - registry with the built in json, xml and form decoders
+func newDecoderRegistry() *decoderRegistry {
+	r := &decoderRegistry{decoders: map[string]Decoder{}}
+	r.register(contentTypeJSON, json.Unmarshal)
+	r.register("application/xml", xml.Unmarshal)
+	r.register("text/xml", xml.Unmarshal)
+	r.register(contentTypeForm, decodeForm)
+	return r
+}
+
+// register - add or replace the decoder of mediaType
+func (r *decoderRegistry) register(mediaType string, decoder Decoder) {
+	mediaType = strings.ToLower(mediaType)
+	if _, ok := r.decoders[mediaType]; !ok {
+		r.mediaTypes = append(r.mediaTypes, mediaType)
+	}
+	r.decoders[mediaType] = decoder
+}
+
+// lookup - decoder of contentType, structured syntax suffixes (+json, +xml) fall back to
+// the json and xml decoders
+func (r *decoderRegistry) lookup(contentType string) (Decoder, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type [%s] - [%w]", contentType, err)
+	}
+
+	if decoder, ok := r.decoders[mediaType]; ok {
+		return decoder, nil
+	}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		switch mediaType[i+1:] {
+		case "json":
+			return r.lookup(contentTypeJSON)
+		case "xml":
+			return r.lookup("application/xml")
+		}
+	}
+	return nil, fmt.Errorf("no decoder registered for content type [%s]", mediaType)
+}
+
+// defaultDecoders - registry of responses not created by a client
+var defaultDecoders = newDecoderRegistry()
+
+// WithDecoder - decode responses of mediaType (application/x-protobuf...) with decoder in
+// Response.Decode, it replaces the built in decoder of the same media type
+func WithDecoder(mediaType string, decoder Decoder) Option {
+	return func(c *Client) error {
+		if mediaType == "" || decoder == nil {
+			return fmt.Errorf("invalid decoder for media type [%s]", mediaType)
+		}
+		c.decoders.register(mediaType, decoder)
+		return nil
+	}
+}
+
+// Decode - decode the body into v with the decoder registered for the response Content-Type
+func (r *Response) Decode(v interface{}) error {
+	decoders := r.decoders
+	if decoders == nil {
+		decoders = defaultDecoders
+	}
+
+	decoder, err := decoders.lookup(r.ContentType())
+	if err != nil {
+		return err
+	}
+	if err := decoder(r.Body, v); err != nil {
+		return fmt.Errorf("error decoding [%s] response [%w]", r.ContentType(), err)
+	}
+	return nil
+}
+
+// decodeForm - decode a form body into *url.Values, *map[string][]string or *map[string]string
+func decodeForm(data []byte, v interface{}) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	switch target := v.(type) {
+	case *url.Values:
+		*target = values
+	case *map[string][]string:
+		*target = values
+	case *map[string]string:
+		*target = make(map[string]string, len(values))
+		for key := range values {
+			(*target)[key] = values.Get(key)
+		}
+	default:
+		return fmt.Errorf("can't decode form into [%T]", v)
+	}
+	return nil
+}
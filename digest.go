@@ -0,0 +1,262 @@
+package client_http
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// WithDigestAuth - authenticate with HTTP digest (RFC 7616, MD5 and SHA-256 with
+// qop=auth). The first request to a host gets the challenge, following ones reuse it
+func WithDigestAuth(username, password string) Option {
+	return func(c *Client) error {
+		if username == "" {
+			return fmt.Errorf("digest username can't be empty")
+		}
+		d := &digestAuth{username: username, password: password, challenges: map[string]*digestChallenge{}}
+		c.internalMiddlewares = append(c.internalMiddlewares, d.middleware())
+		return nil
+	}
+}
+
+// digestAuth - credentials and last challenge of every host
+type digestAuth struct {
+	username string
+	password string
+
+	mu         sync.Mutex
+	challenges map[string]*digestChallenge
+}
+
+// digestChallenge - parameters of a Digest WWW-Authenticate header
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	userhash  bool
+	// nc - requests sent with nonce
+	nc int
+}
+
+// middleware - answer digest challenges and authorize requests to known hosts
+func (d *digestAuth) middleware() Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			if request.Header.Get("Authorization") != "" {
+				return next(request)
+			}
+
+			// authorize a copy, retries of the original request get a fresh nonce count
+			host := request.URL.Host
+			if authorization, ok := d.authorization(host, request); ok {
+				request = request.Clone(request.Context())
+				request.Header.Set("Authorization", authorization)
+			}
+
+			response, err := next(request)
+			if err != nil || response.StatusCode != http.StatusUnauthorized || !rewindable(request) {
+				return response, err
+			}
+
+			challenge, ok := parseDigestChallenge(response.Header.Values("WWW-Authenticate"))
+			if !ok {
+				return response, nil
+			}
+			d.mu.Lock()
+			d.challenges[host] = challenge
+			d.mu.Unlock()
+
+			retry, err := rewind(request)
+			if err != nil {
+				return response, nil
+			}
+			authorization, _ := d.authorization(host, retry)
+			retry.Header.Set("Authorization", authorization)
+
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+			_ = response.Body.Close()
+			return next(retry)
+		}
+	}
+}
+
+// authorization - Authorization header for request with the challenge of host
+func (d *digestAuth) authorization(host string, request *http.Request) (string, bool) {
+	d.mu.Lock()
+	challenge, ok := d.challenges[host]
+	if !ok {
+		d.mu.Unlock()
+		return "", false
+	}
+	challenge.nc++
+	nc := fmt.Sprintf("%08x", challenge.nc)
+	c := *challenge
+	d.mu.Unlock()
+
+	newHash := digestHash(c.algorithm)
+	h := func(s string) string {
+		hasher := newHash()
+		hasher.Write([]byte(s))
+		return hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	cnonce := randomNonce()
+	uri := request.URL.RequestURI()
+
+	ha1 := h(d.username + ":" + c.realm + ":" + d.password)
+	if strings.HasSuffix(strings.ToUpper(c.algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+	ha2 := h(request.Method + ":" + uri)
+
+	var response string
+	if c.qop != "" {
+		response = h(ha1 + ":" + c.nonce + ":" + nc + ":" + cnonce + ":" + c.qop + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	}
+
+	username := d.username
+	if c.userhash {
+		username = h(d.username + ":" + c.realm)
+	}
+
+	fields := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, c.realm),
+		fmt.Sprintf(`nonce="%s"`, c.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if c.algorithm != "" {
+		fields = append(fields, "algorithm="+c.algorithm)
+	}
+	if c.opaque != "" {
+		fields = append(fields, fmt.Sprintf(`opaque="%s"`, c.opaque))
+	}
+	if c.qop != "" {
+		fields = append(fields, "qop="+c.qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if c.userhash {
+		fields = append(fields, "userhash=true")
+	}
+	return "Digest " + strings.Join(fields, ", "), true
+}
+
+// parseDigestChallenge - strongest supported Digest challenge of the WWW-Authenticate
+// headers, qop=auth is required when the server offers qop
+func parseDigestChallenge(headers []string) (*digestChallenge, bool) {
+	var best *digestChallenge
+	for _, header := range headers {
+		if len(header) < 7 || !strings.EqualFold(header[:7], "digest ") {
+			continue
+		}
+		params := parseAuthParams(header[7:])
+		c := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+			userhash:  strings.EqualFold(params["userhash"], "true"),
+		}
+		if c.nonce == "" || digestHash(c.algorithm) == nil {
+			continue
+		}
+		if qop, ok := params["qop"]; ok {
+			for _, q := range strings.Split(qop, ",") {
+				if strings.TrimSpace(q) == "auth" {
+					c.qop = "auth"
+				}
+			}
+			if c.qop == "" {
+				continue
+			}
+		}
+		if best == nil || digestStrength(c.algorithm) > digestStrength(best.algorithm) {
+			best = c
+		}
+	}
+	return best, best != nil
+}
+
+// digestHash - hash function of algorithm, nil when it is not supported
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "", "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	case "SHA-512-256":
+		return sha512.New512_256
+	}
+	return nil
+}
+
+// digestStrength - preference of algorithm when several challenges are offered
+func digestStrength(algorithm string) int {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "SHA-512-256":
+		return 2
+	case "SHA-256":
+		return 1
+	}
+	return 0
+}
+
+// parseAuthParams - comma separated key=value and key="quoted value" parameters
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,\t")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value = b.String()
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// randomNonce - random client nonce
+func randomNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,141 @@
+package client_http_test
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+// digestServer - server accepting digest credentials user:pass with qop=auth, the
+// challenge offers algorithms and a request with an unknown nonce or a nonce count
+// already used is challenged again
+type digestServer struct {
+	algorithms []string
+
+	mu         sync.Mutex
+	challenges int
+	lastNC     int64
+	algorithm  string
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	if r.Method == http.MethodPost && string(body) != "payload" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !s.authorized(r) {
+		s.mu.Lock()
+		s.challenges++
+		s.mu.Unlock()
+		for _, algorithm := range s.algorithms {
+			w.Header().Add("WWW-Authenticate", `Digest realm="test", nonce="n1", opaque="o1", qop="auth", algorithm=`+algorithm)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+}
+
+func (s *digestServer) authorized(r *http.Request) bool {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Digest ") {
+		return false
+	}
+	params := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, "Digest "), ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		params[name] = strings.Trim(value, `"`)
+	}
+	if params["nonce"] != "n1" || params["opaque"] != "o1" || params["uri"] != r.URL.RequestURI() {
+		return false
+	}
+
+	var newHash func() hash.Hash
+	switch params["algorithm"] {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return false
+	}
+	h := func(s string) string {
+		hasher := newHash()
+		hasher.Write([]byte(s))
+		return hex.EncodeToString(hasher.Sum(nil))
+	}
+	ha1 := h("user:test:pass")
+	ha2 := h(r.Method + ":" + params["uri"])
+	if params["response"] != h(ha1+":n1:"+params["nc"]+":"+params["cnonce"]+":auth:"+ha2) {
+		return false
+	}
+
+	nc, err := strconv.ParseInt(params["nc"], 16, 64)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil || nc <= s.lastNC {
+		return false
+	}
+	s.lastNC = nc
+	s.algorithm = params["algorithm"]
+	return true
+}
+
+func TestDigestAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		algorithms []string
+		password   string
+		want       int
+		algorithm  string
+	}{
+		{name: "md5", algorithms: []string{"MD5"}, password: "pass", want: http.StatusOK, algorithm: "MD5"},
+		{name: "sha-256 preferred", algorithms: []string{"MD5", "SHA-256"}, password: "pass", want: http.StatusOK, algorithm: "SHA-256"},
+		{name: "wrong password", algorithms: []string{"SHA-256"}, password: "wrong", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &digestServer{algorithms: tt.algorithms}
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			c, err := client_http.NewHttpClient(client_http.WithDigestAuth("user", tt.password))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodGet} {
+				response, err := c.Do(context.Background(), method, server.URL+"/items?page=1", []byte("payload"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if response.StatusCode != tt.want {
+					t.Fatalf("%s: status = %d, want %d", method, response.StatusCode, tt.want)
+				}
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			handler.mu.Lock()
+			defer handler.mu.Unlock()
+			// following requests reuse the challenge with a new nonce count
+			if handler.challenges != 1 || handler.lastNC != 3 {
+				t.Fatalf("%d challenges, nonce count %d, want 1 challenge and 3 requests", handler.challenges, handler.lastNC)
+			}
+			if handler.algorithm != tt.algorithm {
+				t.Fatalf("algorithm = %s, want %s", handler.algorithm, tt.algorithm)
+			}
+		})
+	}
+}
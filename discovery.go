@@ -0,0 +1,173 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Discovery - source of the endpoints of a service, DNS SRV records or a registry like
+// Consul or etcd
+type Discovery interface {
+	Endpoints(ctx context.Context) ([]Endpoint, error)
+}
+
+// DiscoveryFunc - adapter to use a function as a Discovery
+type DiscoveryFunc func(ctx context.Context) ([]Endpoint, error)
+
+// Endpoints - call f
+func (f DiscoveryFunc) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	return f(ctx)
+}
+
+// SRVDiscovery - endpoints of the SRV records of _service._proto.name with the given
+// scheme ("http" or "https"). Only the records with the lowest priority are used, their
+// weights are the endpoint weights. With WithServiceDiscovery the records are looked up
+// with the resolver of the client when it is a SRVResolver (WithDNSServers), the system
+// resolver otherwise
+func SRVDiscovery(service, proto, name, scheme string) Discovery {
+	return &srvDiscovery{service: service, proto: proto, name: name, scheme: scheme}
+}
+
+// srvDiscovery - Discovery of SRVDiscovery
+type srvDiscovery struct {
+	service, proto, name, scheme string
+	// client - client whose resolver is used, nil for the system resolver
+	client *Client
+}
+
+// Endpoints - endpoints of the records with the lowest priority
+func (s *srvDiscovery) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	var resolver SRVResolver = net.DefaultResolver
+	if s.client != nil {
+		if r, ok := s.client.resolver.(SRVResolver); ok {
+			resolver = r
+		}
+	}
+
+	_, records, err := resolver.LookupSRV(ctx, s.service, s.proto, s.name)
+	if err != nil {
+		return nil, fmt.Errorf("error looking up srv records of [%s] [%w]", s.name, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no srv records found for [%s]", s.name)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Priority < records[j].Priority })
+	var endpoints []Endpoint
+	for _, record := range records {
+		if record.Priority != records[0].Priority {
+			break
+		}
+		host := strings.TrimSuffix(record.Target, ".")
+		endpoints = append(endpoints, Endpoint{
+			URL:    s.scheme + "://" + net.JoinHostPort(host, strconv.Itoa(int(record.Port))),
+			Weight: int(record.Weight),
+		})
+	}
+	return endpoints, nil
+}
+
+// DiscoveryConfig - service of WithServiceDiscovery
+type DiscoveryConfig struct {
+	// Origin - url of the requests balanced across the discovered endpoints, like
+	// "http://users.service". Its path is replaced like with WithLoadBalancer
+	Origin    string
+	Discovery Discovery
+	// RefreshInterval - time between lookups of the endpoints, 30s by default
+	RefreshInterval time.Duration
+	// Balancing - strategy, health checks and ejection of the discovered endpoints, its
+	// Endpoints are used until the first lookup succeeds
+	Balancing LoadBalancerConfig
+}
+
+// WithServiceDiscovery - balance requests to the origin across the endpoints found by the
+// discovery, looked up again every refresh interval until Close. A failed lookup keeps
+// the previous endpoints, the base url is set to the origin when WithBaseURL is not used
+func WithServiceDiscovery(config DiscoveryConfig) Option {
+	return func(c *Client) error {
+		if config.Discovery == nil {
+			return fmt.Errorf("service discovery can't be nil")
+		}
+		origin, err := url.Parse(config.Origin)
+		if err != nil {
+			return fmt.Errorf("invalid discovery origin [%s] - [%w]", config.Origin, err)
+		}
+		if origin.Scheme == "" || origin.Host == "" {
+			return fmt.Errorf("discovery origin [%s] must be absolute", config.Origin)
+		}
+		origin.Path = strings.TrimRight(origin.Path, "/")
+		if config.RefreshInterval <= 0 {
+			config.RefreshInterval = 30 * time.Second
+		}
+
+		b, err := newBalancer(origin, config.Balancing)
+		if err != nil {
+			return err
+		}
+		if srv, ok := config.Discovery.(*srvDiscovery); ok {
+			bound := *srv
+			bound.client = c
+			config.Discovery = &bound
+		}
+		d := &discoverer{balancer: b, discovery: config.Discovery, client: c}
+		if c.baseURL == "" {
+			c.baseURL = strings.TrimRight(origin.String(), "/")
+		}
+		c.routingMiddlewares = append(c.routingMiddlewares, b.middlewareWith(c, d.refresh))
+		c.background = append(c.background, func(ctx context.Context) { d.run(ctx, config.RefreshInterval) })
+		if b.healthCheck != nil {
+			c.background = append(c.background, func(ctx context.Context) { b.probe(ctx, c) })
+		}
+		return nil
+	}
+}
+
+// discoverer - keeps the endpoints of a balancer up to date
+type discoverer struct {
+	balancer  *balancer
+	discovery Discovery
+	client    *Client
+
+	// mu - one lookup at a time
+	mu sync.Mutex
+}
+
+// run - refresh the endpoints every interval until ctx is done
+func (d *discoverer) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := d.refresh(ctx); err != nil && ctx.Err() == nil {
+			d.client.logger.Log(LevelError, "service discovery failed", map[string]interface{}{"origin": redactURL(d.balancer.origin.String()), "error": err.Error()})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh - look up the endpoints and replace the balancer ones, an empty result is
+// an error so the previous endpoints are kept
+func (d *discoverer) refresh(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	endpoints, err := d.discovery.Endpoints(ctx)
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		return fmt.Errorf("no endpoints discovered for [%s]", redactURL(d.balancer.origin.String()))
+	}
+	return d.balancer.setEndpoints(endpoints)
+}
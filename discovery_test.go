@@ -0,0 +1,43 @@
+package client_http_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestSRVDiscoveryUsesClientResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Host", r.Host)
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	srvPort, _ := strconv.Atoi(port)
+
+	// svc.test and api.test only exist in the fake server
+	dns := newFakeDNS(t, 30, uint16(srvPort))
+	c, err := client_http.NewHttpClient(
+		client_http.WithDNSServers(dns.addr),
+		client_http.WithServiceDiscovery(client_http.DiscoveryConfig{
+			Origin:    "http://users.service",
+			Discovery: client_http.SRVDiscovery("http", "tcp", "svc.test", "http"),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	response, err := c.Get(context.Background(), "/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.Headers.Get("X-Host"); got != "api.test:"+port {
+		t.Fatalf("request sent to [%s], want the discovered api.test:%s", got, port)
+	}
+}
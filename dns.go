@@ -0,0 +1,233 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Resolver - looks up the addresses of a host, *net.Resolver implements it.
+// Custom implementations can query specific servers or DNS over HTTPS
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// TTLResolver - Resolver that also reports how long the answer is valid,
+// WithDNSCache keeps its answers for the TTL instead of the cache default.
+// *DNSResolver implements it
+type TTLResolver interface {
+	Resolver
+	LookupHostTTL(ctx context.Context, host string) ([]string, time.Duration, error)
+}
+
+// SRVResolver - Resolver that also looks up SRV records, used by SRVDiscovery.
+// *net.Resolver and *DNSResolver implement it
+type SRVResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// WithResolver - resolve hosts of new connections with resolver
+func WithResolver(resolver Resolver) Option {
+	return func(c *Client) error {
+		if resolver == nil {
+			return fmt.Errorf("resolver can't be nil")
+		}
+		c.resolver = resolver
+		return nil
+	}
+}
+
+// WithDNSServers - resolve hosts querying servers (host:port) in order instead of the
+// system configuration, see NewDNSResolver. With WithDNSCache the answers are kept for
+// their TTL
+func WithDNSServers(servers ...string) Option {
+	return func(c *Client) error {
+		resolver, err := NewDNSResolver(servers...)
+		if err != nil {
+			return err
+		}
+		c.resolver = resolver
+		return nil
+	}
+}
+
+// WithDNSCache - keep resolved addresses for ttl, saving a lookup per new connection.
+// Answers of a TTLResolver (WithDNSServers) are kept for their own TTL when it is
+// shorter, other resolvers do not report TTLs so their answers are kept for ttl
+func WithDNSCache(ttl time.Duration) Option {
+	return func(c *Client) error {
+		if ttl <= 0 {
+			return fmt.Errorf("invalid dns cache ttl [%v]", ttl)
+		}
+		c.dnsCache = &dnsCache{ttl: ttl, entries: map[string]dnsEntry{}}
+		return nil
+	}
+}
+
+// WithHostOverride - connect to address (ip:port or ip) instead of resolving host, like
+// an /etc/hosts entry. TLS server name and Host header still use host. A host with port
+// only overrides that port, an address without port keeps the port of the request
+func WithHostOverride(host, address string) Option {
+	return func(c *Client) error {
+		if host == "" || address == "" {
+			return fmt.Errorf("invalid host override [%s] - [%s]", host, address)
+		}
+		if c.hostOverrides == nil {
+			c.hostOverrides = map[string]string{}
+		}
+		c.hostOverrides[strings.ToLower(host)] = address
+		return nil
+	}
+}
+
+// overrideAddr - address to dial for addr following the host overrides
+func (c *Client) overrideAddr(addr string) string {
+	if len(c.hostOverrides) == 0 {
+		return addr
+	}
+	if address, ok := c.hostOverrides[strings.ToLower(addr)]; ok {
+		return address
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	address, ok := c.hostOverrides[strings.ToLower(host)]
+	if !ok {
+		return addr
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return net.JoinHostPort(address, port)
+	}
+	return address
+}
+
+// dnsEntry - cached addresses of a host
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsCache - resolved addresses by host
+type dnsCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+// get - cached addresses of host, false when missing or expired
+func (d *dnsCache) get(host string) ([]string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.entries[host]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.addrs, true
+}
+
+// put - keep addrs of host for ttl capped by the cache ttl, zero is the cache ttl
+func (d *dnsCache) put(host string, addrs []string, ttl time.Duration) {
+	if ttl <= 0 || ttl > d.ttl {
+		ttl = d.ttl
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(ttl)}
+}
+
+// dialContext - dial addr respecting the host connection limits, the connection is
+// counted as open until closed
+func (c *Client) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	release, err := c.acquireConn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.dial(ctx, network, c.overrideAddr(addr))
+	if err != nil {
+		if release != nil {
+			release()
+		}
+		return nil, err
+	}
+
+	atomic.AddInt64(&c.pool.open, 1)
+	atomic.AddInt64(&c.pool.dialed, 1)
+	return &trackedConn{Conn: conn, onClose: func() {
+		atomic.AddInt64(&c.pool.open, -1)
+		if release != nil {
+			release()
+		}
+	}}, nil
+}
+
+// dial - dial addr resolving the host with the client resolver and cache, every
+// address is tried in order until one connects
+func (c *Client) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if c.resolver == nil && c.dnsCache == nil {
+		return c.dialer.DialContext(ctx, network, addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := c.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range addrs {
+		var conn net.Conn
+		if conn, err = c.dialer.DialContext(ctx, network, net.JoinHostPort(a, port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// lookupHost - addresses of host from the cache or the resolver
+func (c *Client) lookupHost(ctx context.Context, host string) ([]string, error) {
+	if c.dnsCache != nil {
+		if addrs, ok := c.dnsCache.get(host); ok {
+			return addrs, nil
+		}
+	}
+
+	var resolver Resolver = net.DefaultResolver
+	if c.resolver != nil {
+		resolver = c.resolver
+	}
+
+	var addrs []string
+	var ttl time.Duration
+	var err error
+	r, reportsTTL := resolver.(TTLResolver)
+	if reportsTTL {
+		addrs, ttl, err = r.LookupHostTTL(ctx, host)
+	} else {
+		addrs, err = resolver.LookupHost(ctx, host)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error resolving host [%s] - [%w]", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no addresses found", Name: host, IsNotFound: true}
+	}
+
+	// a zero TTL answer must not be cached
+	if c.dnsCache != nil && (!reportsTTL || ttl > 0) {
+		c.dnsCache.put(host, addrs, ttl)
+	}
+	return addrs, nil
+}
@@ -0,0 +1,196 @@
+package client_http_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeDNS - DNS server on 127.0.0.1 answering A questions of api.test with 127.0.0.1,
+// big.test over UDP truncated and over TCP with 127.0.0.2, v4only.test with 127.0.0.4
+// and SERVFAIL for AAAA, SRV questions of _http._tcp.svc.test with api.test:srvPort, and
+// NXDOMAIN otherwise
+type fakeDNS struct {
+	addr    string
+	ttl     uint32
+	srvPort uint16
+	queries int32
+}
+
+func newFakeDNS(t *testing.T, ttl uint32, srvPort uint16) *fakeDNS {
+	t.Helper()
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = udp.Close()
+		_ = tcp.Close()
+	})
+
+	d := &fakeDNS{addr: udp.LocalAddr().String(), ttl: ttl, srvPort: srvPort}
+	go func() {
+		buffer := make([]byte, 512)
+		for {
+			n, from, err := udp.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			_, _ = udp.WriteTo(d.answer(buffer[:n], false), from)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			var size [2]byte
+			if _, err := io.ReadFull(conn, size[:]); err == nil {
+				query := make([]byte, binary.BigEndian.Uint16(size[:]))
+				if _, err := io.ReadFull(conn, query); err == nil {
+					answer := d.answer(query, true)
+					binary.BigEndian.PutUint16(size[:], uint16(len(answer)))
+					_, _ = conn.Write(append(size[:], answer...))
+				}
+			}
+			_ = conn.Close()
+		}
+	}()
+	return d
+}
+
+func (d *fakeDNS) answer(packed []byte, overTCP bool) []byte {
+	var query dnsmessage.Message
+	if err := query.Unpack(packed); err != nil || len(query.Questions) != 1 {
+		return nil
+	}
+	atomic.AddInt32(&d.queries, 1)
+	question := query.Questions[0]
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true},
+		Questions: query.Questions,
+	}
+	header := dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: d.ttl}
+	switch question.Name.String() {
+	case "api.test.":
+		if question.Type == dnsmessage.TypeA {
+			response.Answers = []dnsmessage.Resource{
+				{Header: header, Body: &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}}},
+				{Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: d.ttl * 2},
+					Body: &dnsmessage.AResource{A: [4]byte{127, 0, 0, 3}}},
+			}
+		}
+	case "_http._tcp.svc.test.":
+		if question.Type == dnsmessage.TypeSRV {
+			response.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET, TTL: d.ttl},
+				Body:   &dnsmessage.SRVResource{Target: dnsmessage.MustNewName("api.test."), Port: d.srvPort, Weight: 1},
+			}}
+		}
+	case "v4only.test.":
+		if question.Type == dnsmessage.TypeA {
+			response.Answers = []dnsmessage.Resource{{Header: header, Body: &dnsmessage.AResource{A: [4]byte{127, 0, 0, 4}}}}
+		} else {
+			response.RCode = dnsmessage.RCodeServerFailure
+		}
+	case "big.test.":
+		if !overTCP {
+			response.Truncated = true
+		} else if question.Type == dnsmessage.TypeA {
+			response.Answers = []dnsmessage.Resource{{Header: header, Body: &dnsmessage.AResource{A: [4]byte{127, 0, 0, 2}}}}
+		}
+	default:
+		response.RCode = dnsmessage.RCodeNameError
+	}
+	answer, _ := response.Pack()
+	return answer
+}
+
+func TestDNSResolver(t *testing.T) {
+	dns := newFakeDNS(t, 30, 0)
+	resolver, err := client_http.NewDNSResolver("127.0.0.1:1", dns.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addrs, ttl, err := resolver.LookupHostTTL(ctx, "api.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 2 || addrs[0] != "127.0.0.1" || ttl != 30*time.Second {
+		t.Fatalf("addrs = %v ttl = %v, want 127.0.0.1 first and the smallest ttl 30s", addrs, ttl)
+	}
+
+	addrs, err = resolver.LookupHost(ctx, "big.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || addrs[0] != "127.0.0.2" {
+		t.Fatalf("addrs = %v, want 127.0.0.2 over tcp", addrs)
+	}
+
+	addrs, err = resolver.LookupHost(ctx, "v4only.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || addrs[0] != "127.0.0.4" {
+		t.Fatalf("addrs = %v, want 127.0.0.4 despite the failing AAAA query", addrs)
+	}
+
+	_, err = resolver.LookupHost(ctx, "missing.test")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Fatalf("error = %v, want not found", err)
+	}
+}
+
+func TestDNSCacheHonorsTTL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	dns := newFakeDNS(t, 1, 0)
+	c, err := client_http.NewHttpClient(client_http.WithDNSServers(dns.addr), client_http.WithDNSCache(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	get := func() {
+		t.Helper()
+		if _, err := c.Get(context.Background(), "http://api.test:"+port+"/"); err != nil {
+			t.Fatal(err)
+		}
+		// the next request dials a new connection
+		c.CloseIdleConnections()
+	}
+
+	get()
+	get()
+	// A and AAAA
+	if got := atomic.LoadInt32(&dns.queries); got != 2 {
+		t.Fatalf("%d queries, want 2 while the answer is cached", got)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	get()
+	if got := atomic.LoadInt32(&dns.queries); got != 4 {
+		t.Fatalf("%d queries, want 4 once the 1s ttl expired", got)
+	}
+}
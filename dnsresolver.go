@@ -0,0 +1,233 @@
+package client_http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsTimeout - wait for the answer of a server when ctx has no deadline
+const dnsTimeout = 5 * time.Second
+
+// DNSResolver - TTLResolver querying DNS servers directly, answers report the smallest
+// TTL of their records so WithDNSCache keeps them as long as the servers allow
+type DNSResolver struct {
+	servers []string
+	dialer  net.Dialer
+}
+
+// NewDNSResolver - resolver querying servers (host:port) in order over UDP, answers that
+// do not fit are requested again over TCP. Hosts are resolved as fully qualified names,
+// the search domains of the system are not applied
+func NewDNSResolver(servers ...string) (*DNSResolver, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("dns servers can't be empty")
+	}
+	for _, s := range servers {
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			return nil, fmt.Errorf("invalid dns server [%s] - [%w]", s, err)
+		}
+	}
+	return &DNSResolver{servers: servers}, nil
+}
+
+// LookupHost - IPv4 then IPv6 addresses of host
+func (r *DNSResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, _, err := r.LookupHostTTL(ctx, host)
+	return addrs, err
+}
+
+// LookupHostTTL - IPv4 then IPv6 addresses of host and the smallest TTL of the answers
+func (r *DNSResolver) LookupHostTTL(ctx context.Context, host string) ([]string, time.Duration, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{host}, 0, nil
+	}
+
+	var addrs []string
+	var ttl uint32
+	found := false
+	var lastErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		// a family failing (SERVFAIL or timeouts of AAAA on IPv4 only servers) is only
+		// an error when the other one has no address either
+		answers, err := r.query(ctx, host, qtype)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, 0, err
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, answer := range answers {
+			switch body := answer.Body.(type) {
+			case *dnsmessage.AResource:
+				addrs = append(addrs, net.IP(body.A[:]).String())
+			case *dnsmessage.AAAAResource:
+				addrs = append(addrs, net.IP(body.AAAA[:]).String())
+			default:
+				continue
+			}
+			if !found || answer.Header.TTL < ttl {
+				ttl = answer.Header.TTL
+			}
+			found = true
+		}
+	}
+	if len(addrs) == 0 {
+		if lastErr != nil {
+			return nil, 0, lastErr
+		}
+		return nil, 0, &net.DNSError{Err: "no addresses found", Name: host, IsNotFound: true}
+	}
+	return addrs, time.Duration(ttl) * time.Second, nil
+}
+
+// LookupSRV - SRV records of _service._proto.name, or of name when service and proto
+// are empty
+func (r *DNSResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	target := name
+	if service != "" || proto != "" {
+		target = "_" + service + "._" + proto + "." + name
+	}
+	answers, err := r.query(ctx, target, dnsmessage.TypeSRV)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var records []*net.SRV
+	for _, answer := range answers {
+		if srv, ok := answer.Body.(*dnsmessage.SRVResource); ok {
+			records = append(records, &net.SRV{Target: srv.Target.String(), Port: srv.Port, Priority: srv.Priority, Weight: srv.Weight})
+		}
+	}
+	if len(records) == 0 {
+		return "", nil, &net.DNSError{Err: "no srv records found", Name: target, IsNotFound: true}
+	}
+	return strings.TrimSuffix(target, ".") + ".", records, nil
+}
+
+// query - answers of the first server responding to the question, a name that does
+// not exist is an error
+func (r *DNSResolver) query(ctx context.Context, host string, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return nil, &net.DNSError{Err: "invalid host name", Name: host}
+	}
+
+	var lastErr error
+	for _, server := range r.servers {
+		response, err := r.exchange(ctx, server, name, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		switch response.RCode {
+		case dnsmessage.RCodeSuccess:
+			return response.Answers, nil
+		case dnsmessage.RCodeNameError:
+			return nil, &net.DNSError{Err: "no such host", Name: host, Server: server, IsNotFound: true}
+		default:
+			lastErr = &net.DNSError{Err: "server failure " + response.RCode.String(), Name: host, Server: server}
+		}
+	}
+	return nil, lastErr
+}
+
+// exchange - send the question to server over UDP, and over TCP when the answer is
+// truncated
+func (r *DNSResolver) exchange(ctx context.Context, server string, name dnsmessage.Name, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	// unpredictable ids make spoofed answers harder to match
+	var random [2]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, fmt.Errorf("error generating dns query id [%w]", err)
+	}
+	id := binary.BigEndian.Uint16(random[:])
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("error packing dns query [%w]", err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dnsTimeout)
+		defer cancel()
+	}
+
+	response, err := r.roundTrip(ctx, "udp", server, packed, id)
+	if err == nil && response.Truncated {
+		response, err = r.roundTrip(ctx, "tcp", server, packed, id)
+	}
+	if err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: name.String(), Server: server, IsTimeout: IsTimeout(err)}
+	}
+	return response, nil
+}
+
+// roundTrip - send a packed query over network and read the answer with id, TCP
+// messages are prefixed with their length
+func (r *DNSResolver) roundTrip(ctx context.Context, network, server string, query []byte, id uint16) (*dnsmessage.Message, error) {
+	conn, err := r.dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var answer []byte
+	if network == "tcp" {
+		message := make([]byte, 2+len(query))
+		binary.BigEndian.PutUint16(message, uint16(len(query)))
+		copy(message[2:], query)
+		if _, err := conn.Write(message); err != nil {
+			return nil, err
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return nil, err
+		}
+		answer = make([]byte, binary.BigEndian.Uint16(size[:]))
+		if _, err := io.ReadFull(conn, answer); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buffer := make([]byte, 1232)
+		for {
+			n, err := conn.Read(buffer)
+			if err != nil {
+				return nil, err
+			}
+			// answers of other queries are ignored
+			if n >= 2 && binary.BigEndian.Uint16(buffer) == id {
+				answer = buffer[:n]
+				break
+			}
+		}
+	}
+
+	var response dnsmessage.Message
+	if err := response.Unpack(answer); err != nil {
+		return nil, fmt.Errorf("invalid dns answer [%w]", err)
+	}
+	if response.ID != id || !response.Response {
+		return nil, fmt.Errorf("unexpected dns answer")
+	}
+	return &response, nil
+}
@@ -0,0 +1,161 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Progress - state of a transfer
+type Progress struct {
+	// Transferred - bytes transferred so far
+	Transferred int64
+	// Total - expected bytes, -1 when unknown
+	Total int64
+}
+
+// Percent - completed percentage (0 to 100), -1 when the total is unknown
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Transferred) * 100 / float64(p.Total)
+}
+
+// ProgressFunc - called while a transfer advances, it must return quickly
+type ProgressFunc func(progress Progress)
+
+// DownloadOptions - settings of DownloadFile, all fields are optional
+type DownloadOptions struct {
+	Headers  []HeaderParameters
+	Progress ProgressFunc
+	// FileMode - permissions of the downloaded file, 0644 by default
+	FileMode os.FileMode
+	// Segments - parallel range requests used when the server supports Accept-Ranges,
+	// 1 or less downloads with a single request
+	Segments int
+	// SegmentSize - bytes requested by each range request, by default the file is split
+	// in Segments equal parts
+	SegmentSize int64
+	// Checksum - expected digest of the file
+	Checksum *Checksum
+	// VerifyDigestHeader - verify the file against the Repr-Digest, Digest or Content-MD5
+	// response header when the server sends one
+	VerifyDigestHeader bool
+}
+
+// DownloadFile - stream the body of url into the file at path without buffering it.
+// Data is written into a temporary file in the same directory and renamed to path only
+// when the download is complete and its size matches Content-Length, so path never holds
+// a partial file. Non-2xx responses are returned as *HTTPError
+func (c *Client) DownloadFile(ctx context.Context, url, path string, opts *DownloadOptions) (int64, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+
+	if opts.Checksum != nil {
+		if _, err := newHash(opts.Checksum.Algorithm); err != nil {
+			return 0, err
+		}
+	}
+
+	if opts.Segments > 1 {
+		if size, headers, ok := c.probeRanges(ctx, url, opts.Headers); ok {
+			return c.downloadSegmented(ctx, url, path, size, headers, opts)
+		}
+	}
+
+	request, err := c.newRequest(ctx, http.MethodGet, url, nil, opts.Headers)
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := c.stream(request)
+	if err != nil {
+		return 0, err
+	}
+	if !isSuccess(response.StatusCode) {
+		return 0, c.streamHTTPError(request, &http.Response{
+			Status:     response.Status,
+			StatusCode: response.StatusCode,
+			Header:     response.Headers,
+			Body:       response.Body,
+		})
+	}
+
+	return writeFileAtomic(path, opts.FileMode, func(file *os.File) (int64, error) {
+		written, err := response.WriteTo(&progressWriter{
+			writer:   file,
+			progress: opts.Progress,
+			total:    response.ContentLength,
+		})
+		if err != nil {
+			return written, err
+		}
+		if response.ContentLength >= 0 && written != response.ContentLength {
+			return written, fmt.Errorf("incomplete download [%d] of [%d] bytes", written, response.ContentLength)
+		}
+		return written, verifyChecksum(file, opts, response.Headers)
+	})
+}
+
+// writeFileAtomic - write into a temporary file next to path with write and rename it to
+// path on success, the temporary file is removed on failure
+func writeFileAtomic(path string, mode os.FileMode, write func(file *os.File) (int64, error)) (int64, error) {
+	if mode == 0 {
+		mode = 0644
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".part-")
+	if err != nil {
+		return 0, fmt.Errorf("error creating file [%s] - [%w]", path, err)
+	}
+	defer Defer(func() {
+		// no-op once renamed
+		_ = os.Remove(file.Name())
+	})
+
+	written, err := write(file)
+	if err != nil {
+		_ = file.Close()
+		return written, err
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return written, fmt.Errorf("error writing file [%s] - [%w]", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return written, fmt.Errorf("error writing file [%s] - [%w]", path, err)
+	}
+	if err := os.Chmod(file.Name(), mode); err != nil {
+		return written, fmt.Errorf("error setting file mode [%s] - [%w]", path, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return written, fmt.Errorf("error moving file to [%s] - [%w]", path, err)
+	}
+
+	return written, nil
+}
+
+// progressWriter - writer reporting progress after every write
+type progressWriter struct {
+	writer   io.Writer
+	progress ProgressFunc
+	total    int64
+	written  int64
+}
+
+// Write - write p and report progress
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+	if w.progress != nil {
+		w.progress(Progress{Transferred: w.written, Total: w.total})
+	}
+	return n, err
+}
@@ -0,0 +1,170 @@
+package client_http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CurlCommand - equivalent curl command of request, to reproduce it from a shell.
+// Sensitive headers, and headers and query parameters named in redact, are redacted,
+// the body is kept readable
+func CurlCommand(request *http.Request, redact ...string) (string, error) {
+	body, err := peekBody(request)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("curl")
+	if request.Method != http.MethodGet {
+		fmt.Fprintf(&b, " -X %s", request.Method)
+	}
+	fmt.Fprintf(&b, " %s", shellQuote(redactURL(request.URL.String(), redact...)))
+
+	headers := redactHeaders(request.Header, redact)
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range headers[k] {
+			fmt.Fprintf(&b, " -H %s", shellQuote(k+": "+v))
+		}
+	}
+
+	if len(body) > 0 {
+		fmt.Fprintf(&b, " --data-binary %s", shellQuote(string(body)))
+	}
+	return b.String(), nil
+}
+
+// DumpRequest - raw request as sent on the wire with sensitive headers, and headers and
+// query parameters named in redact, redacted. The body is kept readable
+func DumpRequest(request *http.Request, redact ...string) ([]byte, error) {
+	body, err := peekBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := request.Clone(request.Context())
+	clone.Header = redactHeaders(request.Header, redact)
+	clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if body == nil {
+		clone.Body = nil
+	}
+	u := *clone.URL
+	u.User = nil
+	u.RawQuery = redactQuery(u.RawQuery, redact)
+	clone.URL = &u
+
+	dump, err := httputil.DumpRequestOut(clone, true)
+	if err != nil {
+		return nil, fmt.Errorf("error dumping request [%w]", err)
+	}
+	return dump, nil
+}
+
+// DumpResponse - raw response with sensitive headers and redact headers redacted, the
+// body is kept readable
+func DumpResponse(response *http.Response, redact ...string) ([]byte, error) {
+	header := response.Header
+	response.Header = redactHeaders(header, redact)
+	defer func() { response.Header = header }()
+
+	dump, err := httputil.DumpResponse(response, true)
+	if err != nil {
+		return nil, fmt.Errorf("error dumping response [%w]", err)
+	}
+	return dump, nil
+}
+
+// WithDebug - write every request attempt as curl command and raw dump, and its raw
+// response, to w. Sensitive headers, the api key, and headers and query parameters named
+// in redact are redacted. Bodies are buffered to be dumped, do not use it with large transfers
+func WithDebug(w io.Writer, redact ...string) Option {
+	return func(c *Client) error {
+		if w == nil {
+			return fmt.Errorf("debug writer can't be nil")
+		}
+		c.internalMiddlewares = append(c.internalMiddlewares, c.debugMiddleware(w, redact))
+		return nil
+	}
+}
+
+// debugMiddleware - dump requests and responses to w, serializing concurrent writes
+func (c *Client) debugMiddleware(w io.Writer, redact []string) Middleware {
+	var mu sync.Mutex
+	write := func(dump ...[]byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, d := range dump {
+			_, _ = w.Write(d)
+		}
+	}
+
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			redact := c.redactNames(redact)
+			curl, err := CurlCommand(request, redact...)
+			if err != nil {
+				return nil, err
+			}
+			dump, err := DumpRequest(request, redact...)
+			if err != nil {
+				return nil, err
+			}
+			write([]byte("> "+curl+"\n"), dump, []byte("\n"))
+
+			response, err := next(request)
+			if err != nil {
+				write([]byte(fmt.Sprintf("< error [%v]\n\n", err)))
+				return response, err
+			}
+
+			dump, err = DumpResponse(response, redact...)
+			if err != nil {
+				_ = response.Body.Close()
+				return nil, err
+			}
+			write([]byte("< "), dump, []byte("\n\n"))
+			return response, nil
+		}
+	}
+}
+
+// peekBody - content of the request body leaving it unread, nil without body
+func peekBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body [%w]", err)
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+
+	content, err := ioutil.ReadAll(request.Body)
+	_ = request.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body [%w]", err)
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(content))
+	return content, nil
+}
+
+// shellQuote - s quoted for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,106 @@
+package client_http
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Encoder - encodes a request payload
+type Encoder func(v interface{}) ([]byte, error)
+
+// defaultEncoders - built in json, xml and form encoders by media type
+var defaultEncoders = map[string]Encoder{
+	contentTypeJSON:   json.Marshal,
+	"application/xml": xml.Marshal,
+	"text/xml":        xml.Marshal,
+	contentTypeForm:   encodeForm,
+}
+
+// WithEncoder - encode payloads of mediaType (application/x-protobuf...) with encoder in
+// DoEncoded and RequestBuilder.BodyEncoded, it replaces the built in encoder of the same
+// media type
+func WithEncoder(mediaType string, encoder Encoder) Option {
+	return func(c *Client) error {
+		if mediaType == "" || encoder == nil {
+			return fmt.Errorf("invalid encoder for media type [%s]", mediaType)
+		}
+		if c.encoders == nil {
+			c.encoders = map[string]Encoder{}
+		}
+		c.encoders[strings.ToLower(mediaType)] = encoder
+		return nil
+	}
+}
+
+// DoEncoded - execute a request sending in encoded as mediaType and decoding a successful
+// (2xx) response into out with the decoder of its Content-Type, see WithEncoder and
+// WithDecoder. in and out can be nil, the Response is returned for any status code
+func (c *Client) DoEncoded(ctx context.Context, method, url, mediaType string, in, out interface{}, headers ...HeaderParameters) (*Response, error) {
+	var payload []byte
+	codecHeaders := []HeaderParameters{{Key: "Accept", Value: mediaType}}
+	if in != nil {
+		var err error
+		if payload, err = c.encode(mediaType, in); err != nil {
+			return nil, err
+		}
+		codecHeaders = append(codecHeaders, HeaderParameters{Key: "Content-Type", Value: mediaType})
+	}
+
+	response, err := c.Do(ctx, method, url, payload, append(codecHeaders, headers...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if out != nil && isSuccess(response.StatusCode) && len(response.Body) > 0 {
+		if err := response.Decode(out); err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
+
+// encode - payload of v with the encoder of contentType, structured syntax suffixes
+// (+json, +xml) fall back to the json and xml encoders
+func (c *Client) encode(contentType string, v interface{}) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type [%s] - [%w]", contentType, err)
+	}
+
+	encoder, ok := c.encoders[mediaType]
+	if !ok {
+		encoder, ok = defaultEncoders[mediaType]
+	}
+	if !ok {
+		if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+			switch mediaType[i+1:] {
+			case "json":
+				encoder, ok = defaultEncoders[contentTypeJSON]
+			case "xml":
+				encoder, ok = defaultEncoders["application/xml"]
+			}
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("no encoder registered for content type [%s]", mediaType)
+	}
+
+	payload, err := encoder(v)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding [%s] request [%w]", mediaType, err)
+	}
+	return payload, nil
+}
+
+// encodeForm - form payload of a struct or url.Values, see EncodeForm
+func encodeForm(v interface{}) ([]byte, error) {
+	values, err := formValues(v)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(values.Encode()), nil
+}
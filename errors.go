@@ -0,0 +1,174 @@
+package client_http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// maxErrorBodySize - bytes of the response body kept on HTTPError
+const maxErrorBodySize = 1024
+
+// HTTPError - non-2xx response returned as error when WithFailOnErrorStatus is enabled
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Headers    http.Header
+	// Body - response body truncated to 1KB
+	Body []byte
+	// Problem - RFC 7807 details of application/problem+json responses
+	Problem *ProblemDetails
+	// RequestID - id sent with the request, empty unless WithRequestID is used
+	RequestID string
+}
+
+// Error - method, url, status and body snippet, or the problem title and detail
+func (e *HTTPError) Error() string {
+	message := fmt.Sprintf("%s %s returned status [%s] - [%s]", e.Method, e.URL, e.Status, e.Body)
+	if e.Problem != nil {
+		message = fmt.Sprintf("%s %s returned status [%s] - %v", e.Method, e.URL, e.Status, e.Problem)
+	}
+	if e.RequestID != "" {
+		message += fmt.Sprintf(" - request id [%s]", e.RequestID)
+	}
+	return message
+}
+
+// Unwrap - problem details of the response, nil when it is not a problem document
+func (e *HTTPError) Unwrap() error {
+	if e.Problem == nil {
+		return nil
+	}
+	return e.Problem
+}
+
+// Is - match another *HTTPError by status code, a target with StatusCode 0 matches any status,
+// so errors.Is(err, &HTTPError{StatusCode: 404}) checks for not found
+func (e *HTTPError) Is(target error) bool {
+	t, ok := target.(*HTTPError)
+	if !ok {
+		return false
+	}
+	return t.StatusCode == 0 || t.StatusCode == e.StatusCode
+}
+
+// newHTTPError - build error for response of request with its request id, the url is
+// redacted
+func (c *Client) newHTTPError(request *http.Request, response *Response) *HTTPError {
+	problem, _ := response.Problem()
+
+	body := response.Body
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+	}
+
+	return &HTTPError{
+		Method:     request.Method,
+		URL:        c.redactURL(request.URL.String()),
+		StatusCode: response.StatusCode,
+		Status:     response.Status,
+		Headers:    response.Headers,
+		Body:       append([]byte(nil), body...),
+		Problem:    problem,
+		RequestID:  c.requestID(request),
+	}
+}
+
+// streamHTTPError - build error for an unread response, keeping a body snippet and closing it
+func (c *Client) streamHTTPError(request *http.Request, response *http.Response) *HTTPError {
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+	_ = response.Body.Close()
+
+	return c.newHTTPError(request, &Response{
+		Body:       body,
+		Status:     response.Status,
+		StatusCode: response.StatusCode,
+		Headers:    response.Header,
+	})
+}
+
+// WithFailOnErrorStatus - return *HTTPError instead of a Response for status codes outside 2xx
+func WithFailOnErrorStatus() Option {
+	return func(c *Client) error {
+		c.failOnErrorStatus = true
+		return nil
+	}
+}
+
+// ErrorDecoder - maps a non-2xx response to a domain error of an API, nil keeps the default
+// handling (a Response, or *HTTPError with WithFailOnErrorStatus). Streaming calls pass
+// the first 1KB of the body
+type ErrorDecoder func(statusCode int, headers http.Header, body []byte) error
+
+// WithErrorDecoder - return the error of decoder for the non-2xx responses it maps
+func WithErrorDecoder(decoder ErrorDecoder) Option {
+	return func(c *Client) error {
+		if decoder == nil {
+			return fmt.Errorf("error decoder can't be nil")
+		}
+		c.errorDecoder = decoder
+		return nil
+	}
+}
+
+// statusError - error of a non-2xx response, nil when it must be returned as a Response
+func (c *Client) statusError(request *http.Request, response *Response) error {
+	if c.errorDecoder != nil {
+		if err := c.errorDecoder(response.StatusCode, response.Headers, response.Body); err != nil {
+			return err
+		}
+	}
+	if c.failOnErrorStatus {
+		return c.newHTTPError(request, response)
+	}
+	return nil
+}
+
+// streamError - error of an unread non-2xx response mapped by the error decoder, or
+// *HTTPError. The body is closed
+func (c *Client) streamError(request *http.Request, response *http.Response) error {
+	httpErr := c.streamHTTPError(request, response)
+	if c.errorDecoder != nil {
+		if err := c.errorDecoder(httpErr.StatusCode, httpErr.Headers, httpErr.Body); err != nil {
+			return err
+		}
+	}
+	return httpErr
+}
+
+// IsTimeout - err is a client timeout, a context deadline or a network timeout
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsCanceled - err is caused by the cancellation of the request context
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// IsDNSError - the host of the request could not be resolved
+func IsDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// IsConnectionRefused - the server refused the connection, nothing listens on the port
+func IsConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// IsConnectionReset - the connection was reset by the server or a proxy
+func IsConnectionReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET)
+}
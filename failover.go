@@ -0,0 +1,258 @@
+package client_http
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint - base url of a replica of an API, Weight is its share of the traffic
+// when endpoints are weighted (1 when zero)
+type Endpoint struct {
+	URL    string
+	Weight int
+}
+
+// FailoverConfig - endpoints of WithFailover
+type FailoverConfig struct {
+	// Endpoints - replicas of the API, requests to the first one are sent to the others
+	// when it fails
+	Endpoints []Endpoint
+	// Weighted - try the endpoints in a random order following their weights instead of
+	// the configured order
+	Weighted bool
+	// MaxFailures - consecutive failures marking an endpoint down, 1 by default
+	MaxFailures int
+	// Cooldown - time a down endpoint is tried only after the healthy ones, 30s by default
+	Cooldown time.Duration
+}
+
+// WithFailover - send requests to the first endpoint to the next one on connection errors
+// and 5xx responses. The path of the endpoint url replaces the path of the first one,
+// the base url is set to the first endpoint when WithBaseURL is not used
+func WithFailover(config FailoverConfig) Option {
+	return func(c *Client) error {
+		f, err := newFailover(config)
+		if err != nil {
+			return err
+		}
+		if c.baseURL == "" {
+			c.baseURL = strings.TrimRight(f.endpoints[0].url.String(), "/")
+		}
+		c.routingMiddlewares = append(c.routingMiddlewares, f.middleware(c))
+		return nil
+	}
+}
+
+// failover - endpoints and their health
+type failover struct {
+	endpoints   []*endpoint
+	weighted    bool
+	maxFailures int
+	cooldown    time.Duration
+}
+
+// endpoint - parsed Endpoint with its health
+type endpoint struct {
+	url    *url.URL
+	weight int
+	// pending - requests in flight, see LeastPending
+	pending int64
+
+	mu        sync.Mutex
+	failures  int
+	downUntil time.Time
+	// ejected - failed the active health checks, see HealthCheck
+	ejected bool
+	// probes - consecutive health check results, positive for successes
+	probes int
+}
+
+// newFailover - validate config
+func newFailover(config FailoverConfig) (*failover, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, fmt.Errorf("failover endpoints can't be empty")
+	}
+	f := &failover{weighted: config.Weighted, maxFailures: config.MaxFailures, cooldown: config.Cooldown}
+	if f.maxFailures <= 0 {
+		f.maxFailures = 1
+	}
+	if f.cooldown <= 0 {
+		f.cooldown = 30 * time.Second
+	}
+	for _, e := range config.Endpoints {
+		parsed, err := parseEndpoint(e)
+		if err != nil {
+			return nil, err
+		}
+		f.endpoints = append(f.endpoints, parsed)
+	}
+	return f, nil
+}
+
+// parseEndpoint - endpoint of e, its url must be absolute
+func parseEndpoint(e Endpoint) (*endpoint, error) {
+	parsed, err := url.Parse(e.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint url [%s] - [%w]", e.URL, err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("endpoint url [%s] must be absolute", e.URL)
+	}
+	if e.Weight < 0 {
+		return nil, fmt.Errorf("invalid endpoint weight [%d]", e.Weight)
+	}
+	weight := e.Weight
+	if weight == 0 {
+		weight = 1
+	}
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	return &endpoint{url: parsed, weight: weight}, nil
+}
+
+// middleware - send requests to the first endpoint to the healthy endpoints in turn
+func (f *failover) middleware(c *Client) Middleware {
+	primary := f.endpoints[0].url
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			if !sameOrigin(primary, request.URL) {
+				return next(request)
+			}
+
+			order := f.order()
+			for i, e := range order {
+				attempt, err := endpointAttempt(request, i, primary, e.url)
+				if err != nil {
+					return nil, err
+				}
+
+				response, err := next(attempt)
+				if err == nil && !isServerError(response.StatusCode) {
+					e.success()
+					return response, nil
+				}
+				if request.Context().Err() != nil {
+					return response, err
+				}
+				if e.failure(f.maxFailures, f.cooldown) {
+					c.logger.Log(LevelError, "endpoint marked down", map[string]interface{}{"endpoint": redactURL(e.url.String())})
+				}
+
+				if i == len(order)-1 || !rewindable(request) {
+					return response, err
+				}
+				if response != nil {
+					_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+					_ = response.Body.Close()
+				}
+			}
+			return nil, fmt.Errorf("no endpoint available for [%s]", c.redactURL(request.URL.String()))
+		}
+	}
+}
+
+// order - endpoints to try, healthy ones first in configured or weighted order
+func (f *failover) order() []*endpoint {
+	now := time.Now()
+	var healthy, down []*endpoint
+	for _, e := range f.endpoints {
+		if e.down(now) {
+			down = append(down, e)
+		} else {
+			healthy = append(healthy, e)
+		}
+	}
+	if f.weighted {
+		healthy = weightedOrder(healthy)
+	}
+	return append(healthy, down...)
+}
+
+// weightedOrder - endpoints in random order, heavier ones are more likely first
+func weightedOrder(endpoints []*endpoint) []*endpoint {
+	remaining := append([]*endpoint{}, endpoints...)
+	ordered := make([]*endpoint, 0, len(endpoints))
+	for len(remaining) > 0 {
+		total := 0
+		for _, e := range remaining {
+			total += e.weight
+		}
+		pick := randomFloat() * float64(total)
+		i := 0
+		for ; i < len(remaining)-1; i++ {
+			if pick -= float64(remaining[i].weight); pick < 0 {
+				break
+			}
+		}
+		ordered = append(ordered, remaining[i])
+		remaining = append(remaining[:i], remaining[i+1:]...)
+	}
+	return ordered
+}
+
+// endpointAttempt - copy of request sent to target instead of primary, attempts after
+// the first one get a fresh body
+func endpointAttempt(request *http.Request, attempt int, primary, target *url.URL) (*http.Request, error) {
+	clone := request.Clone(request.Context())
+	if attempt > 0 {
+		var err error
+		if clone, err = rewind(request); err != nil {
+			return nil, err
+		}
+	}
+
+	u := *request.URL
+	u.Scheme = target.Scheme
+	u.Host = target.Host
+	u.Path = target.Path + strings.TrimPrefix(request.URL.Path, primary.Path)
+	if u.RawPath != "" {
+		u.RawPath = target.EscapedPath() + strings.TrimPrefix(request.URL.RawPath, primary.EscapedPath())
+	}
+	clone.URL = &u
+	clone.Host = ""
+	return clone, nil
+}
+
+// sameOrigin - u has the scheme and host of base and is its path or below it, /api does
+// not contain /apiv2
+func sameOrigin(base, u *url.URL) bool {
+	if !strings.EqualFold(u.Scheme, base.Scheme) ||
+		!strings.EqualFold(stripDefaultPort(u.Host, u.Scheme), stripDefaultPort(base.Host, base.Scheme)) {
+		return false
+	}
+	path := strings.TrimSuffix(base.Path, "/")
+	return path == "" || u.Path == path || strings.HasPrefix(u.Path, path+"/")
+}
+
+// down - the endpoint failed recently or its health checks
+func (e *endpoint) down(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.ejected || now.Before(e.downUntil)
+}
+
+// success - reset the failures
+func (e *endpoint) success() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures = 0
+	e.downUntil = time.Time{}
+}
+
+// failure - count a failure, true when the endpoint is marked down
+func (e *endpoint) failure(maxFailures int, cooldown time.Duration) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures++
+	if e.failures < maxFailures {
+		return false
+	}
+	e.failures = 0
+	e.downUntil = time.Now().Add(cooldown)
+	return true
+}
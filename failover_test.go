@@ -0,0 +1,53 @@
+package client_http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestFailover(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secondary " + r.URL.Path))
+	}))
+	defer secondary.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithFailover(client_http.FailoverConfig{
+		Endpoints: []client_http.Endpoint{{URL: primary.URL + "/api"}, {URL: secondary.URL + "/v1"}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{url: "/items", status: http.StatusOK, body: "secondary /v1/items"},
+		{url: primary.URL + "/api", status: http.StatusOK, body: "secondary /v1"},
+		{url: primary.URL + "/api/items/1", status: http.StatusOK, body: "secondary /v1/items/1"},
+		// other paths of the host are not under the endpoint
+		{url: primary.URL + "/apiv2/items", status: http.StatusServiceUnavailable},
+		{url: primary.URL + "/other", status: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			response, err := c.Get(context.Background(), tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != tt.status || (tt.body != "" && string(response.Body) != tt.body) {
+				t.Fatalf("status = %d body %q, want %d %q", response.StatusCode, response.Body, tt.status, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package client_http
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// FaultConfig - faults injected by WithFaultInjection, each probability goes from 0 to 1
+type FaultConfig struct {
+	// LatencyProbability - chance of delaying a request by Latency plus a random part
+	// of LatencyJitter
+	LatencyProbability float64
+	Latency            time.Duration
+	LatencyJitter      time.Duration
+	// DropProbability - chance of failing a request with a connection reset, without
+	// sending it
+	DropProbability float64
+	// ErrorProbability - chance of answering with one of ErrorStatusCodes (503 when
+	// empty) without sending the request
+	ErrorProbability float64
+	ErrorStatusCodes []int
+	// Hosts - hosts affected by the faults, all when empty
+	Hosts []string
+}
+
+// WithFaultInjection - inject latency, dropped connections and error responses into
+// request attempts to exercise the resilience of callers in staging. Retries see the
+// faults like real failures
+func WithFaultInjection(config FaultConfig) Option {
+	return func(c *Client) error {
+		for _, p := range []float64{config.LatencyProbability, config.DropProbability, config.ErrorProbability} {
+			if p < 0 || p > 1 {
+				return fmt.Errorf("invalid fault probability [%v], must be between 0 and 1", p)
+			}
+		}
+		if config.Latency < 0 || config.LatencyJitter < 0 {
+			return fmt.Errorf("invalid fault latency [%v] - [%v]", config.Latency, config.LatencyJitter)
+		}
+		c.internalMiddlewares = append(c.internalMiddlewares, faultMiddleware(config))
+		return nil
+	}
+}
+
+// faultMiddleware - inject the faults of config
+func faultMiddleware(config FaultConfig) Middleware {
+	statusCodes := config.ErrorStatusCodes
+	if len(statusCodes) == 0 {
+		statusCodes = []int{http.StatusServiceUnavailable}
+	}
+
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			if !config.affects(request.URL.Hostname()) {
+				return next(request)
+			}
+
+			if chance(config.LatencyProbability) {
+				delay := config.Latency + time.Duration(randomFloat()*float64(config.LatencyJitter))
+				if err := sleep(request.Context(), delay); err != nil {
+					return nil, err
+				}
+			}
+
+			if chance(config.DropProbability) {
+				closeRequestBody(request)
+				return nil, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+			}
+
+			if chance(config.ErrorProbability) {
+				status := statusCodes[int(randomFloat()*float64(len(statusCodes)))]
+				closeRequestBody(request)
+				body := []byte("fault injected")
+				return &http.Response{
+					Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+					StatusCode:    status,
+					Proto:         "HTTP/1.1",
+					ProtoMajor:    1,
+					ProtoMinor:    1,
+					Header:        http.Header{"Content-Type": {"text/plain"}},
+					Body:          ioutil.NopCloser(bytes.NewReader(body)),
+					ContentLength: int64(len(body)),
+					Request:       request,
+				}, nil
+			}
+
+			return next(request)
+		}
+	}
+}
+
+// affects - true if faults apply to host
+func (f FaultConfig) affects(host string) bool {
+	if len(f.Hosts) == 0 {
+		return true
+	}
+	for _, h := range f.Hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// chance - true with probability p
+func chance(p float64) bool {
+	return p > 0 && randomFloat() < p
+}
+
+// closeRequestBody - close the body of a request that is not sent
+func closeRequestBody(request *http.Request) {
+	if request.Body != nil {
+		_ = request.Body.Close()
+	}
+}
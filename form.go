@@ -0,0 +1,39 @@
+package client_http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// contentTypeForm - media type of url encoded form bodies
+const contentTypeForm = "application/x-www-form-urlencoded"
+
+// EncodeForm - form values of the fields of struct v tagged `form:"name"`, with the tag
+// options of EncodeQuery. Slices repeat the key (a=1&a=2), nested structs and maps use
+// bracket keys (address[city]=x) and slices of structs indexes (items[0][id]=1)
+func EncodeForm(v interface{}) (url.Values, error) {
+	return encodeValues(v, "form")
+}
+
+// PostForm - POST the fields of struct v (see EncodeForm), or url.Values, as an url
+// encoded form
+func (c *Client) PostForm(ctx context.Context, url string, v interface{}, headers ...HeaderParameters) (*Response, error) {
+	values, err := formValues(v)
+	if err != nil {
+		return nil, err
+	}
+	headers = append([]HeaderParameters{{Key: "Content-Type", Value: contentTypeForm}}, headers...)
+	return c.Do(ctx, http.MethodPost, url, []byte(values.Encode()), headers...)
+}
+
+// formValues - values of v, url.Values are used as is
+func formValues(v interface{}) (url.Values, error) {
+	switch values := v.(type) {
+	case url.Values:
+		return values, nil
+	case map[string][]string:
+		return values, nil
+	}
+	return EncodeForm(v)
+}
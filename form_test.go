@@ -0,0 +1,78 @@
+package client_http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+type address struct {
+	City string `form:"city"`
+	Zip  string `form:"zip,omitempty"`
+}
+
+type orderLine struct {
+	ID       int `form:"id"`
+	Quantity int `form:"qty"`
+}
+
+type Audit struct {
+	Source string `form:"source"`
+}
+
+type orderForm struct {
+	Audit
+	Customer string            `form:"customer"`
+	Address  address           `form:"address"`
+	Lines    []orderLine       `form:"items"`
+	Meta     map[string]string `form:"meta"`
+	Billing  *address          `form:"billing"`
+}
+
+func TestEncodeForm(t *testing.T) {
+	values, err := client_http.EncodeForm(orderForm{
+		Audit:    Audit{Source: "web"},
+		Customer: "ana",
+		Address:  address{City: "Lima"},
+		Lines:    []orderLine{{ID: 1, Quantity: 2}, {ID: 7, Quantity: 1}},
+		Meta:     map[string]string{"b": "2", "a": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "address%5Bcity%5D=Lima&customer=ana&items%5B0%5D%5Bid%5D=1&items%5B0%5D%5Bqty%5D=2" +
+		"&items%5B1%5D%5Bid%5D=7&items%5B1%5D%5Bqty%5D=1&meta%5Ba%5D=1&meta%5Bb%5D=2&source=web"
+	if got := values.Encode(); got != want {
+		t.Fatalf("form\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPostForm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		_ = r.ParseForm()
+		_, _ = w.Write([]byte(r.PostForm.Get("address[city]") + "|" + r.PostForm.Get("customer")))
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	response, err := c.PostForm(context.Background(), server.URL, orderForm{Customer: "ana", Address: address{City: "Lima"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(response.Body); got != "Lima|ana" {
+		t.Fatalf("server received %q", got)
+	}
+}
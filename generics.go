@@ -0,0 +1,120 @@
+package client_http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorBody - non-2xx response whose json body was decoded into E
+type ErrorBody[E any] struct {
+	StatusCode int
+	Status     string
+	Body       E
+}
+
+// Error - status of the response
+func (e *ErrorBody[E]) Error() string {
+	return fmt.Sprintf("request returned status [%s] - [%+v]", e.Status, e.Body)
+}
+
+// Do - execute a request sending in as json (nil for no payload) and decode the json
+// response into T. Non-2xx responses are returned as *HTTPError
+func Do[T any](ctx context.Context, c *Client, method, url string, in any, headers ...HeaderParameters) (T, error) {
+	var out T
+
+	request, response, err := typedDo(ctx, c, method, url, in, headers)
+	if err != nil {
+		return out, err
+	}
+	if !isSuccess(response.StatusCode) {
+		return out, c.newHTTPError(request, response)
+	}
+
+	return decodeTyped[T](response)
+}
+
+// Get - GET url decoding the json response into T
+func Get[T any](ctx context.Context, c *Client, url string, headers ...HeaderParameters) (T, error) {
+	return Do[T](ctx, c, http.MethodGet, url, nil, headers...)
+}
+
+// Post - POST in as json decoding the json response into T
+func Post[T any](ctx context.Context, c *Client, url string, in any, headers ...HeaderParameters) (T, error) {
+	return Do[T](ctx, c, http.MethodPost, url, in, headers...)
+}
+
+// Put - PUT in as json decoding the json response into T
+func Put[T any](ctx context.Context, c *Client, url string, in any, headers ...HeaderParameters) (T, error) {
+	return Do[T](ctx, c, http.MethodPut, url, in, headers...)
+}
+
+// Patch - PATCH in as json decoding the json response into T
+func Patch[T any](ctx context.Context, c *Client, url string, in any, headers ...HeaderParameters) (T, error) {
+	return Do[T](ctx, c, http.MethodPatch, url, in, headers...)
+}
+
+// DoWithErrorBody - like Do, non-2xx json responses are decoded into E and returned
+// as *ErrorBody[E], check them with errors.As
+func DoWithErrorBody[T, E any](ctx context.Context, c *Client, method, url string, in any, headers ...HeaderParameters) (T, error) {
+	var out T
+
+	request, response, err := typedDo(ctx, c, method, url, in, headers)
+	if err != nil {
+		// error status returned by WithFailOnErrorStatus, the body is truncated
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) {
+			return out, err
+		}
+		response = &Response{Body: httpErr.Body, Status: httpErr.Status, StatusCode: httpErr.StatusCode, Headers: httpErr.Headers}
+	}
+
+	if !isSuccess(response.StatusCode) {
+		errorBody := &ErrorBody[E]{StatusCode: response.StatusCode, Status: response.Status}
+		if len(response.Body) == 0 || response.JSON(&errorBody.Body) != nil {
+			return out, c.newHTTPError(request, response)
+		}
+		return out, errorBody
+	}
+
+	return decodeTyped[T](response)
+}
+
+// GetWithErrorBody - GET url decoding the response into T or the error body into E
+func GetWithErrorBody[T, E any](ctx context.Context, c *Client, url string, headers ...HeaderParameters) (T, error) {
+	return DoWithErrorBody[T, E](ctx, c, http.MethodGet, url, nil, headers...)
+}
+
+// PostWithErrorBody - POST in as json decoding the response into T or the error body into E
+func PostWithErrorBody[T, E any](ctx context.Context, c *Client, url string, in any, headers ...HeaderParameters) (T, error) {
+	return DoWithErrorBody[T, E](ctx, c, http.MethodPost, url, in, headers...)
+}
+
+// typedDo - execute a json request
+func typedDo(ctx context.Context, c *Client, method, url string, in any, headers []HeaderParameters) (*http.Request, *Response, error) {
+	request, err := c.newJSONRequest(ctx, method, url, in, headers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := c.execute(request)
+	return request, response, err
+}
+
+// decodeTyped - json body into T, an empty body is the zero value
+func decodeTyped[T any](response *Response) (T, error) {
+	var out T
+	if len(response.Body) == 0 {
+		return out, nil
+	}
+	if err := response.JSON(&out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// isSuccess - true for 2xx status codes
+func isSuccess(statusCode int) bool {
+	return statusCode >= 200 && statusCode <= 299
+}
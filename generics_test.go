@@ -0,0 +1,62 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+type item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type apiError struct {
+	Code string `json:"code"`
+}
+
+func TestGenericsHTTPErrorRequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/item":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":1,"name":"one"}`))
+		case "/text-error":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("bad gateway"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithRequestID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := client_http.MarkRequestID(context.Background(), "req-42")
+
+	got, err := client_http.Get[item](ctx, c, server.URL+"/item")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (item{ID: 1, Name: "one"}) {
+		t.Fatalf("item = %+v", got)
+	}
+
+	_, err = client_http.Get[item](ctx, c, server.URL+"/missing")
+	var httpErr *client_http.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound || httpErr.RequestID != "req-42" {
+		t.Fatalf("error = %v, want 404 with request id req-42", err)
+	}
+
+	_, err = client_http.GetWithErrorBody[item, apiError](ctx, c, server.URL+"/text-error")
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadGateway || httpErr.RequestID != "req-42" {
+		t.Fatalf("error = %v, want 502 with request id req-42", err)
+	}
+}
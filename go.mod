@@ -1,3 +1,7 @@
 module github.com/erikwco/client_http
 
-go 1.17
+go 1.18
+
+require golang.org/x/net v0.33.0
+
+require golang.org/x/text v0.21.0 // indirect
@@ -0,0 +1,145 @@
+package client_http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLRequest - GraphQL operation
+type GraphQLRequest struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+	// Persisted - send only the sha256 hash of the query (automatic persisted queries),
+	// the full query is sent again when the server does not know the hash
+	Persisted bool
+}
+
+// GraphQLError - error entry of a GraphQL response
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLErrorLocation - position in the query of an error
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error - message of the error
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+// GraphQLErrors - errors returned by the server, data may still be partially decoded
+type GraphQLErrors []GraphQLError
+
+// Error - all messages
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return fmt.Sprintf("graphql errors [%s]", strings.Join(messages, "; "))
+}
+
+// graphQLPayload - standard request envelope
+type graphQLPayload struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// graphQLResponse - standard response envelope
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// GraphQL - execute query with variables and decode the data of the response into result
+func (c *Client) GraphQL(ctx context.Context, endpoint, query string, variables map[string]interface{}, result interface{}, headers ...HeaderParameters) error {
+	return c.DoGraphQL(ctx, endpoint, GraphQLRequest{Query: query, Variables: variables}, result, headers...)
+}
+
+// DoGraphQL - execute request and decode the data of the response into result (can be nil).
+// Errors of the response are returned as GraphQLErrors, data is decoded even then
+func (c *Client) DoGraphQL(ctx context.Context, endpoint string, request GraphQLRequest, result interface{}, headers ...HeaderParameters) error {
+	payload := graphQLPayload{
+		Query:         request.Query,
+		OperationName: request.OperationName,
+		Variables:     request.Variables,
+	}
+
+	if request.Persisted {
+		hash := sha256.Sum256([]byte(request.Query))
+		payload.Query = ""
+		payload.Extensions = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hex.EncodeToString(hash[:])},
+		}
+
+		decoded, err := c.postGraphQL(ctx, endpoint, payload, headers)
+		if err != nil {
+			return err
+		}
+		if !persistedQueryNotFound(decoded.Errors) {
+			return decodeGraphQL(decoded, result)
+		}
+		// unknown hash, register the query sending it along with the hash
+		payload.Query = request.Query
+	}
+
+	decoded, err := c.postGraphQL(ctx, endpoint, payload, headers)
+	if err != nil {
+		return err
+	}
+	return decodeGraphQL(decoded, result)
+}
+
+// postGraphQL - send payload and decode the response envelope
+func (c *Client) postGraphQL(ctx context.Context, endpoint string, payload graphQLPayload, headers []HeaderParameters) (*graphQLResponse, error) {
+	var decoded graphQLResponse
+	response, err := c.DoJSON(ctx, http.MethodPost, endpoint, payload, &decoded, headers...)
+	if err != nil {
+		return nil, err
+	}
+
+	// servers may answer errors with 4xx and a valid envelope
+	if !isSuccess(response.StatusCode) {
+		if response.JSON(&decoded) != nil || len(decoded.Errors) == 0 {
+			return nil, fmt.Errorf("graphql request returned status [%s]", response.Status)
+		}
+	}
+	return &decoded, nil
+}
+
+// decodeGraphQL - decode data into result and return the response errors
+func decodeGraphQL(decoded *graphQLResponse, result interface{}) error {
+	if result != nil && len(decoded.Data) > 0 && string(decoded.Data) != "null" {
+		if err := json.Unmarshal(decoded.Data, result); err != nil {
+			return fmt.Errorf("error decoding graphql data [%w]", err)
+		}
+	}
+	if len(decoded.Errors) > 0 {
+		return decoded.Errors
+	}
+	return nil
+}
+
+// persistedQueryNotFound - true when the server does not know the persisted query hash
+func persistedQueryNotFound(errs GraphQLErrors) bool {
+	for _, err := range errs {
+		if err.Message == "PersistedQueryNotFound" || err.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,351 @@
+package client_http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HARRecorder - keeps the traffic of a client as HAR 1.2 entries, to be opened in
+// browser devtools or API debugging tools. Safe for concurrent use
+type HARRecorder struct {
+	maxBodySize int64
+	redact      []string
+
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// NewHARRecorder - recorder keeping up to maxBodySize bytes of every body, zero keeps
+// no bodies. Sensitive headers, the api key of the client, and headers and query
+// parameters named in redact are redacted
+func NewHARRecorder(maxBodySize int64, redact ...string) *HARRecorder {
+	return &HARRecorder{maxBodySize: maxBodySize, redact: redact}
+}
+
+// WithHAR - record every request attempt in recorder
+func WithHAR(recorder *HARRecorder) Option {
+	return func(c *Client) error {
+		if recorder == nil {
+			return fmt.Errorf("har recorder can't be nil")
+		}
+		c.internalMiddlewares = append(c.internalMiddlewares, recorder.middleware(c))
+		return nil
+	}
+}
+
+// WriteTo - write the recorded traffic as a HAR document
+func (h *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	h.mu.Lock()
+	entries := append([]harEntry{}, h.entries...)
+	h.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].StartedDateTime.Before(entries[j].StartedDateTime) })
+	data, err := json.MarshalIndent(harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "client_http", Version: Version},
+		Entries: entries,
+	}}, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("error encoding har [%w]", err)
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// WriteFile - write the recorded traffic to the HAR file at path
+func (h *HARRecorder) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("error writing har file [%s] - [%w]", path, err)
+	}
+	return nil
+}
+
+// Reset - discard the recorded entries
+func (h *HARRecorder) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = nil
+}
+
+// middleware - record request attempts, entries are added once the response body is
+// closed or the request fails
+func (h *HARRecorder) middleware(c *Client) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			redact := c.redactNames(h.redact)
+			requestBody, err := peekBody(request)
+			if err != nil {
+				return nil, err
+			}
+
+			request, t := trace(request, nil)
+			entry := harEntry{StartedDateTime: t.start, Request: h.harRequest(request, requestBody, redact)}
+
+			response, err := next(request)
+			if err != nil {
+				entry.Response = harResponse{HTTPVersion: "unknown", Cookies: []harCookie{}, Headers: []harNameValue{},
+					Content: harContent{MimeType: "x-unknown"}, HeadersSize: -1, BodySize: -1, Comment: err.Error()}
+				h.add(entry, t, time.Now())
+				return response, err
+			}
+
+			entry.Response = h.harResponse(response, redact)
+			response.Body = &harBody{body: response.Body, max: h.maxBodySize, done: func(content []byte, size int64) {
+				entry.Response.Content.Size = size
+				entry.Response.BodySize = size
+				entry.Response.Content.Text, entry.Response.Content.Encoding = harText(content)
+				h.add(entry, t, time.Now())
+			}}
+			return response, nil
+		}
+	}
+}
+
+// add - complete the timings of entry and keep it
+func (h *HARRecorder) add(entry harEntry, t *connTrace, end time.Time) {
+	t.mu.Lock()
+	entry.Timings = harTimings{
+		Blocked: -1,
+		DNS:     harDuration(t.dnsStart, t.dnsDone),
+		Connect: harDuration(t.connectStart, t.connectDone),
+		SSL:     harDuration(t.tlsStart, t.tlsDone),
+		Send:    math.Max(harDuration(t.gotConn, t.wroteRequest), 0),
+		Wait:    math.Max(harDuration(t.wroteRequest, t.firstByte), 0),
+		Receive: math.Max(harDuration(t.firstByte, end), 0),
+	}
+	if !t.gotConn.IsZero() {
+		first := t.gotConn
+		for _, ts := range []time.Time{t.dnsStart, t.connectStart} {
+			if !ts.IsZero() && ts.Before(first) {
+				first = ts
+			}
+		}
+		entry.Timings.Blocked = harDuration(t.start, first)
+	}
+	// HAR connect time includes the TLS handshake
+	if entry.Timings.Connect >= 0 && entry.Timings.SSL > 0 {
+		entry.Timings.Connect += entry.Timings.SSL
+	}
+	t.mu.Unlock()
+	entry.Time = float64(end.Sub(entry.StartedDateTime)) / float64(time.Millisecond)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+}
+
+// harRequest - request part of an entry
+func (h *HARRecorder) harRequest(request *http.Request, body []byte, redact []string) harRequest {
+	r := harRequest{
+		Method:      request.Method,
+		URL:         redactURL(request.URL.String(), redact...),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harCookie{},
+		Headers:     harHeaders(redactHeaders(request.Header, redact)),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    int64(len(body)),
+	}
+	for name, values := range request.URL.Query() {
+		for _, v := range values {
+			if containsString(redact, name) {
+				v = redactedValue
+			}
+			r.QueryString = append(r.QueryString, harNameValue{Name: name, Value: v})
+		}
+	}
+	if body != nil {
+		text, _ := harText(truncate(body, h.maxBodySize))
+		r.PostData = &harPostData{MimeType: request.Header.Get("Content-Type"), Text: text}
+	}
+	return r
+}
+
+// harResponse - response part of an entry, the content is completed when the body is read
+func (h *HARRecorder) harResponse(response *http.Response, redact []string) harResponse {
+	statusText := response.Status
+	if i := strings.Index(statusText, " "); i >= 0 {
+		statusText = statusText[i+1:]
+	}
+	return harResponse{
+		Status:      response.StatusCode,
+		StatusText:  statusText,
+		HTTPVersion: response.Proto,
+		Cookies:     []harCookie{},
+		Headers:     harHeaders(redactHeaders(response.Header, redact)),
+		Content:     harContent{MimeType: response.Header.Get("Content-Type")},
+		RedirectURL: response.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+}
+
+// harBody - response body keeping up to max bytes, done is called once on EOF or close
+type harBody struct {
+	body    io.ReadCloser
+	max     int64
+	content []byte
+	size    int64
+	once    sync.Once
+	done    func(content []byte, size int64)
+}
+
+// Read - read and keep the content
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.size += int64(n)
+	if keep := b.max - int64(len(b.content)); keep > 0 {
+		if int64(n) < keep {
+			keep = int64(n)
+		}
+		b.content = append(b.content, p[:keep]...)
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+// Close - close the body and record the entry
+func (b *harBody) Close() error {
+	defer b.finish()
+	return b.body.Close()
+}
+
+// finish - call done once
+func (b *harBody) finish() {
+	b.once.Do(func() { b.done(b.content, b.size) })
+}
+
+// harHeaders - headers as sorted name value pairs
+func harHeaders(headers http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	for name, values := range headers {
+		for _, v := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: v})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// harText - content as text, or base64 with its encoding when it is binary
+func harText(content []byte) (string, string) {
+	if utf8.Valid(content) {
+		return string(content), ""
+	}
+	return base64.StdEncoding.EncodeToString(content), "base64"
+}
+
+// harDuration - milliseconds between start and end, -1 when the phase did not happen.
+// Send, wait and receive are required, they use 0 instead
+func harDuration(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return -1
+	}
+	return float64(end.Sub(start)) / float64(time.Millisecond)
+}
+
+// truncate - first max bytes of content
+func truncate(content []byte, max int64) []byte {
+	if int64(len(content)) > max {
+		return content[:max]
+	}
+	return content
+}
+
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+type harCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
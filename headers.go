@@ -0,0 +1,75 @@
+package client_http
+
+import (
+	"net/http"
+	"sort"
+)
+
+// AddHeader - header adding value to the values of key, to send repeated headers
+// (Accept, Forwarded, Link) with the variadic headers of the calls
+func AddHeader(key, value string) HeaderParameters {
+	return HeaderParameters{Key: key, Value: value, Add: true}
+}
+
+// HeadersFrom - headers of h keeping every value, the first value of a key replaces the
+// previous ones and the others are added
+func HeadersFrom(h http.Header) []HeaderParameters {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var headers []HeaderParameters
+	for _, key := range keys {
+		for i, value := range h[key] {
+			headers = append(headers, HeaderParameters{Key: key, Value: value, Add: i > 0})
+		}
+	}
+	return headers
+}
+
+// HeadersFromMap - headers of m in key order
+func HeadersFromMap(m map[string]string) []HeaderParameters {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	headers := make([]HeaderParameters, 0, len(keys))
+	for _, key := range keys {
+		headers = append(headers, HeaderParameters{Key: key, Value: m[key]})
+	}
+	return headers
+}
+
+// applyHeaders - set or add headers in order
+func applyHeaders(header http.Header, headers []HeaderParameters) {
+	for _, h := range headers {
+		if h.Add {
+			header.Add(h.Key, h.Value)
+			continue
+		}
+		header.Set(h.Key, h.Value)
+	}
+}
+
+// applyMissingHeaders - apply the headers whose key header does not have yet, so every
+// value of a repeated default is sent unless the call set the key
+func applyMissingHeaders(header http.Header, headers []HeaderParameters) {
+	missing := map[string]bool{}
+	for _, h := range headers {
+		key := http.CanonicalHeaderKey(h.Key)
+		if _, ok := missing[key]; !ok {
+			missing[key] = header.Get(key) == ""
+		}
+	}
+	var apply []HeaderParameters
+	for _, h := range headers {
+		if missing[http.CanonicalHeaderKey(h.Key)] {
+			apply = append(apply, h)
+		}
+	}
+	applyHeaders(header, apply)
+}
@@ -0,0 +1,121 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithHedging - send up to hedges duplicates of idempotent requests, one every delay while
+// no response arrived (the p95 latency of the API is a good delay). The first response
+// wins and the other attempts are cancelled. Requests with bodies that can't be rewound
+// are sent once
+func WithHedging(delay time.Duration, hedges int) Option {
+	return func(c *Client) error {
+		if delay <= 0 || hedges < 1 {
+			return fmt.Errorf("invalid hedging delay [%v] - [%d] hedges", delay, hedges)
+		}
+		c.internalMiddlewares = append(c.internalMiddlewares, hedgeMiddleware(delay, hedges))
+		return nil
+	}
+}
+
+// hedgeResult - outcome of one hedged attempt
+type hedgeResult struct {
+	index    int
+	response *http.Response
+	err      error
+}
+
+// hedgeMiddleware - race the request with delayed duplicates
+func hedgeMiddleware(delay time.Duration, hedges int) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			if !idempotent(request) || !rewindable(request) {
+				return next(request)
+			}
+
+			ctx := request.Context()
+			results := make(chan hedgeResult, hedges+1)
+			var cancels []context.CancelFunc
+			launch := func(attempt *http.Request) {
+				attemptCtx, cancel := context.WithCancel(ctx)
+				index := len(cancels)
+				cancels = append(cancels, cancel)
+				go func() {
+					response, err := next(attempt.WithContext(attemptCtx))
+					results <- hedgeResult{index: index, response: response, err: err}
+				}()
+			}
+
+			launch(request)
+			pending := 1
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+
+			var err error
+			for {
+				select {
+				case <-timer.C:
+					if len(cancels) > hedges {
+						continue
+					}
+					attempt, rewindErr := rewind(request)
+					if rewindErr != nil {
+						continue
+					}
+					launch(attempt)
+					pending++
+					if len(cancels) <= hedges {
+						timer.Reset(delay)
+					}
+
+				case result := <-results:
+					pending--
+					if result.err != nil {
+						cancels[result.index]()
+						err = result.err
+						if pending == 0 {
+							return nil, err
+						}
+						continue
+					}
+
+					// cancel the losers and release their late responses
+					for i, cancel := range cancels {
+						if i != result.index {
+							cancel()
+						}
+					}
+					go discardHedges(results, pending)
+
+					result.response.Body = &cancelBody{ReadCloser: result.response.Body, cancel: cancels[result.index]}
+					return result.response, nil
+				}
+			}
+		}
+	}
+}
+
+// discardHedges - close the responses of the pending cancelled attempts
+func discardHedges(results <-chan hedgeResult, pending int) {
+	for ; pending > 0; pending-- {
+		if result := <-results; result.response != nil {
+			_ = result.response.Body.Close()
+		}
+	}
+}
+
+// cancelBody - body of the winning attempt, its context is cancelled once it is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close - close the body and release the attempt context
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,92 @@
+package client_http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HookEvent - request attempt passed to the hooks
+type HookEvent struct {
+	// Request - request of the attempt, hooks must not change it
+	Request *http.Request
+	// Attempt - 1 for the first attempt, incremented on every retry
+	Attempt int
+	// StatusCode - zero before the response or when the attempt failed
+	StatusCode int
+	// Duration - time until the response headers or the error, zero in OnRequest
+	Duration time.Duration
+	// Err - error of a failed attempt
+	Err error
+	// Delay - wait before the next attempt, only set in OnRetry
+	Delay time.Duration
+}
+
+// Hooks - callbacks of the request lifecycle for audit logs and metrics, any of them can
+// be nil. They run synchronously in the goroutine of the request
+type Hooks struct {
+	// OnRequest - before every attempt is sent
+	OnRequest func(event HookEvent)
+	// OnResponse - after every attempt with a response, whatever its status
+	OnResponse func(event HookEvent)
+	// OnError - after every attempt failing without response
+	OnError func(event HookEvent)
+	// OnRetry - once an attempt is going to be retried, before waiting Delay
+	OnRetry func(event HookEvent)
+}
+
+// WithHooks - call hooks on every request attempt, hooks of several WithHooks run in
+// registration order
+func WithHooks(hooks Hooks) Option {
+	return func(c *Client) error {
+		if hooks.OnRequest == nil && hooks.OnResponse == nil && hooks.OnError == nil && hooks.OnRetry == nil {
+			return fmt.Errorf("hooks can't be empty")
+		}
+		c.hooks = append(c.hooks, hooks)
+		return nil
+	}
+}
+
+// attempt - send request through the chain calling the hooks
+func (c *Client) attempt(request *http.Request, attempt int) (*http.Response, error) {
+	if len(c.hooks) == 0 {
+		return c.chain(request)
+	}
+
+	event := HookEvent{Request: request, Attempt: attempt}
+	for _, h := range c.hooks {
+		if h.OnRequest != nil {
+			h.OnRequest(event)
+		}
+	}
+
+	start := time.Now()
+	response, err := c.chain(request)
+	event.Duration = time.Since(start)
+	event.Err = err
+	if response != nil {
+		event.StatusCode = response.StatusCode
+	}
+	for _, h := range c.hooks {
+		switch {
+		case err != nil && h.OnError != nil:
+			h.OnError(event)
+		case err == nil && h.OnResponse != nil:
+			h.OnResponse(event)
+		}
+	}
+	return response, err
+}
+
+// retrying - call the OnRetry hooks
+func (c *Client) retrying(request *http.Request, attempt int, response *http.Response, err error, delay time.Duration) {
+	event := HookEvent{Request: request, Attempt: attempt, Err: err, Delay: delay}
+	if response != nil {
+		event.StatusCode = response.StatusCode
+	}
+	for _, h := range c.hooks {
+		if h.OnRetry != nil {
+			h.OnRetry(event)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package client_http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// HTTP2Settings - tuning of HTTP/2 connections, zero values keep the defaults
+type HTTP2Settings struct {
+	// ReadIdleTimeout - send a ping when nothing is received for this long, detecting
+	// dead connections. Zero disables health checks
+	ReadIdleTimeout time.Duration
+	// PingTimeout - close the connection when a ping gets no answer in time, 15s by default
+	PingTimeout time.Duration
+	// WriteByteTimeout - close the connection when no data can be written for this long
+	WriteByteTimeout time.Duration
+	// StrictMaxConcurrentStreams - respect the max concurrent streams announced by the
+	// server for the whole client, instead of opening new connections when it is reached
+	StrictMaxConcurrentStreams bool
+	// MaxReadFrameSize - largest frame the client accepts, between 16KB and 16MB
+	MaxReadFrameSize uint32
+	// MaxHeaderListSize - largest response header list accepted
+	MaxHeaderListSize uint32
+}
+
+// http2Config - HTTP/2 mode of the client
+type http2Config struct {
+	h2c      bool
+	settings HTTP2Settings
+}
+
+// WithHTTP2 - negotiate HTTP/2 over TLS even with custom dialers or TLS configuration,
+// which disable it on the default transport. Servers without HTTP/2 still get HTTP/1.1
+func WithHTTP2() Option {
+	return func(c *Client) error {
+		c.http2Mode().h2c = false
+		return nil
+	}
+}
+
+// WithH2C - talk cleartext HTTP/2 (prior knowledge, no upgrade) to internal services,
+// every request must use http:// and the server must support h2c
+func WithH2C() Option {
+	return func(c *Client) error {
+		c.http2Mode().h2c = true
+		return nil
+	}
+}
+
+// WithHTTP2Settings - tune HTTP/2 connections, it enables WithHTTP2 unless WithH2C is used
+func WithHTTP2Settings(settings HTTP2Settings) Option {
+	return func(c *Client) error {
+		if settings.MaxReadFrameSize != 0 && (settings.MaxReadFrameSize < 16<<10 || settings.MaxReadFrameSize > 16<<20-1) {
+			return fmt.Errorf("invalid http2 max read frame size [%d]", settings.MaxReadFrameSize)
+		}
+		c.http2Mode().settings = settings
+		return nil
+	}
+}
+
+// http2Mode - HTTP/2 configuration, created on first use
+func (c *Client) http2Mode() *http2Config {
+	if c.http2 == nil {
+		c.http2 = &http2Config{}
+	}
+	return c.http2
+}
+
+// configureHTTP2 - set up HTTP/2 once every transport option has been applied
+func (c *Client) configureHTTP2() error {
+	if c.http2 == nil {
+		return nil
+	}
+
+	if c.http2.h2c {
+		transport := &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return c.dialContext(ctx, network, addr)
+			},
+		}
+		applyHTTP2Settings(transport, c.http2.settings)
+		c.roundTripper = transport
+		return nil
+	}
+
+	c.transport.ForceAttemptHTTP2 = true
+	transport, err := http2.ConfigureTransports(c.transport)
+	if err != nil {
+		return fmt.Errorf("error configuring http2 [%w]", err)
+	}
+	applyHTTP2Settings(transport, c.http2.settings)
+	return nil
+}
+
+// applyHTTP2Settings - copy settings into transport
+func applyHTTP2Settings(transport *http2.Transport, settings HTTP2Settings) {
+	transport.ReadIdleTimeout = settings.ReadIdleTimeout
+	transport.PingTimeout = settings.PingTimeout
+	transport.WriteByteTimeout = settings.WriteByteTimeout
+	transport.StrictMaxConcurrentStreams = settings.StrictMaxConcurrentStreams
+	transport.MaxReadFrameSize = settings.MaxReadFrameSize
+	transport.MaxHeaderListSize = settings.MaxHeaderListSize
+}
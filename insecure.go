@@ -0,0 +1,167 @@
+package client_http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// insecureKey - context key of requests skipping TLS verification
+type insecureKey struct{}
+
+// InsecureSkipVerify - mark ctx so requests using it skip the verification of the server
+// certificate. They are sent on a separate connection pool, verified requests never
+// reuse their connections. The pool is a copy of the transport of the client, wrapped
+// like it, requests fail when the transport of WithTransport is not an *http.Transport
+func InsecureSkipVerify(ctx context.Context) context.Context {
+	return context.WithValue(ctx, insecureKey{}, true)
+}
+
+// WithInsecureSkipVerifyHosts - skip the verification of the server certificate for
+// hosts only (names, IP addresses or host:port), every other host is verified as usual
+func WithInsecureSkipVerifyHosts(hosts ...string) Option {
+	return func(c *Client) error {
+		if len(hosts) == 0 {
+			return fmt.Errorf("insecure hosts can't be empty")
+		}
+		if c.insecureHosts == nil {
+			c.insecureHosts = map[string]bool{}
+		}
+		for _, h := range hosts {
+			c.insecureHosts[strings.ToLower(h)] = true
+		}
+		return nil
+	}
+}
+
+// InsecureSkipVerify - skip the verification of the server certificate for this request,
+// see the InsecureSkipVerify function
+func (b *RequestBuilder) InsecureSkipVerify() *RequestBuilder {
+	b.insecure = true
+	return b
+}
+
+// insecureState - lazily created client sharing the settings of the main one with
+// verification disabled
+type insecureState struct {
+	once   sync.Once
+	client *http.Client
+	err    error
+}
+
+// configureInsecureHosts - dial TLS connections with a configuration of the dialed host,
+// skipping verification for the insecure ones, once every TLS option has been applied.
+// Hosts reached through a proxy tunnel are always verified
+func (c *Client) configureInsecureHosts() {
+	if len(c.insecureHosts) == 0 || (c.transport.TLSClientConfig != nil && c.transport.TLSClientConfig.InsecureSkipVerify) {
+		return
+	}
+	c.transport.DialTLSContext = c.dialTLS
+}
+
+// dialTLS - dial addr and run the TLS handshake, the certificate is verified for the host
+// of addr, names and IP addresses alike, unless it is an insecure host. The
+// VerifyConnection of the configuration still runs
+func (c *Client) dialTLS(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	config := c.tlsConfig().Clone()
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	if c.insecureHosts[strings.ToLower(addr)] || c.insecureHosts[strings.ToLower(host)] {
+		config.InsecureSkipVerify = true
+	}
+
+	conn, err := c.dialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	handshakeCtx := ctx
+	if timeout := c.transport.TLSHandshakeTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		handshakeCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	tlsConn := tls.Client(conn, config)
+	err = tlsConn.HandshakeContext(handshakeCtx)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// do - send request with the http client, or the insecure one when the request context
+// asks to skip verification
+func (c *Client) do(request *http.Request) (*http.Response, error) {
+	request = withTrailers(request)
+	client := c.Instance
+	if skip, _ := request.Context().Value(insecureKey{}).(bool); skip {
+		var err error
+		if client, err = c.insecureClient(); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := client.Do(request)
+	// the url of transport errors is part of the message
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = c.redactURL(urlErr.URL)
+	}
+	return response, err
+}
+
+// insecureClient - copy of the http client with a copy of its transport skipping
+// verification, wrapped by the transport wrappers
+func (c *Client) insecureClient() (*http.Client, error) {
+	c.insecure.once.Do(func() {
+		var transport *http.Transport
+		switch roundTripper := c.roundTripper.(type) {
+		case nil:
+			transport = c.transport.Clone()
+			transport.TLSClientConfig = c.tlsConfig().Clone()
+			// dialTLS verifies the hosts that are not insecure
+			transport.DialTLSContext = nil
+		case *http.Transport:
+			transport = roundTripper.Clone()
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{}
+			}
+		default:
+			c.insecure.err = fmt.Errorf("can't skip certificate verification with a transport of type [%T]", roundTripper)
+			return
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		// do not share the HTTP/2 connection pool of the main transport
+		transport.TLSNextProto = nil
+
+		var roundTripper http.RoundTripper = transport
+		for _, wrap := range c.transportWrappers {
+			roundTripper = wrap(roundTripper)
+		}
+		client := *c.Instance
+		client.Transport = roundTripper
+		c.insecure.client = &client
+	})
+	return c.insecure.client, c.insecure.err
+}
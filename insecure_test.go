@@ -0,0 +1,166 @@
+package client_http_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+// newTLSServer - TLS server whose certificate is valid for example.com, 127.0.0.1 and ::1,
+// and the pool trusting it
+func newTLSServer(t *testing.T) (*httptest.Server, *x509.CertPool, string) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	return server, pool, port
+}
+
+func TestInsecureHostsVerifyDialedHost(t *testing.T) {
+	server, pool, port := newTLSServer(t)
+	address := server.Listener.Addr().String()
+
+	tests := []struct {
+		name     string
+		host     string
+		insecure []string
+		wantErr  bool
+	}{
+		{name: "ip in certificate", host: "127.0.0.1", insecure: []string{"other.test"}},
+		{name: "ip not in certificate", host: "10.1.2.3", insecure: []string{"other.test"}, wantErr: true},
+		{name: "insecure ip", host: "10.1.2.3", insecure: []string{"10.1.2.3"}},
+		{name: "insecure ip and port", host: "10.1.2.3", insecure: []string{"10.1.2.3:" + port}},
+		{name: "name in certificate", host: "example.com", insecure: []string{"other.test"}},
+		{name: "name not in certificate", host: "wrong.test", insecure: []string{"other.test"}, wantErr: true},
+		{name: "insecure name", host: "other.test", insecure: []string{"other.test"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := client_http.NewHttpClient(
+				client_http.WithRootCAs(pool),
+				client_http.WithHostOverride(tt.host+":"+port, address),
+				client_http.WithInsecureSkipVerifyHosts(tt.insecure...),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			_, err = c.Get(context.Background(), "https://"+net.JoinHostPort(tt.host, port)+"/")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInsecureHostsKeepVerifyConnection(t *testing.T) {
+	_, pool, port := newTLSServer(t)
+
+	var calls int32
+	c, err := client_http.NewHttpClient(
+		client_http.WithTLSConfig(&tls.Config{
+			RootCAs: pool,
+			VerifyConnection: func(tls.ConnectionState) error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			},
+		}),
+		client_http.WithInsecureSkipVerifyHosts("other.test"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.Get(context.Background(), "https://127.0.0.1:"+port+"/"); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("VerifyConnection called %d times, want 1", calls)
+	}
+}
+
+// roundTripperFunc - http.RoundTripper calling the function
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestInsecureSkipVerifyUsesConfiguredTransport(t *testing.T) {
+	server, _, _ := newTLSServer(t)
+
+	var dials int32
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	var wrapped int32
+	c, err := client_http.NewHttpClient(
+		client_http.WithTransport(transport),
+		client_http.WithTransportWrapper(func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&wrapped, 1)
+				return next.RoundTrip(request)
+			})
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.Get(context.Background(), server.URL); err == nil {
+		t.Fatal("error = nil, want the unknown certificate rejected")
+	}
+	response, err := c.Get(client_http.InsecureSkipVerify(context.Background()), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", response.StatusCode)
+	}
+	if atomic.LoadInt32(&dials) != 2 || atomic.LoadInt32(&wrapped) != 2 {
+		t.Fatalf("%d dials and %d wrapped requests, want both requests through the configured transport", dials, wrapped)
+	}
+	if transport.TLSClientConfig != nil && transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("configured transport changed, want a copy skipping verification")
+	}
+}
+
+func TestInsecureSkipVerifyRejectsCustomRoundTripper(t *testing.T) {
+	server, _, _ := newTLSServer(t)
+
+	var calls int32
+	c, err := client_http.NewHttpClient(client_http.WithTransport(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return http.DefaultTransport.RoundTrip(request)
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, err = c.Get(client_http.InsecureSkipVerify(context.Background()), server.URL)
+	if err == nil || !strings.Contains(err.Error(), "can't skip certificate verification") {
+		t.Fatalf("error = %v, want the transport rejected", err)
+	}
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Fatal("request sent with verification, want it rejected")
+	}
+}
@@ -0,0 +1,161 @@
+package client_http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// JobStore - persistence of the jobs of the delivery queue
+type JobStore interface {
+	// Save - insert or replace job
+	Save(ctx context.Context, job *Job) error
+	// Delete - drop the job with id
+	Delete(ctx context.Context, id string) error
+	// List - every stored job
+	List(ctx context.Context) ([]*Job, error)
+}
+
+// MemoryJobStore - JobStore keeping jobs in memory, they are lost when the process exits
+type MemoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// NewMemoryJobStore - empty memory store
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: map[string]Job{}}
+}
+
+// Save - keep a copy of job
+func (m *MemoryJobStore) Save(_ context.Context, job *Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[job.ID] = *job
+	return nil
+}
+
+// Delete - drop the job with id
+func (m *MemoryJobStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.jobs, id)
+	return nil
+}
+
+// List - copies of the stored jobs
+func (m *MemoryJobStore) List(_ context.Context) ([]*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		job := job
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+// FileJobStore - JobStore keeping every job as a json file of dir, jobs survive restarts
+// of the process
+type FileJobStore struct {
+	dir string
+}
+
+// NewFileJobStore - file store in dir, created when it does not exist
+func NewFileJobStore(dir string) (*FileJobStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating job dir [%s] - [%w]", dir, err)
+	}
+	return &FileJobStore{dir: dir}, nil
+}
+
+// Save - write the file of job, through a temporary file so readers never see a partial
+// job. The file and the directory are synced so a saved job survives a crash
+func (f *FileJobStore) Save(_ context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("error encoding job [%w]", err)
+	}
+	file, err := ioutil.TempFile(f.dir, "tmp-")
+	if err != nil {
+		return fmt.Errorf("error creating job file [%w]", err)
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), f.path(job.ID))
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("error writing job file [%w]", err)
+	}
+	if err := syncDir(f.dir); err != nil {
+		return fmt.Errorf("error syncing job dir [%w]", err)
+	}
+	return nil
+}
+
+// syncDir - flush the entries of dir, making renames in it durable
+func syncDir(dir string) error {
+	// directories can't be opened for syncing on windows
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Delete - remove the file of the job with id
+func (f *FileJobStore) Delete(_ context.Context, id string) error {
+	if err := os.Remove(f.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting job file [%w]", err)
+	}
+	return nil
+}
+
+// List - jobs of the files of dir
+func (f *FileJobStore) List(_ context.Context) ([]*Job, error) {
+	entries, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing job dir [%w]", err)
+	}
+	var jobs []*Job
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(f.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading job file [%w]", err)
+		}
+		job := &Job{}
+		if err := json.Unmarshal(data, job); err != nil {
+			return nil, fmt.Errorf("error decoding job file [%s] - [%w]", entry.Name(), err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// path - file of the job with id
+func (f *FileJobStore) path(id string) string {
+	return filepath.Join(f.dir, filepath.Base(id)+".json")
+}
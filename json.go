@@ -0,0 +1,79 @@
+package client_http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const contentTypeJSON = "application/json"
+
+// JSON - decode the response body into v
+func (r *Response) JSON(v interface{}) error {
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		return fmt.Errorf("error decoding json response [%w]", err)
+	}
+	return nil
+}
+
+// DoJSON - execute a request sending in as json and decoding a successful (2xx) response into out.
+// in and out can be nil, the Response is returned for any status code to let callers inspect errors
+func (c *Client) DoJSON(ctx context.Context, method, url string, in, out interface{}, headers ...HeaderParameters) (*Response, error) {
+	request, err := c.newJSONRequest(ctx, method, url, in, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.execute(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if out != nil && response.StatusCode >= 200 && response.StatusCode < 300 && len(response.Body) > 0 {
+		if err := response.JSON(out); err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+// newJSONRequest - build request sending in as json and accepting json responses
+func (c *Client) newJSONRequest(ctx context.Context, method, url string, in interface{}, headers []HeaderParameters) (*http.Request, error) {
+	var payload []byte
+	if in != nil {
+		var err error
+		if payload, err = json.Marshal(in); err != nil {
+			return nil, fmt.Errorf("error encoding json request [%w]", err)
+		}
+	}
+
+	// json headers, explicit headers take precedence
+	jsonHeaders := []HeaderParameters{{Key: "Accept", Value: contentTypeJSON}}
+	if in != nil {
+		jsonHeaders = append(jsonHeaders, HeaderParameters{Key: "Content-Type", Value: contentTypeJSON})
+	}
+
+	return c.newRequest(ctx, method, url, payload, append(jsonHeaders, headers...))
+}
+
+// GetJSON - execute a GET request decoding the json response into out
+func (c *Client) GetJSON(ctx context.Context, url string, out interface{}, headers ...HeaderParameters) (*Response, error) {
+	return c.DoJSON(ctx, http.MethodGet, url, nil, out, headers...)
+}
+
+// PostJSON - execute a POST request sending in as json and decoding the response into out
+func (c *Client) PostJSON(ctx context.Context, url string, in, out interface{}, headers ...HeaderParameters) (*Response, error) {
+	return c.DoJSON(ctx, http.MethodPost, url, in, out, headers...)
+}
+
+// PutJSON - execute a PUT request sending in as json and decoding the response into out
+func (c *Client) PutJSON(ctx context.Context, url string, in, out interface{}, headers ...HeaderParameters) (*Response, error) {
+	return c.DoJSON(ctx, http.MethodPut, url, in, out, headers...)
+}
+
+// PatchJSON - execute a PATCH request sending in as json and decoding the response into out
+func (c *Client) PatchJSON(ctx context.Context, url string, in, out interface{}, headers ...HeaderParameters) (*Response, error) {
+	return c.DoJSON(ctx, http.MethodPatch, url, in, out, headers...)
+}
@@ -0,0 +1,247 @@
+package client_http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONValue - value found at a path of a JSON response, the typed accessors return zero
+// values when it is missing or has another type
+type JSONValue struct {
+	raw json.RawMessage
+}
+
+// GetPath - value at path of the JSON body, found by scanning the document without
+// decoding the other values. path is dot separated: object keys, array indexes
+// ("data.items.0.id"), # for the length of an array or, followed by more keys, the
+// values of every element ("items.#.id"). Dots of keys are escaped as \.
+func (r *Response) GetPath(path string) JSONValue {
+	raw, _ := scanJSONPath(r.Body, splitJSONPath(path))
+	return JSONValue{raw: raw}
+}
+
+// GetPathAs - value at path of the JSON body of response decoded into T, see GetPath.
+// Missing values are an error
+func GetPathAs[T any](response *Response, path string) (T, error) {
+	var value T
+	raw, err := scanJSONPath(response.Body, splitJSONPath(path))
+	if err != nil {
+		return value, err
+	}
+	if raw == nil {
+		return value, fmt.Errorf("json path [%s] not found", path)
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, fmt.Errorf("error decoding json path [%s] - [%w]", path, err)
+	}
+	return value, nil
+}
+
+// Exists - the path was found, even with a null value
+func (v JSONValue) Exists() bool {
+	return v.raw != nil
+}
+
+// Raw - JSON text of the value, nil when missing
+func (v JSONValue) Raw() json.RawMessage {
+	return v.raw
+}
+
+// String - text of strings, JSON text of other values, empty for null or missing values
+func (v JSONValue) String() string {
+	if v.raw == nil || string(v.raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(v.raw, &s); err == nil {
+		return s
+	}
+	return string(v.raw)
+}
+
+// Int - integer of numbers, decimals are truncated, and numeric strings
+func (v JSONValue) Int() int64 {
+	text := v.number()
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n
+	}
+	f, _ := strconv.ParseFloat(text, 64)
+	return int64(f)
+}
+
+// Float - float of numbers and numeric strings
+func (v JSONValue) Float() float64 {
+	f, _ := strconv.ParseFloat(v.number(), 64)
+	return f
+}
+
+// Bool - true for true, non-zero numbers and strings like "true" or "1"
+func (v JSONValue) Bool() bool {
+	switch text := string(v.raw); {
+	case text == "true":
+		return true
+	case strings.HasPrefix(text, `"`):
+		b, _ := strconv.ParseBool(v.String())
+		return b
+	}
+	return v.Float() != 0
+}
+
+// Array - elements of arrays, nil for other values
+func (v JSONValue) Array() []JSONValue {
+	var items []json.RawMessage
+	if err := json.Unmarshal(v.raw, &items); err != nil {
+		return nil
+	}
+	values := make([]JSONValue, len(items))
+	for i, item := range items {
+		values[i] = JSONValue{raw: item}
+	}
+	return values
+}
+
+// Decode - decode the value into out
+func (v JSONValue) Decode(out interface{}) error {
+	if v.raw == nil {
+		return fmt.Errorf("json value not found")
+	}
+	return json.Unmarshal(v.raw, out)
+}
+
+// number - text of a number or of a string holding one
+func (v JSONValue) number() string {
+	if strings.HasPrefix(string(v.raw), `"`) {
+		return strings.TrimSpace(v.String())
+	}
+	return string(v.raw)
+}
+
+// splitJSONPath - keys of path, \. is a dot inside a key
+func splitJSONPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	var segments []string
+	var current strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			current.WriteByte('.')
+			i++
+		case path[i] == '.':
+			segments = append(segments, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(path[i])
+		}
+	}
+	return append(segments, current.String())
+}
+
+// scanJSONPath - JSON text at segments of data, nil when missing, skipping the values out
+// of the path
+func scanJSONPath(data []byte, segments []string) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for i, segment := range segments {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error scanning json response [%w]", err)
+		}
+
+		switch token {
+		case json.Delim('{'):
+			found, err := seekKey(decoder, segment)
+			if err != nil || !found {
+				return nil, err
+			}
+		case json.Delim('['):
+			if segment == "#" {
+				return scanJSONArray(decoder, segments[i+1:])
+			}
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 {
+				return nil, nil
+			}
+			found, err := seekIndex(decoder, index)
+			if err != nil || !found {
+				return nil, err
+			}
+		default:
+			// scalars have no children
+			return nil, nil
+		}
+	}
+
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error scanning json response [%w]", err)
+	}
+	return raw, nil
+}
+
+// seekKey - move decoder, inside an object, to the value of key
+func seekKey(decoder *json.Decoder, key string) (bool, error) {
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return false, fmt.Errorf("error scanning json response [%w]", err)
+		}
+		if token == key {
+			return true, nil
+		}
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return false, fmt.Errorf("error scanning json response [%w]", err)
+		}
+	}
+	return false, nil
+}
+
+// seekIndex - move decoder, inside an array, to the element at index
+func seekIndex(decoder *json.Decoder, index int) (bool, error) {
+	for i := 0; decoder.More(); i++ {
+		if i == index {
+			return true, nil
+		}
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return false, fmt.Errorf("error scanning json response [%w]", err)
+		}
+	}
+	return false, nil
+}
+
+// scanJSONArray - length of the array of decoder without rest, the array of the values at
+// rest of every element otherwise, elements without them are left out
+func scanJSONArray(decoder *json.Decoder, rest []string) (json.RawMessage, error) {
+	count := 0
+	var values []json.RawMessage
+	for decoder.More() {
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			return nil, fmt.Errorf("error scanning json response [%w]", err)
+		}
+		count++
+		if len(rest) == 0 {
+			continue
+		}
+		value, err := scanJSONPath(item, rest)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			values = append(values, value)
+		}
+	}
+
+	if len(rest) == 0 {
+		return json.RawMessage(strconv.Itoa(count)), nil
+	}
+	if values == nil {
+		values = []json.RawMessage{}
+	}
+	return json.Marshal(values)
+}
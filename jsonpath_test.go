@@ -0,0 +1,78 @@
+package client_http_test
+
+import (
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestGetPath(t *testing.T) {
+	response := &client_http.Response{Body: []byte(`{
+		"data": {
+			"total": "42",
+			"ratio": 0.5,
+			"active": true,
+			"empty": null,
+			"items": [
+				{"id": 1, "name": "one", "tags": ["a", "b"]},
+				{"id": 2, "name": "two"},
+				{"name": "three"}
+			]
+		},
+		"a.b": {"c": "dotted"}
+	}`)}
+
+	tests := []struct {
+		path   string
+		want   string
+		exists bool
+	}{
+		{path: "data.items.1.name", want: "two", exists: true},
+		{path: "data.items.0.tags.1", want: "b", exists: true},
+		{path: "data.items.#", want: "3", exists: true},
+		{path: "data.items.#.id", want: "[1,2]", exists: true},
+		{path: "data.items.#.missing", want: "[]", exists: true},
+		{path: `a\.b.c`, want: "dotted", exists: true},
+		{path: "data.empty", want: "", exists: true},
+		{path: "data.items.3.name"},
+		{path: "data.items.x"},
+		{path: "data.total.more"},
+		{path: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value := response.GetPath(tt.path)
+			if value.String() != tt.want || value.Exists() != tt.exists {
+				t.Fatalf("GetPath(%q) = %q exists %v, want %q exists %v", tt.path, value.String(), value.Exists(), tt.want, tt.exists)
+			}
+		})
+	}
+
+	if got := response.GetPath("data.total").Int(); got != 42 {
+		t.Errorf("Int = %d, want 42 from a numeric string", got)
+	}
+	if got := response.GetPath("data.ratio").Float(); got != 0.5 {
+		t.Errorf("Float = %v, want 0.5", got)
+	}
+	if !response.GetPath("data.active").Bool() || response.GetPath("data.empty").Bool() {
+		t.Error("Bool = false for true or true for null")
+	}
+	if items := response.GetPath("data.items").Array(); len(items) != 3 || items[2].String() != `{"name": "three"}` {
+		t.Errorf("Array = %v", items)
+	}
+
+	type entry struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	got, err := client_http.GetPathAs[entry](response, "data.items.0")
+	if err != nil || got != (entry{ID: 1, Name: "one"}) {
+		t.Errorf("GetPathAs = %+v %v", got, err)
+	}
+	if _, err := client_http.GetPathAs[entry](response, "data.items.9"); err == nil {
+		t.Error("GetPathAs of a missing path = nil error")
+	}
+	if _, err := client_http.GetPathAs[int]((&client_http.Response{Body: []byte(`{"a": [1,`)}), "a.5"); err == nil {
+		t.Error("GetPathAs of a truncated document = nil error")
+	}
+}
@@ -0,0 +1,166 @@
+package client_http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+const jsonRPCVersion = "2.0"
+
+// JSONRPCError - error object of a JSON-RPC 2.0 response
+type JSONRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error - code and message
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("jsonrpc error [%d] - [%s]", e.Code, e.Message)
+}
+
+// JSONRPCCall - call of a batch, Result and Error are filled with the response
+type JSONRPCCall struct {
+	Method string
+	Params interface{}
+	// Result - destination of the result, can be nil
+	Result interface{}
+	// Notification - the server sends no response for notifications
+	Notification bool
+	// Error - error returned by the server for this call
+	Error *JSONRPCError
+}
+
+// JSONRPCClient - JSON-RPC 2.0 client over the transport, auth and options of a Client
+type JSONRPCClient struct {
+	client   *Client
+	endpoint string
+	headers  []HeaderParameters
+	lastID   uint64
+}
+
+// jsonRPCRequest - request envelope, notifications have no id
+type jsonRPCRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+	ID      *uint64     `json:"id,omitempty"`
+}
+
+// jsonRPCResponse - response envelope
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *JSONRPCError   `json:"error"`
+	ID      *uint64         `json:"id"`
+}
+
+// JSONRPC - JSON-RPC client for endpoint, headers are sent on every call
+func (c *Client) JSONRPC(endpoint string, headers ...HeaderParameters) *JSONRPCClient {
+	return &JSONRPCClient{client: c, endpoint: endpoint, headers: headers}
+}
+
+// Call - invoke method with params (array or object, nil for none) and decode the result.
+// Errors of the server are returned as *JSONRPCError
+func (r *JSONRPCClient) Call(ctx context.Context, method string, params, result interface{}) error {
+	call := &JSONRPCCall{Method: method, Params: params, Result: result}
+	if err := r.Batch(ctx, call); err != nil {
+		return err
+	}
+	if call.Error != nil {
+		return call.Error
+	}
+	return nil
+}
+
+// Notify - invoke method without waiting for a result
+func (r *JSONRPCClient) Notify(ctx context.Context, method string, params interface{}) error {
+	return r.Batch(ctx, &JSONRPCCall{Method: method, Params: params, Notification: true})
+}
+
+// Batch - send calls in a single request, more than one call is sent as a JSON-RPC batch.
+// The returned error is about the request itself, errors of each call are set in call.Error
+func (r *JSONRPCClient) Batch(ctx context.Context, calls ...*JSONRPCCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	requests := make([]jsonRPCRequest, len(calls))
+	pending := map[uint64]*JSONRPCCall{}
+	for i, call := range calls {
+		requests[i] = jsonRPCRequest{JSONRPC: jsonRPCVersion, Method: call.Method, Params: call.Params}
+		if !call.Notification {
+			id := atomic.AddUint64(&r.lastID, 1)
+			requests[i].ID = &id
+			pending[id] = call
+		}
+	}
+
+	var in interface{} = requests
+	if len(requests) == 1 {
+		in = requests[0]
+	}
+
+	response, err := r.client.DoJSON(ctx, http.MethodPost, r.endpoint, in, nil, r.headers...)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	responses, err := decodeJSONRPCResponses(response.Body)
+	if err != nil {
+		if !isSuccess(response.StatusCode) {
+			return fmt.Errorf("jsonrpc request returned status [%s]", response.Status)
+		}
+		return err
+	}
+
+	for _, res := range responses {
+		if res.ID == nil {
+			// error not related to a call (parse error, invalid request)
+			if res.Error != nil {
+				return res.Error
+			}
+			continue
+		}
+		call, ok := pending[*res.ID]
+		if !ok {
+			continue
+		}
+		delete(pending, *res.ID)
+
+		if res.Error != nil {
+			call.Error = res.Error
+			continue
+		}
+		if call.Result != nil && len(res.Result) > 0 {
+			if err := json.Unmarshal(res.Result, call.Result); err != nil {
+				return fmt.Errorf("error decoding jsonrpc result of [%s] - [%w]", call.Method, err)
+			}
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("jsonrpc response is missing [%d] results", len(pending))
+	}
+	return nil
+}
+
+// decodeJSONRPCResponses - a single response object or a batch array
+func decodeJSONRPCResponses(body []byte) ([]jsonRPCResponse, error) {
+	var responses []jsonRPCResponse
+	if err := json.Unmarshal(body, &responses); err == nil {
+		return responses, nil
+	}
+
+	var single jsonRPCResponse
+	if err := json.Unmarshal(body, &single); err != nil {
+		return nil, fmt.Errorf("error decoding jsonrpc response [%w]", err)
+	}
+	return []jsonRPCResponse{single}, nil
+}
@@ -0,0 +1,92 @@
+package client_http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// EachJSON - decode the top level JSON array of the body one element at a time calling
+// handler with each of them, so huge arrays are never held in memory. The body is only
+// read while handler is not running, a slow handler slows down the transfer. It stops at
+// the first handler error, which is returned as is, and closes the body. A null body is
+// an empty array
+func (s *StreamResponse) EachJSON(handler func(item json.RawMessage) error) error {
+	defer Defer(s.closeBody)
+
+	return decodeArray(s.Body, func(decoder *json.Decoder, index int) error {
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			return fmt.Errorf("error decoding json array element [%d] - [%w]", index, err)
+		}
+		return handler(item)
+	})
+}
+
+// DecodeEach - like StreamResponse.EachJSON decoding every element into T
+func DecodeEach[T any](s *StreamResponse, handler func(item T) error) error {
+	defer Defer(s.closeBody)
+
+	return decodeArray(s.Body, func(decoder *json.Decoder, index int) error {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			return fmt.Errorf("error decoding json array element [%d] - [%w]", index, err)
+		}
+		return handler(item)
+	})
+}
+
+// GetEach - GET url and decode its JSON array response element by element into T, see
+// StreamResponse.EachJSON. Non-2xx responses are returned as *HTTPError
+func GetEach[T any](ctx context.Context, c *Client, url string, handler func(item T) error, headers ...HeaderParameters) error {
+	jsonHeaders := append([]HeaderParameters{{Key: "Accept", Value: contentTypeJSON}}, headers...)
+	request, err := c.newRequest(ctx, http.MethodGet, url, nil, jsonHeaders)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.stream(request)
+	if err != nil {
+		return err
+	}
+	if !isSuccess(response.StatusCode) {
+		return c.streamHTTPError(request, &http.Response{
+			Status:     response.Status,
+			StatusCode: response.StatusCode,
+			Header:     response.Headers,
+			Body:       response.Body,
+		})
+	}
+	return DecodeEach(response, handler)
+}
+
+// decodeArray - read the opening bracket of body, call decode for every element and
+// check the array is closed
+func decodeArray(body io.Reader, decode func(decoder *json.Decoder, index int) error) error {
+	decoder := json.NewDecoder(body)
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading json array [%w]", err)
+	}
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("json body is not an array, it starts with [%v]", token)
+	}
+
+	for index := 0; decoder.More(); index++ {
+		if err := decode(decoder, index); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("error reading the end of the json array [%w]", err)
+	}
+	return nil
+}
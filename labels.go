@@ -0,0 +1,35 @@
+package client_http
+
+import (
+	"context"
+)
+
+// labelsKey - context key of the telemetry labels
+type labelsKey struct{}
+
+// MarkLabels - mark ctx so requests using it report labels (tenant, operation,
+// feature flag) in request logs, RequestMetric and traces. Labels already in ctx are
+// kept unless labels replaces them
+func MarkLabels(ctx context.Context, labels map[string]string) context.Context {
+	merged := LabelsFrom(ctx)
+	if merged == nil {
+		merged = make(map[string]string, len(labels))
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, labelsKey{}, merged)
+}
+
+// LabelsFrom - copy of the labels marked in ctx, nil when there are none
+func LabelsFrom(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(labelsKey{}).(map[string]string)
+	if len(labels) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
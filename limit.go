@@ -0,0 +1,77 @@
+package client_http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ResponseTooLargeError - response body exceeds the limit set with WithMaxResponseBytes
+type ResponseTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+// Error - url and limit
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response body of [%s] exceeds [%d] bytes", e.URL, e.Limit)
+}
+
+// WithMaxResponseBytes - never read more than limit bytes of a response body, larger
+// bodies fail with *ResponseTooLargeError. It applies to streamed responses and downloads too
+func WithMaxResponseBytes(limit int64) Option {
+	return func(c *Client) error {
+		if limit <= 0 {
+			return fmt.Errorf("invalid max response bytes [%d]", limit)
+		}
+		c.maxResponseBytes = limit
+		return nil
+	}
+}
+
+// limitResponse - reject responses announcing a body over the limit and cap the others
+func (c *Client) limitResponse(request *http.Request, response *http.Response) error {
+	// the body of a protocol switch is the connection, see DoRaw
+	if c.maxResponseBytes <= 0 || response.StatusCode == http.StatusSwitchingProtocols {
+		return nil
+	}
+
+	tooLarge := &ResponseTooLargeError{URL: c.redactURL(request.URL.String()), Limit: c.maxResponseBytes}
+	if response.ContentLength > c.maxResponseBytes {
+		_ = response.Body.Close()
+		return tooLarge
+	}
+
+	response.Body = &limitedBody{body: response.Body, remaining: c.maxResponseBytes, err: tooLarge}
+	return nil
+}
+
+// limitedBody - body failing with err after remaining bytes
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	err       error
+}
+
+// Read - read up to the limit, one byte past it is an error
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+	// read one byte more than allowed to detect oversized bodies
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), b.err
+	}
+	return n, err
+}
+
+// Close - close the wrapped body
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
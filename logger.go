@@ -0,0 +1,228 @@
+package client_http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogLevel - severity of a log entry
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String - level name
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Logger - receives the log entries of the client with structured fields,
+// implementations must be safe for concurrent use
+type Logger interface {
+	Log(level LogLevel, message string, fields map[string]interface{})
+}
+
+// LoggerFunc - adapter to use a function as Logger
+type LoggerFunc func(level LogLevel, message string, fields map[string]interface{})
+
+// Log - call f
+func (f LoggerFunc) Log(level LogLevel, message string, fields map[string]interface{}) {
+	f(level, message, fields)
+}
+
+// StdLogger - Logger writing key=value lines to l
+func StdLogger(l *log.Logger) Logger {
+	return LoggerFunc(func(level LogLevel, message string, fields map[string]interface{}) {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "level=%s msg=%q", level, message)
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %s=%q", k, fmt.Sprint(fields[k]))
+		}
+		l.Print(b.String())
+	})
+}
+
+// NopLogger - Logger discarding every entry
+func NopLogger() Logger {
+	return LoggerFunc(func(LogLevel, string, map[string]interface{}) {})
+}
+
+// WithLogger - send client logs to logger instead of the standard log package
+func WithLogger(logger Logger) Option {
+	return func(c *Client) error {
+		if logger == nil {
+			return fmt.Errorf("logger can't be nil")
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithRequestLogging - log every request attempt and its response at info level.
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie headers and the WithAPIKey
+// header or query parameter are always redacted, redactHeaders adds more sensitive
+// headers and query parameters (tokens, signatures)
+func WithRequestLogging(redactHeaders ...string) Option {
+	return func(c *Client) error {
+		c.internalMiddlewares = append(c.internalMiddlewares, c.loggingMiddleware(redactHeaders))
+		return nil
+	}
+}
+
+// loggingMiddleware - log request and response metadata with redacted headers
+func (c *Client) loggingMiddleware(redact []string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			redact := c.redactNames(redact)
+			c.logger.Log(LevelInfo, "http request", c.logFields(request, map[string]interface{}{
+				"method":  request.Method,
+				"url":     redactURL(request.URL.String(), redact...),
+				"headers": redactHeaders(request.Header, redact),
+			}))
+
+			start := time.Now()
+			response, err := next(request)
+			if err != nil {
+				c.logger.Log(LevelError, "http request failed", c.logFields(request, map[string]interface{}{
+					"method":   request.Method,
+					"url":      redactURL(request.URL.String(), redact...),
+					"duration": time.Since(start),
+					"error":    err,
+				}))
+				return response, err
+			}
+
+			c.logger.Log(LevelInfo, "http response", c.logFields(request, map[string]interface{}{
+				"method":   request.Method,
+				"url":      redactURL(request.URL.String(), redact...),
+				"status":   response.StatusCode,
+				"duration": time.Since(start),
+				"headers":  redactHeaders(response.Header, redact),
+			}))
+			return response, nil
+		}
+	}
+}
+
+// logFields - fields with the request id and labels of request added
+func (c *Client) logFields(request *http.Request, fields map[string]interface{}) map[string]interface{} {
+	if id := c.requestID(request); id != "" {
+		fields["request_id"] = id
+	}
+	if labels := LabelsFrom(request.Context()); labels != nil {
+		fields["labels"] = labels
+	}
+	return fields
+}
+
+// sensitiveHeaders - headers never written to logs
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redactedValue - replaces sensitive values
+const redactedValue = "[REDACTED]"
+
+// redactHeaders - copy of headers with sensitive and extra header values replaced
+func redactHeaders(headers http.Header, extra []string) http.Header {
+	redacted := headers.Clone()
+	if redacted == nil {
+		return http.Header{}
+	}
+	for _, name := range append(append([]string{}, sensitiveHeaders...), extra...) {
+		key := http.CanonicalHeaderKey(name)
+		if _, ok := redacted[key]; ok {
+			redacted[key] = []string{redactedValue}
+		}
+	}
+	return redacted
+}
+
+// redactURL - url with the password of the user info and the values of params query
+// parameters replaced
+func redactURL(rawURL string, params ...string) string {
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		rest := rawURL[i+3:]
+		if at := strings.Index(rest, "@"); at >= 0 && at < strings.IndexAny(rest+"/", "/?#") {
+			rawURL = rawURL[:i+3] + redactedValue + rest[at:]
+		}
+	}
+	if len(params) == 0 {
+		return rawURL
+	}
+
+	start := strings.Index(rawURL, "?")
+	if start < 0 {
+		return rawURL
+	}
+	end := len(rawURL)
+	if i := strings.Index(rawURL[start:], "#"); i >= 0 {
+		end = start + i
+	}
+	return rawURL[:start+1] + redactQuery(rawURL[start+1:end], params) + rawURL[end:]
+}
+
+// redactQuery - raw query with the values of params replaced, the order is kept
+func redactQuery(rawQuery string, params []string) string {
+	if rawQuery == "" || len(params) == 0 {
+		return rawQuery
+	}
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		key := pair
+		if eq := strings.Index(pair, "="); eq >= 0 {
+			key = pair[:eq]
+		}
+		if name, err := url.QueryUnescape(key); err == nil && containsString(params, name) {
+			pairs[i] = key + "=" + redactedValue
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// containsString - values has s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// redactNames - extra headers and query parameters redacted by the client, the api key
+// name is always added
+func (c *Client) redactNames(extra []string) []string {
+	if c.apiKey == nil {
+		return extra
+	}
+	return append(append([]string{}, extra...), c.apiKey.name)
+}
+
+// redactURL - url with the user info and the api key parameter redacted
+func (c *Client) redactURL(rawURL string) string {
+	return redactURL(rawURL, c.redactNames(nil)...)
+}
@@ -0,0 +1,222 @@
+package client_http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Do - execute a request with any http method, payload and custom headers.
+// It is the entry point for verbs without a dedicated helper
+func (c *Client) Do(ctx context.Context, method, url string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	request, err := c.newRequest(ctx, method, url, payload, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.execute(request)
+}
+
+// DoWithCredentials - execute a request with any http method using basic authentication
+func (c *Client) DoWithCredentials(ctx context.Context, method, url, username, password string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	request, err := c.newRequest(ctx, method, url, payload, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	// set Authentication headers
+	request.SetBasicAuth(username, password)
+
+	return c.execute(request)
+}
+
+// Get - execute a GET request with custom headers
+func (c *Client) Get(ctx context.Context, url string, headers ...HeaderParameters) (*Response, error) {
+	return c.Do(ctx, http.MethodGet, url, nil, headers...)
+}
+
+// GetWithCredentials - execute a GET request using basic authentication
+func (c *Client) GetWithCredentials(ctx context.Context, url, username, password string, headers ...HeaderParameters) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodGet, url, username, password, nil, headers...)
+}
+
+// Post - execute a POST request sending payload and custom headers
+func (c *Client) Post(ctx context.Context, url string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	return c.Do(ctx, http.MethodPost, url, payload, headers...)
+}
+
+// PostWithCredentials - execute a POST request using basic authentication
+func (c *Client) PostWithCredentials(ctx context.Context, url, username, password string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodPost, url, username, password, payload, headers...)
+}
+
+// Put - execute a PUT request sending payload and custom headers
+func (c *Client) Put(ctx context.Context, url string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	return c.Do(ctx, http.MethodPut, url, payload, headers...)
+}
+
+// PutWithCredentials - execute a PUT request using basic authentication
+func (c *Client) PutWithCredentials(ctx context.Context, url, username, password string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodPut, url, username, password, payload, headers...)
+}
+
+// Patch - execute a PATCH request sending payload and custom headers
+func (c *Client) Patch(ctx context.Context, url string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	return c.Do(ctx, http.MethodPatch, url, payload, headers...)
+}
+
+// PatchWithCredentials - execute a PATCH request using basic authentication
+func (c *Client) PatchWithCredentials(ctx context.Context, url, username, password string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodPatch, url, username, password, payload, headers...)
+}
+
+// Delete - execute a DELETE request, payload is optional and can be nil
+func (c *Client) Delete(ctx context.Context, url string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	return c.Do(ctx, http.MethodDelete, url, payload, headers...)
+}
+
+// DeleteWithCredentials - execute a DELETE request using basic authentication
+func (c *Client) DeleteWithCredentials(ctx context.Context, url, username, password string, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodDelete, url, username, password, payload, headers...)
+}
+
+// Head - execute a HEAD request, Response.Body will be empty
+func (c *Client) Head(ctx context.Context, url string, headers ...HeaderParameters) (*Response, error) {
+	return c.Do(ctx, http.MethodHead, url, nil, headers...)
+}
+
+// HeadWithCredentials - execute a HEAD request using basic authentication
+func (c *Client) HeadWithCredentials(ctx context.Context, url, username, password string, headers ...HeaderParameters) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodHead, url, username, password, nil, headers...)
+}
+
+// Options - execute an OPTIONS request with custom headers
+func (c *Client) Options(ctx context.Context, url string, headers ...HeaderParameters) (*Response, error) {
+	return c.Do(ctx, http.MethodOptions, url, nil, headers...)
+}
+
+// OptionsWithCredentials - execute an OPTIONS request using basic authentication
+func (c *Client) OptionsWithCredentials(ctx context.Context, url, username, password string, headers ...HeaderParameters) (*Response, error) {
+	return c.DoWithCredentials(ctx, http.MethodOptions, url, username, password, nil, headers...)
+}
+
+// DoReader - execute a request sending body without buffering it. Bodies implementing
+// io.Seeker (files) and bytes or strings readers are rewound for retries and redirects,
+// they are not closed by the client. Other readers, and files that can't seek like
+// pipes, are sent once and closed when they are an io.Closer
+func (c *Client) DoReader(ctx context.Context, method, url string, body io.Reader, headers ...HeaderParameters) (*Response, error) {
+	request, err := c.newStreamRequest(ctx, method, url, body, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.execute(request)
+}
+
+// newRequest - build request for method and url, payload is only attached when present.
+// Relative urls are resolved against the client base url
+func (c *Client) newRequest(ctx context.Context, method, url string, payload []byte, headers []HeaderParameters) (*http.Request, error) {
+	var body io.Reader
+	if len(payload) > 0 {
+		body = bytes.NewReader(payload)
+	}
+
+	return c.newStreamRequest(ctx, method, url, body, headers)
+}
+
+// newStreamRequest - build request reading the payload from body, it can be nil
+func (c *Client) newStreamRequest(ctx context.Context, method, url string, body io.Reader, headers []HeaderParameters) (*http.Request, error) {
+	url = c.resolveURL(url)
+
+	request, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s request for url [%s] - [%w]", method, url, err)
+	}
+
+	// bytes and strings readers get GetBody from net/http, seekers are rewound
+	if seeker, ok := body.(io.ReadSeeker); ok && request.GetBody == nil {
+		if getBody := seekBody(seeker, func(r io.Reader) io.Reader { return r }); getBody != nil {
+			if request.ContentLength == 0 {
+				request.ContentLength = remaining(seeker)
+			}
+			request.GetBody = getBody
+			request.Body, _ = getBody()
+		}
+	}
+
+	// set additional headers
+	applyHeaders(request.Header, headers)
+
+	return request, nil
+}
+
+// resolveURL - join relative url with the client base url
+func (c *Client) resolveURL(url string) string {
+	if c.baseURL == "" || strings.Contains(url, "://") {
+		return url
+	}
+	if url == "" || strings.HasPrefix(url, "?") {
+		return c.baseURL + url
+	}
+	return c.baseURL + "/" + strings.TrimLeft(url, "/")
+}
+
+// seekBody - GetBody function reading body from its current offset, nil when body can't
+// seek (pipes). Each call returns an independent reader, so hedged attempts and body
+// peeks don't move each other. wrap builds the reader of each attempt
+func seekBody(body io.ReadSeeker, wrap func(io.Reader) io.Reader) func() (io.ReadCloser, error) {
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+
+	shared := &sync.Mutex{}
+	return func() (io.ReadCloser, error) {
+		var reader io.Reader
+		if at, ok := body.(io.ReaderAt); ok {
+			reader = io.NewSectionReader(at, start, math.MaxInt64-start)
+		} else {
+			reader = &offsetReader{mu: shared, body: body, offset: start}
+		}
+		return ioutil.NopCloser(wrap(reader)), nil
+	}
+}
+
+// offsetReader - reader of body from its own offset, readers sharing body share mu
+type offsetReader struct {
+	mu     *sync.Mutex
+	body   io.ReadSeeker
+	offset int64
+}
+
+// Read - seek body to the offset of the reader and read from there
+func (r *offsetReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.body.Seek(r.offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("error rewinding request body [%w]", err)
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// remaining - bytes left in body, 0 (unknown) when it can't be measured
+func remaining(body io.Seeker) int64 {
+	current, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	end, err := body.Seek(0, io.SeekEnd)
+	if _, seekErr := body.Seek(current, io.SeekStart); err != nil || seekErr != nil {
+		return 0
+	}
+	return end - current
+}
@@ -0,0 +1,155 @@
+package client_http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// RequestMetric - measures of a single request attempt
+type RequestMetric struct {
+	Method string
+	Host   string
+	// StatusCode - zero when the request failed without response
+	StatusCode int
+	// StatusClass - 1xx, 2xx, 3xx, 4xx, 5xx or error
+	StatusClass string
+	Duration    time.Duration
+	Err         error
+	// Labels - labels of the request context, see MarkLabels
+	Labels map[string]string
+}
+
+// Metrics - receives the measures of every request attempt, implementations must be
+// safe for concurrent use. See clienthttpprom for a Prometheus collector
+type Metrics interface {
+	ObserveRequest(metric RequestMetric)
+}
+
+// ConnMetric - connection used by a request attempt
+type ConnMetric struct {
+	Host string
+	// Reused - the connection was already open, DNS, Connect and TLS are zero
+	Reused bool
+	// IdleTime - time the reused connection was idle in the pool
+	IdleTime time.Duration
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	// Pool - connection counters once the connection was obtained
+	Pool PoolStats
+}
+
+// PoolStats - connections of the client
+type PoolStats struct {
+	// Open - connections dialed and not closed yet
+	Open int
+	// Active - requests holding a connection until their body is closed, with HTTP/2
+	// several of them share a connection
+	Active int
+	// Idle - open connections without request
+	Idle int
+	// Dialed - connections dialed since the client was created, its rate is the
+	// new connection rate
+	Dialed int64
+}
+
+// ConnMetrics - optional interface of Metrics, implementations also receive the
+// connection of every request attempt
+type ConnMetrics interface {
+	ObserveConn(metric ConnMetric)
+}
+
+// WithMetrics - report every request attempt to metrics, and its connection when
+// metrics implements ConnMetrics
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Client) error {
+		if metrics == nil {
+			return fmt.Errorf("metrics can't be nil")
+		}
+		c.internalMiddlewares = append(c.internalMiddlewares, metricsMiddleware(metrics))
+		if connMetrics, ok := metrics.(ConnMetrics); ok {
+			c.internalMiddlewares = append(c.internalMiddlewares, c.connMetricsMiddleware(connMetrics))
+		}
+		return nil
+	}
+}
+
+// metricsMiddleware - measure duration and outcome of requests
+func metricsMiddleware(metrics Metrics) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next(request)
+
+			metric := RequestMetric{
+				Method:      request.Method,
+				Host:        request.URL.Host,
+				StatusClass: "error",
+				Duration:    time.Since(start),
+				Err:         err,
+				Labels:      LabelsFrom(request.Context()),
+			}
+			if err == nil {
+				metric.StatusCode = response.StatusCode
+				metric.StatusClass = StatusClass(response.StatusCode)
+			}
+			metrics.ObserveRequest(metric)
+
+			return response, err
+		}
+	}
+}
+
+// StatusClass - class of status code (2xx, 4xx...)
+func StatusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
+
+// connMetricsMiddleware - trace the connection of requests, counting them as active
+// until the response body is closed
+func (c *Client) connMetricsMiddleware(metrics ConnMetrics) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			var active int32
+			request, _ = trace(request, func(t *connTrace) {
+				if atomic.CompareAndSwapInt32(&active, 0, 1) {
+					atomic.AddInt64(&c.pool.active, 1)
+				}
+				metric := t.connMetric(request.URL.Host)
+				metric.Pool = c.pool.stats()
+				metrics.ObserveConn(metric)
+			})
+			done := func() {
+				if atomic.CompareAndSwapInt32(&active, 1, 2) {
+					atomic.AddInt64(&c.pool.active, -1)
+				}
+			}
+
+			response, err := next(request)
+			if err != nil {
+				done()
+				return response, err
+			}
+			response.Body = &closeNotifyBody{ReadCloser: response.Body, onClose: done}
+			return response, nil
+		}
+	}
+}
+
+// closeNotifyBody - body calling onClose when closed
+type closeNotifyBody struct {
+	io.ReadCloser
+	onClose func()
+}
+
+// Close - close the body and call onClose
+func (b *closeNotifyBody) Close() error {
+	defer b.onClose()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,52 @@
+package client_http
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RoundTripFunc - executes a request and returns the raw response
+type RoundTripFunc func(request *http.Request) (*http.Response, error)
+
+// RoundTrip - call f, lets a RoundTripFunc be used as http.RoundTripper
+func (f RoundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// Middleware - wraps the next step of the chain to inspect or change requests and responses.
+// A middleware reading the response body must replace it with an unread copy
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware - register middlewares, the first one registered is the outermost.
+// Every request attempt, including retries, goes through the chain
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Client) error {
+		for _, m := range middlewares {
+			if m == nil {
+				return fmt.Errorf("middleware can't be nil")
+			}
+		}
+		c.middlewares = append(c.middlewares, middlewares...)
+		return nil
+	}
+}
+
+// buildChain - compose middlewares around the http client, client features run
+// closest to the transport. The cache runs first so hits skip every client feature, then
+// routing so the other features see the final destination. Signing runs last so no
+// feature changes a signed request
+func (c *Client) buildChain() RoundTripFunc {
+	middlewares := append([]Middleware{}, c.middlewares...)
+	if c.cache != nil {
+		middlewares = append(middlewares, c.cache.middleware(c))
+	}
+	middlewares = append(middlewares, c.routingMiddlewares...)
+	middlewares = append(middlewares, c.internalMiddlewares...)
+	middlewares = append(middlewares, c.signingMiddlewares...)
+
+	chain := RoundTripFunc(c.do)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		chain = middlewares[i](chain)
+	}
+	return chain
+}
@@ -0,0 +1,141 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MultipartFile - file part of a multipart/form-data body, read from Reader or from Path
+type MultipartFile struct {
+	// FieldName - form field of the file
+	FieldName string
+	// FileName - name sent to the server, defaults to the base name of Path
+	FileName string
+	// ContentType - defaults to application/octet-stream
+	ContentType string
+	// Reader - file content, when nil the file at Path is opened
+	Reader io.Reader
+	Path   string
+}
+
+// PostMultipart - POST a multipart/form-data body built from fields and files.
+// The body is streamed while it is written so files are never held in memory,
+// for that reason multipart requests are not retried
+func (c *Client) PostMultipart(ctx context.Context, url string, fields map[string]string, files []MultipartFile, headers ...HeaderParameters) (*Response, error) {
+	return c.DoMultipart(ctx, http.MethodPost, url, fields, files, headers...)
+}
+
+// UploadFile - POST the file at path in fieldName along with fields
+func (c *Client) UploadFile(ctx context.Context, url, fieldName, path string, fields map[string]string, headers ...HeaderParameters) (*Response, error) {
+	return c.PostMultipart(ctx, url, fields, []MultipartFile{{FieldName: fieldName, Path: path}}, headers...)
+}
+
+// DoMultipart - execute a request with any method and a streamed multipart/form-data body
+func (c *Client) DoMultipart(ctx context.Context, method, url string, fields map[string]string, files []MultipartFile, headers ...HeaderParameters) (*Response, error) {
+	for _, f := range files {
+		if f.FieldName == "" {
+			return nil, fmt.Errorf("multipart file field name can't be empty")
+		}
+		if f.Reader == nil && f.Path == "" {
+			return nil, fmt.Errorf("multipart file [%s] needs a reader or a path", f.FieldName)
+		}
+	}
+
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	request, err := c.newStreamRequest(ctx, method, url, reader, headers)
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	request.Header.Set("Content-Type", form.FormDataContentType())
+
+	// write the body while the transport reads it
+	go func() {
+		_ = writer.CloseWithError(writeMultipart(form, fields, files))
+	}()
+
+	response, err := c.execute(request)
+	// stop the writer when the body was not read to the end, the request may have
+	// failed before being sent
+	_ = reader.Close()
+	return response, err
+}
+
+// writeMultipart - write fields, sorted by name, and files into form
+func writeMultipart(form *multipart.Writer, fields map[string]string, files []MultipartFile) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := form.WriteField(name, fields[name]); err != nil {
+			return fmt.Errorf("error writing multipart field [%s] - [%w]", name, err)
+		}
+	}
+
+	for _, f := range files {
+		if err := writeMultipartFile(form, f); err != nil {
+			return err
+		}
+	}
+
+	return form.Close()
+}
+
+// writeMultipartFile - copy file content into a new part of form
+func writeMultipartFile(form *multipart.Writer, f MultipartFile) error {
+	reader := f.Reader
+	if reader == nil {
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return fmt.Errorf("error opening multipart file [%s] - [%w]", f.Path, err)
+		}
+		defer Defer(func() {
+			_ = file.Close()
+		})
+		reader = file
+	}
+
+	fileName := f.FileName
+	if fileName == "" && f.Path != "" {
+		fileName = filepath.Base(f.Path)
+	}
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(f.FieldName), escapeQuotes(fileName)))
+	header.Set("Content-Type", contentType)
+
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("error creating multipart part [%s] - [%w]", f.FieldName, err)
+	}
+	if _, err := io.Copy(part, reader); err != nil {
+		return fmt.Errorf("error writing multipart file [%s] - [%w]", f.FieldName, err)
+	}
+
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes - escape a Content-Disposition parameter, as mime/multipart does
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,81 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("upload")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		content, _ := ioutil.ReadAll(file)
+		_, _ = w.Write([]byte(r.FormValue("name") + "|" + header.Filename + "|" + string(content)))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(path, []byte("file content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	response, err := c.UploadFile(context.Background(), server.URL, "upload", path, map[string]string{"name": "monthly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(response.Body); got != "monthly|report.txt|file content" {
+		t.Fatalf("server received %q", got)
+	}
+}
+
+func TestMultipartReleasedOnError(t *testing.T) {
+	failing := client_http.TokenSourceFunc(func(ctx context.Context) (*client_http.Token, error) {
+		return nil, errors.New("no token")
+	})
+	c, err := client_http.NewHttpClient(client_http.WithTokenSource(failing))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(path, []byte("file content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := c.UploadFile(context.Background(), "http://127.0.0.1:1/", "upload", path, map[string]string{"name": "monthly"}); err == nil {
+			t.Fatal("error = nil, want the token error")
+		}
+	}
+
+	// the writers of the bodies that were never sent exit
+	waitFor(t, 2*time.Second, func() bool {
+		stacks := make([]byte, 1<<20)
+		return !strings.Contains(string(stacks[:runtime.Stack(stacks, true)]), "client_http.(*Client).DoMultipart")
+	})
+}
@@ -0,0 +1,104 @@
+package client_http
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// UnsupportedMediaTypeError - response Content-Type without a registered decoder, returned
+// when WithContentNegotiation is used
+type UnsupportedMediaTypeError struct {
+	URL         string
+	ContentType string
+	// Accept - Accept header sent with the request
+	Accept string
+}
+
+// Error - url and content type
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("unsupported content type [%s] from [%s], accepted [%s]", e.ContentType, e.URL, e.Accept)
+}
+
+// negotiation - content negotiation settings
+type negotiation struct {
+	preferred []string
+	// accept - Accept header built from the decoders once all options are applied
+	accept string
+}
+
+// WithContentNegotiation - send an Accept header listing the media types of the registered
+// decoders (json, xml, form and the ones of WithDecoder), preferred first, with
+// decreasing q-values. 2xx responses with a body whose Content-Type has no decoder fail
+// with *UnsupportedMediaTypeError. Calls setting their own Accept header are not checked
+func WithContentNegotiation(preferred ...string) Option {
+	return func(c *Client) error {
+		for _, mediaType := range preferred {
+			if _, _, err := mime.ParseMediaType(mediaType); err != nil {
+				return fmt.Errorf("invalid media type [%s] - [%w]", mediaType, err)
+			}
+		}
+		c.negotiation = &negotiation{preferred: preferred}
+		c.internalMiddlewares = append(c.internalMiddlewares, c.negotiationMiddleware)
+		return nil
+	}
+}
+
+// acceptHeader - preferred media types then the other decoders, q-values from 1 down to 0.1
+func (r *decoderRegistry) acceptHeader(preferred []string) string {
+	seen := map[string]bool{}
+	var mediaTypes []string
+	for _, mediaType := range append(append([]string{}, preferred...), r.mediaTypes...) {
+		mediaType = strings.ToLower(mediaType)
+		if !seen[mediaType] {
+			seen[mediaType] = true
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+
+	values := make([]string, len(mediaTypes))
+	for i, mediaType := range mediaTypes {
+		q := 10 - i
+		switch {
+		case i == 0:
+			values[i] = mediaType
+			continue
+		case q < 1:
+			q = 1
+		}
+		values[i] = mediaType + ";q=0." + strconv.Itoa(q)
+	}
+	return strings.Join(values, ", ")
+}
+
+// negotiationMiddleware - reject responses the decoders can't read
+func (c *Client) negotiationMiddleware(next RoundTripFunc) RoundTripFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		response, err := next(request)
+		if err != nil || request.Header.Get("Accept") != c.negotiation.accept {
+			return response, err
+		}
+		if !isSuccess(response.StatusCode) || response.StatusCode == http.StatusNoContent || response.ContentLength == 0 ||
+			request.Method == http.MethodHead {
+			return response, nil
+		}
+
+		contentType := response.Header.Get("Content-Type")
+		if _, err := c.decoders.lookup(contentType); err == nil {
+			return response, nil
+		}
+		// without Content-Type and length, a chunked body may still be empty
+		if contentType == "" && response.ContentLength < 0 {
+			if n, err := response.Body.Read(make([]byte, 1)); n == 0 && err == io.EOF {
+				return response, nil
+			}
+		}
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+		_ = response.Body.Close()
+		return nil, &UnsupportedMediaTypeError{URL: c.redactURL(request.URL.String()), ContentType: contentType, Accept: c.negotiation.accept}
+	}
+}
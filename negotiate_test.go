@@ -0,0 +1,63 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		case "/unknown":
+			w.Header().Set("Content-Type", "application/x-unknown")
+			_, _ = w.Write([]byte("data"))
+		case "/untyped":
+			w.Header()["Content-Type"] = nil
+			_, _ = w.Write([]byte("data"))
+		case "/empty-chunked":
+			// flushed headers without a length, chunked with no chunk
+			w.Header()["Content-Type"] = nil
+			w.(http.Flusher).Flush()
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithContentNegotiation("application/json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		path        string
+		unsupported bool
+	}{
+		{path: "/json"},
+		{path: "/unknown", unsupported: true},
+		{path: "/untyped", unsupported: true},
+		{path: "/empty-chunked"},
+		{path: "/no-content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := c.Get(context.Background(), server.URL+tt.path)
+			var unsupported *client_http.UnsupportedMediaTypeError
+			if errors.As(err, &unsupported) != tt.unsupported {
+				t.Fatalf("error = %v, want unsupported %v", err, tt.unsupported)
+			}
+			if err != nil && !tt.unsupported {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package client_http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientCredentialsConfig - OAuth2 client credentials grant (RFC 6749 section 4.4)
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// EndpointParams - extra form values sent to the token endpoint (audience, resource)
+	EndpointParams url.Values
+	// CredentialsInBody - send client_id and client_secret in the form instead of basic auth
+	CredentialsInBody bool
+	// ExpirySkew - tokens are renewed this long before they expire, 30s by default. It
+	// is capped to a quarter of the token lifetime
+	ExpirySkew time.Duration
+	// RefreshWindow - tokens are renewed in background when they expire within the window,
+	// so requests rarely wait for the token endpoint. 2 minutes by default, capped to half
+	// of the token lifetime
+	RefreshWindow time.Duration
+	// HTTPClient - client used to call the token endpoint, 30s timeout by default
+	HTTPClient *http.Client
+}
+
+// OAuth2Error - error response of the token endpoint
+type OAuth2Error struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	URI         string `json:"error_uri"`
+	bodySnippet string
+}
+
+// Error - status and oauth2 error code
+func (e *OAuth2Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("token endpoint returned status [%d] - [%s]", e.StatusCode, e.bodySnippet)
+	}
+	return fmt.Sprintf("token endpoint returned status [%d] - [%s] [%s]", e.StatusCode, e.Code, e.Description)
+}
+
+// ClientCredentialsSource - TokenSource fetching tokens with the client credentials
+// grant, tokens are cached until they are about to expire. Concurrent calls share a
+// single request to the token endpoint
+type ClientCredentialsSource struct {
+	config ClientCredentialsConfig
+
+	mu    sync.Mutex
+	token *Token
+	// lifetime - validity of token when it was fetched
+	lifetime time.Duration
+	// previous - Authorization value of the token replaced or dropped last
+	previous string
+	// fetching - request to the token endpoint in flight, nil when there is none
+	fetching *tokenFetch
+}
+
+// tokenFetch - request to the token endpoint shared by the callers waiting for it
+type tokenFetch struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// NewClientCredentialsSource - token source for config
+func NewClientCredentialsSource(config ClientCredentialsConfig) (*ClientCredentialsSource, error) {
+	if config.TokenURL == "" || config.ClientID == "" {
+		return nil, fmt.Errorf("token url and client id are required")
+	}
+	if config.ExpirySkew == 0 {
+		config.ExpirySkew = 30 * time.Second
+	}
+	if config.RefreshWindow == 0 {
+		config.RefreshWindow = 2 * time.Minute
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &ClientCredentialsSource{config: config}, nil
+}
+
+// WithClientCredentials - authorize every request with a token of the client credentials
+// grant. On 401 the cached token is dropped and the request is sent once more with a new one
+func WithClientCredentials(config ClientCredentialsConfig) Option {
+	return func(c *Client) error {
+		source, err := NewClientCredentialsSource(config)
+		if err != nil {
+			return err
+		}
+		c.tokenSource = source
+		c.internalMiddlewares = append(c.internalMiddlewares, reauthMiddleware(source.reauthenticate))
+		return nil
+	}
+}
+
+// Token - cached token, fetched when it expires within the skew and refreshed in
+// background when it expires within the refresh window
+func (s *ClientCredentialsSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	skew, window := s.windows()
+	if s.token != nil && !s.token.expired(skew) {
+		token := s.token
+		if token.expired(window) && s.fetching == nil {
+			s.startFetch()
+		}
+		s.mu.Unlock()
+		return token, nil
+	}
+
+	fetch := s.fetching
+	if fetch == nil {
+		fetch = s.startFetch()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.token, fetch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Invalidate - drop the cached token, the next call fetches a new one
+func (s *ClientCredentialsSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drop()
+}
+
+// drop - forget the cached token, remembering it as the previous one. s.mu must be held
+func (s *ClientCredentialsSource) drop() {
+	if s.token != nil {
+		s.previous = s.token.authorization()
+	}
+	s.token = nil
+}
+
+// windows - expiry skew and refresh window of the cached token, capped to a quarter and
+// half of its lifetime so short lived tokens are not renewed on every call. s.mu must
+// be held
+func (s *ClientCredentialsSource) windows() (time.Duration, time.Duration) {
+	skew, window := s.config.ExpirySkew, s.config.RefreshWindow
+	if s.lifetime > 0 {
+		if skew > s.lifetime/4 {
+			skew = s.lifetime / 4
+		}
+		if window > s.lifetime/2 {
+			window = s.lifetime / 2
+		}
+	}
+	return skew, window
+}
+
+// startFetch - request a token in background, detached from the callers so one of them
+// giving up does not fail the others. Failures keep the cached token. s.mu must be held
+func (s *ClientCredentialsSource) startFetch() *tokenFetch {
+	fetch := &tokenFetch{done: make(chan struct{})}
+	s.fetching = fetch
+
+	go func() {
+		ctx := context.Background()
+		if timeout := s.config.HTTPClient.Timeout; timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout+time.Second)
+			defer cancel()
+		}
+		started := time.Now()
+		token, err := s.fetch(ctx)
+
+		s.mu.Lock()
+		s.fetching = nil
+		if err == nil {
+			s.drop()
+			s.token = token
+			s.lifetime = 0
+			if !token.Expiry.IsZero() {
+				s.lifetime = token.Expiry.Sub(started)
+			}
+		}
+		s.mu.Unlock()
+
+		fetch.token, fetch.err = token, err
+		close(fetch.done)
+	}()
+	return fetch
+}
+
+// fetch - request a new token from the token endpoint
+func (s *ClientCredentialsSource) fetch(ctx context.Context) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	for k, v := range s.config.EndpointParams {
+		form[k] = v
+	}
+	if s.config.CredentialsInBody {
+		form.Set("client_id", s.config.ClientID)
+		form.Set("client_secret", s.config.ClientSecret)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating token request [%w]", err)
+	}
+	request.Header.Set("Content-Type", contentTypeForm)
+	request.Header.Set("Accept", contentTypeJSON)
+	if !s.config.CredentialsInBody {
+		request.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	response, err := s.config.HTTPClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting token [%w]", err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("error reading token response [%w]", err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		oauthErr := &OAuth2Error{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(body, oauthErr)
+		if len(body) > maxErrorBodySize {
+			body = body[:maxErrorBodySize]
+		}
+		oauthErr.bodySnippet = string(body)
+		return nil, oauthErr
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("error decoding token response [%w]", err)
+	}
+	if payload.AccessToken == "" {
+		return nil, fmt.Errorf("token response without access_token")
+	}
+
+	token := &Token{AccessToken: payload.AccessToken, TokenType: payload.TokenType}
+	if strings.EqualFold(token.TokenType, "bearer") {
+		token.TokenType = "Bearer"
+	}
+	if payload.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// reauthenticate - Reauthenticator renewing the token of source when the rejected
+// request was authorized by it. A request sent with the token replaced since is retried
+// with the current one
+func (s *ClientCredentialsSource) reauthenticate(retry *http.Request, _ *http.Response) error {
+	sent := retry.Header.Get("Authorization")
+	s.mu.Lock()
+	switch {
+	case s.token != nil && sent == s.token.authorization():
+		s.drop()
+	case sent != "" && sent == s.previous:
+		// rotated by another request, the cached or fetching token is used
+	default:
+		s.mu.Unlock()
+		return fmt.Errorf("request was not authorized by the client credentials token")
+	}
+	s.mu.Unlock()
+
+	token, err := s.Token(retry.Context())
+	if err != nil {
+		return err
+	}
+	retry.Header.Set("Authorization", token.authorization())
+	return nil
+}
@@ -0,0 +1,191 @@
+package client_http_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+// tokenServer - token endpoint issuing tok-1, tok-2... valid for expiresIn seconds
+func tokenServer(t *testing.T, expiresIn int, delay time.Duration) (*httptest.Server, *int32) {
+	t.Helper()
+	var issued int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "id" || password != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		time.Sleep(delay)
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"tok-%d","token_type":"bearer","expires_in":%d}`, n, expiresIn)
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func TestClientCredentialsCoalescesFetches(t *testing.T) {
+	tokens, issued := tokenServer(t, 3600, 20*time.Millisecond)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer api.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithClientCredentials(client_http.ClientCredentialsConfig{
+		TokenURL: tokens.URL, ClientID: "id", ClientSecret: "secret",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := c.Get(context.Background(), api.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if response.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200", response.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt32(issued); got != 1 {
+		t.Fatalf("%d tokens fetched, want 1", got)
+	}
+}
+
+func TestClientCredentialsShortLivedToken(t *testing.T) {
+	tokens, issued := tokenServer(t, 4, 0)
+	source, err := client_http.NewClientCredentialsSource(client_http.ClientCredentialsConfig{
+		TokenURL: tokens.URL, ClientID: "id", ClientSecret: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the default 30s skew and 2m window are capped to 1s and 2s for a 4s token
+	for i := 0; i < 5; i++ {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessToken != "tok-1" {
+			t.Fatalf("token = %s, want tok-1", token.AccessToken)
+		}
+	}
+	if got := atomic.LoadInt32(issued); got != 1 {
+		t.Fatalf("%d tokens fetched, want 1", got)
+	}
+}
+
+func TestClientCredentialsRefreshInBackground(t *testing.T) {
+	tokens, issued := tokenServer(t, 1, 0)
+	source, err := client_http.NewClientCredentialsSource(client_http.ClientCredentialsConfig{
+		TokenURL: tokens.URL, ClientID: "id", ClientSecret: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := source.Token(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// within the refresh window, the cached token is returned while a new one is fetched
+	time.Sleep(600 * time.Millisecond)
+	token, err := source.Token(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "tok-1" {
+		t.Fatalf("token = %s, want the cached tok-1", token.AccessToken)
+	}
+	waitFor(t, 2*time.Second, func() bool { return atomic.LoadInt32(issued) == 2 })
+	waitFor(t, 2*time.Second, func() bool {
+		token, err := source.Token(ctx)
+		return err == nil && token.AccessToken == "tok-2"
+	})
+}
+
+func TestClientCredentialsError(t *testing.T) {
+	tokens, _ := tokenServer(t, 3600, 0)
+	source, err := client_http.NewClientCredentialsSource(client_http.ClientCredentialsConfig{
+		TokenURL: tokens.URL, ClientID: "id", ClientSecret: "wrong",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = source.Token(context.Background())
+	oauthErr, ok := err.(*client_http.OAuth2Error)
+	if !ok || oauthErr.StatusCode != http.StatusUnauthorized || oauthErr.Code != "invalid_client" {
+		t.Fatalf("error = %v, want invalid_client", err)
+	}
+}
+
+func TestClientCredentialsReauthenticateAfterRotation(t *testing.T) {
+	const requests = 5
+	tokens, issued := tokenServer(t, 3600, 0)
+
+	// rejected requests are held until all of them were sent with tok-1
+	var rejected int32
+	release := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer tok-2" {
+			return
+		}
+		if atomic.AddInt32(&rejected, 1) == requests {
+			close(release)
+		}
+		select {
+		case <-release:
+		case <-time.After(2 * time.Second):
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer api.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithClientCredentials(client_http.ClientCredentialsConfig{
+		TokenURL: tokens.URL, ClientID: "id", ClientSecret: "secret",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := c.Get(context.Background(), api.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if response.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200", response.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt32(issued); got != 2 {
+		t.Fatalf("%d tokens fetched, want 2", got)
+	}
+}
@@ -0,0 +1,188 @@
+package client_http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Option - functional option used to configure the Client on NewHttpClient
+type Option func(c *Client) error
+
+// WithTimeout - overall timeout of a request, including reading the body. Zero means no timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("invalid timeout [%v]", timeout)
+		}
+		c.Instance.Timeout = timeout
+		return nil
+	}
+}
+
+// WithDialTimeout - max time to establish a TCP connection, 30s by default. Zero means no timeout
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("invalid dial timeout [%v]", timeout)
+		}
+		c.dialer.Timeout = timeout
+		return nil
+	}
+}
+
+// WithTLSHandshakeTimeout - max time of the TLS handshake, 10s by default. Zero means no timeout
+func WithTLSHandshakeTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("invalid tls handshake timeout [%v]", timeout)
+		}
+		c.transport.TLSHandshakeTimeout = timeout
+		return nil
+	}
+}
+
+// WithResponseHeaderTimeout - max time waiting for the response headers once the request
+// is sent, reading the body is not limited. Zero means no timeout
+func WithResponseHeaderTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("invalid response header timeout [%v]", timeout)
+		}
+		c.transport.ResponseHeaderTimeout = timeout
+		return nil
+	}
+}
+
+// WithIdleConnTimeout - time an idle connection is kept in the pool, 90s by default.
+// Zero means no limit
+func WithIdleConnTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("invalid idle connection timeout [%v]", timeout)
+		}
+		c.transport.IdleConnTimeout = timeout
+		return nil
+	}
+}
+
+// WithMaxConns - max connections per host (dialing, active and idle). Zero means no limit
+func WithMaxConns(maxConnsPerHost int) Option {
+	return func(c *Client) error {
+		if maxConnsPerHost < 0 {
+			return fmt.Errorf("invalid max connections per host [%d]", maxConnsPerHost)
+		}
+		c.transport.MaxConnsPerHost = maxConnsPerHost
+		return nil
+	}
+}
+
+// WithMaxIdleConns - max idle connections kept in the pool in total and per host
+func WithMaxIdleConns(maxIdleConns, maxIdleConnsPerHost int) Option {
+	return func(c *Client) error {
+		if maxIdleConns < 0 || maxIdleConnsPerHost < 0 {
+			return fmt.Errorf("invalid max idle connections [%d] - [%d]", maxIdleConns, maxIdleConnsPerHost)
+		}
+		c.transport.MaxIdleConns = maxIdleConns
+		c.transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		return nil
+	}
+}
+
+// WithTransport - use a custom RoundTripper instead of the default pooled transport.
+// Transport related options (TLS, proxy, pool sizes) have no effect when it is set
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) error {
+		if transport == nil {
+			return fmt.Errorf("transport can't be nil")
+		}
+		c.roundTripper = transport
+		return nil
+	}
+}
+
+// WithTransportWrapper - wrap the transport once every option has been applied, the
+// first wrapper registered is the innermost. Used by modules adding protocols below the
+// http client, see clienthttp3
+func WithTransportWrapper(wrap func(next http.RoundTripper) http.RoundTripper) Option {
+	return func(c *Client) error {
+		if wrap == nil {
+			return fmt.Errorf("transport wrapper can't be nil")
+		}
+		c.transportWrappers = append(c.transportWrappers, wrap)
+		return nil
+	}
+}
+
+// WithProxy - function used to select the proxy of each request, see http.ProxyURL.
+// By default, proxies are read from the environment (HTTP_PROXY, HTTPS_PROXY, NO_PROXY)
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) Option {
+	return func(c *Client) error {
+		c.transport.Proxy = proxy
+		return nil
+	}
+}
+
+// WithTLSConfig - TLS configuration used by the transport
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) error {
+		if config == nil {
+			return fmt.Errorf("tls config can't be nil")
+		}
+		c.transport.TLSClientConfig = config.Clone()
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify - skip verification of the server certificate chain and host name
+func WithInsecureSkipVerify() Option {
+	return func(c *Client) error {
+		c.tlsConfig().InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// WithBaseURL - prefix for relative urls, c.Get(ctx, "/v1/users") requests baseURL + "/v1/users".
+// Absolute urls are sent unchanged
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		parsed, err := url.Parse(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid base url [%s] - [%w]", baseURL, err)
+		}
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("base url [%s] must be absolute", baseURL)
+		}
+		c.baseURL = strings.TrimRight(baseURL, "/")
+		return nil
+	}
+}
+
+// WithDefaultHeaders - headers sent on every request (api keys, tenant ids, User-Agent).
+// Headers passed to a call take precedence
+func WithDefaultHeaders(headers ...HeaderParameters) Option {
+	return func(c *Client) error {
+		c.defaultHeaders = append(c.defaultHeaders, headers...)
+		return nil
+	}
+}
+
+// WithTimings - measure DNS, connect, TLS, time to first byte and total duration of
+// every request in Response.Timings
+func WithTimings() Option {
+	return func(c *Client) error {
+		c.timings = true
+		return nil
+	}
+}
+
+// tlsConfig - TLS configuration of the transport, created on first use
+func (c *Client) tlsConfig() *tls.Config {
+	if c.transport.TLSClientConfig == nil {
+		c.transport.TLSClientConfig = &tls.Config{}
+	}
+	return c.transport.TLSClientConfig
+}
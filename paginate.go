@@ -0,0 +1,262 @@
+package client_http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PaginateOptions - how Paginate finds the next page. Without fields it follows the
+// RFC 5988 Link header with rel="next"
+type PaginateOptions struct {
+	Headers []HeaderParameters
+	// CursorField - dot separated path of the next cursor in the JSON body (meta.next_cursor),
+	// sent as CursorParam. A missing, null or empty cursor ends the pagination
+	CursorField string
+	// CursorParam - query parameter of the cursor, "cursor" by default
+	CursorParam string
+	// NextURLField - dot separated path of the next page url in the JSON body (links.next)
+	NextURLField string
+	// OffsetParam - query parameter incremented by the items of every page (offset, skip)
+	OffsetParam string
+	// ItemsField - dot separated path of the items array counted by OffsetParam, empty when
+	// the body is the array
+	ItemsField string
+	// Limit - page size sent as LimitParam, a page with fewer items is the last one. Zero
+	// stops at the first empty page
+	Limit int
+	// LimitParam - query parameter of Limit, "limit" by default
+	LimitParam string
+	// MaxPages - stop after this many pages, zero means no limit
+	MaxPages int
+}
+
+// Pager - iterator over the pages of a paginated API:
+//
+//	pager := c.Paginate(ctx, "/v1/users", nil)
+//	for pager.Next() {
+//		var users []User
+//		_ = pager.Page().JSON(&users)
+//	}
+//	if err := pager.Err(); err != nil { ... }
+type Pager struct {
+	client *Client
+	ctx    context.Context
+	opts   PaginateOptions
+
+	next   string
+	offset int
+	pages  int
+	page   *Response
+	err    error
+}
+
+// Paginate - iterate the pages of url, requests are sent by Next
+func (c *Client) Paginate(ctx context.Context, url string, opts *PaginateOptions) *Pager {
+	p := &Pager{client: c, ctx: ctx, next: c.resolveURL(url)}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.CursorParam == "" {
+		p.opts.CursorParam = "cursor"
+	}
+	if p.opts.LimitParam == "" {
+		p.opts.LimitParam = "limit"
+	}
+	if p.opts.OffsetParam != "" {
+		p.next, p.err = p.withOffset(p.next, 0)
+	}
+	return p
+}
+
+// Next - request the next page, false once every page was read or a request failed
+func (p *Pager) Next() bool {
+	if p.err != nil || p.next == "" || (p.opts.MaxPages > 0 && p.pages >= p.opts.MaxPages) {
+		return false
+	}
+
+	current := p.next
+	response, err := p.client.Get(p.ctx, current, p.opts.Headers...)
+	if err != nil {
+		p.err = err
+		return false
+	}
+	if !isSuccess(response.StatusCode) {
+		p.err = fmt.Errorf("page [%s] returned status [%s]", p.client.redactURL(current), response.Status)
+		return false
+	}
+
+	p.page = response
+	p.pages++
+	if p.next, err = p.nextURL(current, response); err != nil {
+		p.err = err
+		p.next = ""
+	}
+	return true
+}
+
+// Page - response of the current page
+func (p *Pager) Page() *Response {
+	return p.page
+}
+
+// Err - error that stopped the pagination, nil when every page was read
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// nextURL - url of the page after the current one, empty when it was the last one
+func (p *Pager) nextURL(current string, response *Response) (string, error) {
+	switch {
+	case p.opts.CursorField != "":
+		value, err := p.field(response, p.opts.CursorField)
+		if err != nil || value == "" {
+			return "", err
+		}
+		return setQuery(current, p.opts.CursorParam, value)
+
+	case p.opts.NextURLField != "":
+		value, err := p.field(response, p.opts.NextURLField)
+		if err != nil || value == "" {
+			return "", err
+		}
+		return resolveReference(current, value)
+
+	case p.opts.OffsetParam != "":
+		count, err := p.items(response)
+		if err != nil || count == 0 || (p.opts.Limit > 0 && count < p.opts.Limit) {
+			return "", err
+		}
+		p.offset += count
+		return p.withOffset(current, p.offset)
+	}
+
+	next := parseLinkHeader(response.Headers.Values("Link"))["next"]
+	if next == "" {
+		return "", nil
+	}
+	return resolveReference(current, next)
+}
+
+// field - string value of the field at path of the JSON body, empty when missing or null
+func (p *Pager) field(response *Response, path string) (string, error) {
+	body, err := decodeJSONValue(response.Body)
+	if err != nil {
+		return "", err
+	}
+	switch v := lookupJSON(body, path).(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("pagination field [%s] is not a string or number", path)
+	}
+}
+
+// items - length of the items array of the JSON body
+func (p *Pager) items(response *Response) (int, error) {
+	body, err := decodeJSONValue(response.Body)
+	if err != nil {
+		return 0, err
+	}
+	items := body
+	if p.opts.ItemsField != "" {
+		items = lookupJSON(body, p.opts.ItemsField)
+	}
+	array, ok := items.([]interface{})
+	if !ok && items != nil {
+		return 0, fmt.Errorf("pagination items [%s] are not an array", p.opts.ItemsField)
+	}
+	return len(array), nil
+}
+
+// withOffset - rawURL with the offset and limit parameters
+func (p *Pager) withOffset(rawURL string, offset int) (string, error) {
+	next, err := setQuery(rawURL, p.opts.OffsetParam, strconv.Itoa(offset))
+	if err != nil || p.opts.Limit <= 0 {
+		return next, err
+	}
+	return setQuery(next, p.opts.LimitParam, strconv.Itoa(p.opts.Limit))
+}
+
+// parseLinkHeader - urls of the RFC 5988 Link header values by rel
+func parseLinkHeader(values []string) map[string]string {
+	links := map[string]string{}
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = target[1 : len(target)-1]
+			for _, param := range parts[1:] {
+				name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+					if _, ok := links[strings.ToLower(rel)]; !ok {
+						links[strings.ToLower(rel)] = target
+					}
+				}
+			}
+		}
+	}
+	return links
+}
+
+// resolveReference - reference resolved against base
+func resolveReference(base, reference string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid url [%s] - [%w]", base, err)
+	}
+	next, err := u.Parse(reference)
+	if err != nil {
+		return "", fmt.Errorf("invalid next page url [%s] - [%w]", reference, err)
+	}
+	return next.String(), nil
+}
+
+// setQuery - rawURL with the query parameter key replaced by value
+func setQuery(rawURL, key, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url [%s] - [%w]", rawURL, err)
+	}
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// decodeJSONValue - generic value of a JSON document, numbers are kept as json.Number
+func decodeJSONValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("error decoding json response [%w]", err)
+	}
+	return value, nil
+}
+
+// lookupJSON - value at the dot separated path of a generic JSON value, nil when missing
+func lookupJSON(value interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
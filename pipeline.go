@@ -0,0 +1,153 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Step - request of a pipeline, run once the steps it depends on succeeded
+type Step struct {
+	Name      string
+	DependsOn []string
+	// Request - build the request of the step from the responses of its dependencies,
+	// keyed by step name. Returning an error fails the step
+	Request func(ctx context.Context, results map[string]*Response) (BatchRequest, error)
+}
+
+// PipelineError - failed and skipped steps of a pipeline, keyed by step name
+type PipelineError struct {
+	Errors map[string]error
+}
+
+// Error - errors of the steps in name order
+func (e *PipelineError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := make([]string, 0, len(names))
+	for _, name := range names {
+		messages = append(messages, fmt.Sprintf("%s: %v", name, e.Errors[name]))
+	}
+	return fmt.Sprintf("pipeline failed [%s]", strings.Join(messages, "; "))
+}
+
+// RunPipeline - run steps as soon as their dependencies are done, independent steps
+// concurrently (token fetch -> resource fetch -> enrichment). A failed step skips the
+// steps depending on it but not the others. It returns the responses of the successful
+// steps and a *PipelineError when any step failed or was skipped
+func (c *Client) RunPipeline(ctx context.Context, steps ...Step) (map[string]*Response, error) {
+	if err := validatePipeline(steps); err != nil {
+		return nil, err
+	}
+
+	var (
+		mu      sync.Mutex
+		results = map[string]*Response{}
+		errs    = map[string]error{}
+		done    = map[string]chan struct{}{}
+		wg      sync.WaitGroup
+	)
+	for _, step := range steps {
+		done[step.Name] = make(chan struct{})
+	}
+
+	for _, step := range steps {
+		wg.Add(1)
+		go func(step Step) {
+			defer wg.Done()
+			defer close(done[step.Name])
+
+			for _, dependency := range step.DependsOn {
+				<-done[dependency]
+			}
+
+			mu.Lock()
+			inputs := make(map[string]*Response, len(step.DependsOn))
+			for _, dependency := range step.DependsOn {
+				if _, failed := errs[dependency]; failed {
+					errs[step.Name] = fmt.Errorf("skipped, dependency [%s] failed", dependency)
+					mu.Unlock()
+					return
+				}
+				inputs[dependency] = results[dependency]
+			}
+			mu.Unlock()
+
+			response, err := c.runStep(ctx, step, inputs)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[step.Name] = err
+				return
+			}
+			results[step.Name] = response
+		}(step)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, &PipelineError{Errors: errs}
+	}
+	return results, nil
+}
+
+// runStep - build and execute the request of step
+func (c *Client) runStep(ctx context.Context, step Step, inputs map[string]*Response) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	request, err := step.Request(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("error building request [%w]", err)
+	}
+	return c.Do(ctx, request.Method, request.URL, request.Payload, request.Headers...)
+}
+
+// validatePipeline - names are unique, dependencies exist and there are no cycles
+func validatePipeline(steps []Step) error {
+	byName := map[string]Step{}
+	for _, step := range steps {
+		if step.Name == "" || step.Request == nil {
+			return fmt.Errorf("pipeline step needs a name and a request")
+		}
+		if _, ok := byName[step.Name]; ok {
+			return fmt.Errorf("duplicated pipeline step [%s]", step.Name)
+		}
+		byName[step.Name] = step
+	}
+
+	// 1 visiting, 2 visited
+	state := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("pipeline cycle through step [%s]", name)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, dependency := range byName[name].DependsOn {
+			if _, ok := byName[dependency]; !ok {
+				return fmt.Errorf("pipeline step [%s] depends on unknown step [%s]", name, dependency)
+			}
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		return nil
+	}
+	for _, step := range steps {
+		if err := visit(step.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
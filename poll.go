@@ -0,0 +1,107 @@
+package client_http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PollOptions - settings of Poll, all fields are optional
+type PollOptions struct {
+	Headers []HeaderParameters
+	// Interval - wait between requests, zero requests again right away (long polling)
+	Interval time.Duration
+	// MaxBackoff - cap of the wait after failed requests, doubled from Interval (1s when
+	// Interval is zero) on each consecutive failure. 1 minute by default
+	MaxBackoff time.Duration
+	// OnError - called with failed requests and non-2xx responses before backing off
+	OnError func(err error)
+}
+
+// PollHandler - called with every changed response, returning an error stops polling
+type PollHandler func(response *Response) error
+
+// Poll - request url repeatedly calling handler with every 2xx response. ETag and
+// Last-Modified of the last response are sent as If-None-Match and If-Modified-Since so
+// unchanged content (304) is skipped. It runs until ctx is done (returning its error)
+// or handler fails
+func (c *Client) Poll(ctx context.Context, url string, opts *PollOptions, handler PollHandler) error {
+	if opts == nil {
+		opts = &PollOptions{}
+	}
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = time.Minute
+	}
+
+	var (
+		etag         string
+		lastModified string
+		failures     int
+	)
+	for {
+		headers := append([]HeaderParameters{}, opts.Headers...)
+		if etag != "" {
+			headers = append(headers, HeaderParameters{Key: "If-None-Match", Value: etag})
+		}
+		if lastModified != "" {
+			headers = append(headers, HeaderParameters{Key: "If-Modified-Since", Value: lastModified})
+		}
+
+		response, err := c.Get(ctx, url, headers...)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// not modified is expected even when WithFailOnErrorStatus is enabled
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotModified {
+			response, err = &Response{StatusCode: http.StatusNotModified, Status: httpErr.Status, Headers: httpErr.Headers}, nil
+		}
+
+		switch {
+		case err == nil && response.StatusCode == http.StatusNotModified:
+			failures = 0
+		case err == nil && isSuccess(response.StatusCode):
+			failures = 0
+			etag = response.Header("ETag")
+			lastModified = response.Header("Last-Modified")
+			if err := handler(response); err != nil {
+				return err
+			}
+		default:
+			failures++
+			if err == nil {
+				err = fmt.Errorf("poll of [%s] returned status [%s]", url, response.Status)
+			}
+			if opts.OnError != nil {
+				opts.OnError(err)
+			}
+		}
+
+		if err := sleep(ctx, pollDelay(opts.Interval, maxBackoff, failures)); err != nil {
+			return err
+		}
+	}
+}
+
+// pollDelay - wait before the next request after failures consecutive failures
+func pollDelay(interval, maxBackoff time.Duration, failures int) time.Duration {
+	if failures == 0 {
+		return interval
+	}
+
+	delay := interval
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
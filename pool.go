@@ -0,0 +1,92 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// WithHostMaxConns - max connections (dialing, active and idle) to host, overriding
+// WithMaxConns for it. Requests wait for a free connection when the limit is reached
+func WithHostMaxConns(host string, maxConns int) Option {
+	return func(c *Client) error {
+		if host == "" || maxConns <= 0 {
+			return fmt.Errorf("invalid max connections [%d] for host [%s]", maxConns, host)
+		}
+		if c.hostConnLimits == nil {
+			c.hostConnLimits = map[string]chan struct{}{}
+		}
+		c.hostConnLimits[strings.ToLower(host)] = make(chan struct{}, maxConns)
+		return nil
+	}
+}
+
+// CloseIdleConnections - close the idle connections of the pool, connections in use
+// are not interrupted. Call it on shutdown to release sockets
+func (c *Client) CloseIdleConnections() {
+	c.Instance.CloseIdleConnections()
+}
+
+// acquireConn - wait for a free connection slot of the host in addr, the returned
+// function gives it back. It is nil for hosts without limit
+func (c *Client) acquireConn(ctx context.Context, addr string) (func(), error) {
+	if len(c.hostConnLimits) == 0 {
+		return nil, nil
+	}
+
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	slots, ok := c.hostConnLimits[strings.ToLower(host)]
+	if !ok {
+		return nil, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-slots })
+	}, nil
+}
+
+// poolCounters - connection counters of the client, updated atomically
+type poolCounters struct {
+	open   int64
+	active int64
+	dialed int64
+}
+
+// stats - snapshot of the counters
+func (p *poolCounters) stats() PoolStats {
+	stats := PoolStats{
+		Open:   int(atomic.LoadInt64(&p.open)),
+		Active: int(atomic.LoadInt64(&p.active)),
+		Dialed: atomic.LoadInt64(&p.dialed),
+	}
+	if stats.Idle = stats.Open - stats.Active; stats.Idle < 0 {
+		stats.Idle = 0
+	}
+	return stats
+}
+
+// trackedConn - connection counted as open until closed
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+// Close - close the connection and run onClose once
+func (t *trackedConn) Close() error {
+	defer t.once.Do(t.onClose)
+	return t.Conn.Close()
+}
@@ -0,0 +1,34 @@
+package client_http
+
+import (
+	"context"
+	"net/http"
+)
+
+// Priority - order of the requests waiting for a slot of a host when its concurrency
+// limit is reached (WithBulkhead, WithAdaptiveConcurrency). Higher priorities are sent
+// first, requests of the same priority in arrival order
+type Priority int
+
+const (
+	// PriorityBackground - batch and background jobs
+	PriorityBackground Priority = -10
+	// PriorityNormal - requests without priority
+	PriorityNormal Priority = 0
+	// PriorityHigh - user facing requests
+	PriorityHigh Priority = 10
+)
+
+// priorityKey - context key of the request priority
+type priorityKey struct{}
+
+// MarkPriority - mark ctx so requests using it wait for a slot with priority
+func MarkPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// requestPriority - priority of request, PriorityNormal when not marked
+func requestPriority(request *http.Request) Priority {
+	priority, _ := request.Context().Value(priorityKey{}).(Priority)
+	return priority
+}
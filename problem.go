@@ -0,0 +1,95 @@
+package client_http
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+)
+
+// contentTypeProblem - media type of RFC 7807 problem details
+const contentTypeProblem = "application/problem+json"
+
+// ProblemDetails - RFC 7807 error of an application/problem+json response. HTTPError
+// unwraps to it, errors.As(err, &problem) gives access to the problem of an error status
+type ProblemDetails struct {
+	// Type - uri identifying the problem type, "about:blank" when not sent
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+	// Extensions - members other than the standard ones (errors, trace_id...)
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// Error - status, title and detail of the problem
+func (p *ProblemDetails) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("problem [%s] status [%d] - [%s]", p.Type, p.Status, p.Title)
+	}
+	return fmt.Sprintf("problem [%s] status [%d] - [%s] [%s]", p.Type, p.Status, p.Title, p.Detail)
+}
+
+// Extension - decode the extension member name into v, false when it was not sent
+func (p *ProblemDetails) Extension(name string, v interface{}) (bool, error) {
+	value, ok := p.Extensions[name]
+	if !ok {
+		return false, nil
+	}
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		return true, fmt.Errorf("error decoding problem extension [%s] - [%w]", name, err)
+	}
+	return true, nil
+}
+
+// UnmarshalJSON - decode the standard members and keep the others as extensions
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	type standard ProblemDetails
+	var s standard
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	var members map[string]interface{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	for _, name := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(members, name)
+	}
+
+	*p = ProblemDetails(s)
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if len(members) > 0 {
+		p.Extensions = members
+	}
+	return nil
+}
+
+// Problem - problem details of an application/problem+json response, false for other
+// responses or invalid documents
+func (r *Response) Problem() (*ProblemDetails, bool) {
+	return parseProblem(r.ContentType(), r.StatusCode, r.Body)
+}
+
+// parseProblem - problem details of a body of contentType, the status of the response is
+// used when the document does not have one
+func parseProblem(contentType string, statusCode int, body []byte) (*ProblemDetails, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != contentTypeProblem {
+		return nil, false
+	}
+	problem := &ProblemDetails{}
+	if err := json.Unmarshal(body, problem); err != nil {
+		return nil, false
+	}
+	if problem.Status == 0 {
+		problem.Status = statusCode
+	}
+	return problem, true
+}
@@ -0,0 +1,121 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HostProfile - settings of the requests to one host, they take precedence over the
+// client ones so a single client can talk to APIs with different requirements
+type HostProfile struct {
+	// Timeout - max duration of each attempt, including reading the body. Zero keeps the
+	// client timeout, the client timeout still applies when it is shorter
+	Timeout time.Duration
+	// Retry - retry configuration of the host, nil keeps the client one
+	Retry *RetryConfig
+	// RateLimit - requests per second to the host with bursts of up to Burst requests,
+	// zero means no limit besides the client ones
+	RateLimit float64
+	Burst     int
+	// TokenSource - provides the Authorization token of the host, used instead of the
+	// client credentials
+	TokenSource TokenSource
+	// Headers - headers of every request to the host unless set by the call, they take
+	// precedence over WithDefaultHeaders
+	Headers []HeaderParameters
+}
+
+// hostProfile - validated HostProfile
+type hostProfile struct {
+	HostProfile
+	bucket *tokenBucket
+}
+
+// WithHostProfile - use profile for the requests to host. A host with port only matches
+// that port, the profile of a host without port matches every port
+func WithHostProfile(host string, profile HostProfile) Option {
+	return func(c *Client) error {
+		if host == "" {
+			return fmt.Errorf("host profile host can't be empty")
+		}
+		if profile.Timeout < 0 {
+			return fmt.Errorf("invalid host profile timeout [%v]", profile.Timeout)
+		}
+
+		p := &hostProfile{HostProfile: profile}
+		if profile.RateLimit != 0 {
+			if err := validateRate(profile.RateLimit, profile.Burst); err != nil {
+				return err
+			}
+			p.bucket = newTokenBucket(profile.RateLimit, profile.Burst)
+		}
+		if profile.Retry != nil {
+			retry := *profile.Retry
+			p.Retry = &retry
+		}
+
+		if c.profiles == nil {
+			c.profiles = map[string]*hostProfile{}
+			c.internalMiddlewares = append(c.internalMiddlewares, c.profileMiddleware)
+		}
+		c.profiles[strings.ToLower(host)] = p
+		return nil
+	}
+}
+
+// profile - profile of the request host, nil when there is none
+func (c *Client) profile(request *http.Request) *hostProfile {
+	if len(c.profiles) == 0 {
+		return nil
+	}
+	host := strings.ToLower(request.URL.Host)
+	if p, ok := c.profiles[host]; ok {
+		return p
+	}
+	return c.profiles[strings.ToLower(request.URL.Hostname())]
+}
+
+// apply - set the profile headers and credentials of request, before the client ones
+func (p *hostProfile) apply(request *http.Request) error {
+	applyMissingHeaders(request.Header, p.Headers)
+
+	if p.TokenSource != nil && request.Header.Get("Authorization") == "" {
+		token, err := p.TokenSource.Token(request.Context())
+		if err != nil {
+			return fmt.Errorf("error getting authorization token [%w]", err)
+		}
+		request.Header.Set("Authorization", token.authorization())
+	}
+	return nil
+}
+
+// profileMiddleware - throttle and time out the attempts to hosts with a profile
+func (c *Client) profileMiddleware(next RoundTripFunc) RoundTripFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		p := c.profile(request)
+		if p == nil {
+			return next(request)
+		}
+
+		if p.bucket != nil {
+			if err := p.bucket.wait(request); err != nil {
+				return nil, fmt.Errorf("rate limit wait cancelled [%w]", err)
+			}
+		}
+		if p.Timeout == 0 {
+			return next(request)
+		}
+
+		ctx, cancel := context.WithTimeout(request.Context(), p.Timeout)
+		response, err := next(request.WithContext(ctx))
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		response.Body = &cancelBody{ReadCloser: response.Body, cancel: cancel}
+		return response, nil
+	}
+}
@@ -0,0 +1,190 @@
+package client_http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceFormat - trace headers written by WithTracePropagation
+type TraceFormat int
+
+const (
+	// TraceW3C - traceparent and tracestate headers (W3C Trace Context)
+	TraceW3C TraceFormat = iota
+	// TraceB3 - X-B3-TraceId, X-B3-SpanId, X-B3-ParentSpanId and X-B3-Sampled headers
+	TraceB3
+	// TraceB3Single - single b3 header
+	TraceB3Single
+)
+
+// traceContext - trace of an incoming request
+type traceContext struct {
+	traceID string
+	spanID  string
+	// sampled - "1", "0" or empty when the caller did not decide
+	sampled string
+	// state - tracestate of W3C headers
+	state string
+}
+
+// traceKey - context key of the incoming trace
+type traceKey struct{}
+
+// ExtractTrace - mark ctx with the trace of the incoming request headers (traceparent,
+// b3 or X-B3-*), so WithTracePropagation continues it on the outgoing requests using ctx.
+// ctx is returned unchanged when headers have no valid trace
+func ExtractTrace(ctx context.Context, headers http.Header) context.Context {
+	if tc, ok := parseTraceHeaders(headers); ok {
+		return context.WithValue(ctx, traceKey{}, tc)
+	}
+	return ctx
+}
+
+// WithTracePropagation - send the trace extracted with ExtractTrace on every request in
+// formats (W3C when none), as a child span with a new span id. For teams not using
+// OpenTelemetry, see clienthttpotel otherwise. Trace headers set by the call are kept.
+// The sampling decision of the incoming trace is kept, W3C can't express a deferred
+// decision (B3 without sampling state) so traceparent is not sent for those traces
+func WithTracePropagation(formats ...TraceFormat) Option {
+	return func(c *Client) error {
+		if len(formats) == 0 {
+			formats = []TraceFormat{TraceW3C}
+		}
+		for _, format := range formats {
+			if format < TraceW3C || format > TraceB3Single {
+				return fmt.Errorf("invalid trace format [%d]", format)
+			}
+		}
+		c.traceFormats = formats
+		return nil
+	}
+}
+
+// propagateTrace - write the trace of the request context in the configured formats
+func (c *Client) propagateTrace(request *http.Request) {
+	if len(c.traceFormats) == 0 {
+		return
+	}
+	tc, ok := request.Context().Value(traceKey{}).(traceContext)
+	if !ok {
+		return
+	}
+	if _, present := parseTraceHeaders(request.Header); present {
+		return
+	}
+
+	spanID := randomHex(8)
+	for _, format := range c.traceFormats {
+		switch format {
+		case TraceW3C:
+			// flags 00 would turn a deferred decision into not sampled
+			if tc.sampled == "" {
+				continue
+			}
+			flags := "00"
+			if tc.sampled == "1" {
+				flags = "01"
+			}
+			request.Header.Set("traceparent", "00-"+padTraceID(tc.traceID)+"-"+spanID+"-"+flags)
+			if tc.state != "" {
+				request.Header.Set("tracestate", tc.state)
+			}
+		case TraceB3:
+			request.Header.Set("X-B3-TraceId", tc.traceID)
+			request.Header.Set("X-B3-SpanId", spanID)
+			request.Header.Set("X-B3-ParentSpanId", tc.spanID)
+			if tc.sampled != "" {
+				request.Header.Set("X-B3-Sampled", tc.sampled)
+			}
+		case TraceB3Single:
+			// the parent span id is only allowed after the sampling state
+			value := tc.traceID + "-" + spanID
+			if tc.sampled != "" {
+				value += "-" + tc.sampled + "-" + tc.spanID
+			}
+			request.Header.Set("b3", value)
+		}
+	}
+}
+
+// parseTraceHeaders - trace of headers, W3C first then single and multi header B3
+func parseTraceHeaders(headers http.Header) (traceContext, bool) {
+	if parent := headers.Get("traceparent"); parent != "" {
+		parts := strings.Split(strings.TrimSpace(parent), "-")
+		if len(parts) >= 4 && len(parts[0]) == 2 && parts[0] != "ff" &&
+			validTraceID(parts[1], 32) && validTraceID(parts[2], 16) && isHex(parts[3]) && len(parts[3]) == 2 {
+			flags, _ := hex.DecodeString(parts[3])
+			sampled := "0"
+			if flags[0]&1 == 1 {
+				sampled = "1"
+			}
+			return traceContext{traceID: parts[1], spanID: parts[2], sampled: sampled, state: headers.Get("tracestate")}, true
+		}
+	}
+
+	if single := headers.Get("b3"); single != "" {
+		parts := strings.Split(strings.TrimSpace(single), "-")
+		if len(parts) >= 2 && (validTraceID(parts[0], 32) || validTraceID(parts[0], 16)) && validTraceID(parts[1], 16) {
+			tc := traceContext{traceID: parts[0], spanID: parts[1]}
+			if len(parts) >= 3 {
+				tc.sampled = b3Sampled(parts[2])
+			}
+			return tc, true
+		}
+	}
+
+	traceID, spanID := headers.Get("X-B3-TraceId"), headers.Get("X-B3-SpanId")
+	if (validTraceID(traceID, 32) || validTraceID(traceID, 16)) && validTraceID(spanID, 16) {
+		sampled := b3Sampled(headers.Get("X-B3-Sampled"))
+		if headers.Get("X-B3-Flags") == "1" {
+			sampled = "1"
+		}
+		return traceContext{traceID: traceID, spanID: spanID, sampled: sampled}, true
+	}
+	return traceContext{}, false
+}
+
+// b3Sampled - sampling decision of a B3 value, debug means sampled
+func b3Sampled(value string) string {
+	switch strings.ToLower(value) {
+	case "1", "d", "true":
+		return "1"
+	case "0", "false":
+		return "0"
+	}
+	return ""
+}
+
+// validTraceID - id of size hex digits, not all zeros
+func validTraceID(id string, size int) bool {
+	return len(id) == size && isHex(id) && strings.Trim(id, "0") != ""
+}
+
+// isHex - s only has lowercase hex digits
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// padTraceID - 128-bit trace id of a 64-bit B3 one
+func padTraceID(id string) string {
+	if len(id) == 16 {
+		return strings.Repeat("0", 16) + id
+	}
+	return id
+}
+
+// randomHex - n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
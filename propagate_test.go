@@ -0,0 +1,89 @@
+package client_http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestTracePropagation(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+		span    = "[0-9a-f]{16}"
+	)
+
+	tests := []struct {
+		name     string
+		incoming http.Header
+		formats  []client_http.TraceFormat
+		want     map[string]string
+	}{
+		{
+			name:     "w3c sampled",
+			incoming: http.Header{"Traceparent": {"00-" + traceID + "-" + spanID + "-01"}, "Tracestate": {"vendor=1"}},
+			formats:  []client_http.TraceFormat{client_http.TraceW3C},
+			want:     map[string]string{"Traceparent": "00-" + traceID + "-" + span + "-01", "Tracestate": "vendor=1"},
+		},
+		{
+			name:     "w3c not sampled to b3",
+			incoming: http.Header{"Traceparent": {"00-" + traceID + "-" + spanID + "-00"}},
+			formats:  []client_http.TraceFormat{client_http.TraceB3},
+			want:     map[string]string{"X-B3-Traceid": traceID, "X-B3-Spanid": span, "X-B3-Parentspanid": spanID, "X-B3-Sampled": "0"},
+		},
+		{
+			name:     "b3 single sampled",
+			incoming: http.Header{"B3": {traceID + "-" + spanID + "-1"}},
+			formats:  []client_http.TraceFormat{client_http.TraceB3Single, client_http.TraceW3C},
+			want:     map[string]string{"B3": traceID + "-" + span + "-1-" + spanID, "Traceparent": "00-" + traceID + "-" + span + "-01"},
+		},
+		{
+			name:     "b3 single deferred has no parent",
+			incoming: http.Header{"B3": {traceID + "-" + spanID}},
+			formats:  []client_http.TraceFormat{client_http.TraceB3Single},
+			want:     map[string]string{"B3": traceID + "-" + span},
+		},
+		{
+			name:     "b3 deferred is not sent as w3c",
+			incoming: http.Header{"X-B3-Traceid": {traceID}, "X-B3-Spanid": {spanID}},
+			formats:  []client_http.TraceFormat{client_http.TraceW3C, client_http.TraceB3},
+			want:     map[string]string{"Traceparent": "", "X-B3-Traceid": traceID, "X-B3-Sampled": ""},
+		},
+		{
+			name:     "b3 debug is sampled",
+			incoming: http.Header{"X-B3-Traceid": {spanID}, "X-B3-Spanid": {spanID}, "X-B3-Flags": {"1"}},
+			formats:  []client_http.TraceFormat{client_http.TraceW3C},
+			want:     map[string]string{"Traceparent": "00-0000000000000000" + spanID + "-" + span + "-01"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan http.Header, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received <- r.Header.Clone()
+			}))
+			defer server.Close()
+
+			c, err := client_http.NewHttpClient(client_http.WithTracePropagation(tt.formats...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			ctx := client_http.ExtractTrace(context.Background(), tt.incoming)
+			if _, err := c.Get(ctx, server.URL); err != nil {
+				t.Fatal(err)
+			}
+			headers := <-received
+			for name, pattern := range tt.want {
+				if got := headers.Get(name); !regexp.MustCompile("^" + pattern + "$").MatchString(got) {
+					t.Errorf("%s = %q, want %q", name, got, pattern)
+				}
+			}
+		})
+	}
+}
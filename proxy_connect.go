@@ -0,0 +1,25 @@
+//go:build go1.20
+
+package client_http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// rejectProxyConnect - fail https requests whose CONNECT the proxy answers with 407
+// with *ProxyAuthError
+func (c *Client) rejectProxyConnect() {
+	c.transport.OnProxyConnectResponse = func(_ context.Context, proxy *url.URL, connect *http.Request, response *http.Response) error {
+		if response.StatusCode != http.StatusProxyAuthRequired {
+			return nil
+		}
+		return &ProxyAuthError{
+			Proxy:     redactURL(proxy.String()),
+			Challenge: response.Header.Get("Proxy-Authenticate"),
+			// the body is not read, the tunnel connection is dropped
+			Err: c.newHTTPError(connect, &Response{Status: response.Status, StatusCode: response.StatusCode, Headers: response.Header}),
+		}
+	}
+}
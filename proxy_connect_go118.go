@@ -0,0 +1,7 @@
+//go:build !go1.20
+
+package client_http
+
+// rejectProxyConnect - CONNECT responses can't be inspected before go1.20, a rejected
+// CONNECT fails with the error of the transport
+func (c *Client) rejectProxyConnect() {}
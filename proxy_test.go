@@ -0,0 +1,109 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+// newAuthProxy - forward proxy accepting basic credentials user:pass, plain http
+// requests are answered by the proxy and CONNECT is tunneled to the target
+func newAuthProxy(t *testing.T) *httptest.Server {
+	t.Helper()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpwYXNz" {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if r.Method != http.MethodConnect {
+			w.Header().Set("X-Proxied", r.URL.String())
+			return
+		}
+
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			_ = target.Close()
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			_, _ = io.Copy(target, conn)
+			_ = target.Close()
+		}()
+		go func() {
+			_, _ = io.Copy(conn, target)
+			_ = conn.Close()
+		}()
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy
+}
+
+func TestProxyBasicAuth(t *testing.T) {
+	proxy := newAuthProxy(t)
+	target, pool, _ := newTLSServer(t)
+
+	tests := []struct {
+		name     string
+		url      string
+		password string
+		wantErr  bool
+	}{
+		{name: "http", url: "http://example.test/path", password: "pass"},
+		{name: "http rejected", url: "http://example.test/path", password: "wrong", wantErr: true},
+		{name: "https tunnel", url: target.URL, password: "pass"},
+		{name: "https tunnel rejected", url: target.URL, password: "wrong", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := client_http.NewHttpClient(
+				client_http.WithProxyURL(proxy.URL),
+				client_http.WithProxyBasicAuth("user", tt.password),
+				client_http.WithRootCAs(pool),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			response, err := c.Get(context.Background(), tt.url)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if response.StatusCode >= 300 {
+					t.Fatalf("status = %d, want success", response.StatusCode)
+				}
+				return
+			}
+
+			var authErr *client_http.ProxyAuthError
+			if !errors.As(err, &authErr) {
+				t.Fatalf("error = %v, want *ProxyAuthError", err)
+			}
+			if authErr.Challenge != `Basic realm="proxy"` {
+				t.Errorf("challenge = %q", authErr.Challenge)
+			}
+			if u, _ := url.Parse(proxy.URL); authErr.Proxy != u.String() {
+				t.Errorf("proxy = %q, want %q", authErr.Proxy, proxy.URL)
+			}
+			var httpErr *client_http.HTTPError
+			if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusProxyAuthRequired {
+				t.Errorf("error = %v, want a wrapped 407 *HTTPError", err)
+			}
+		})
+	}
+}
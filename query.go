@@ -0,0 +1,63 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// AppendQuery - add query parameters to rawURL keeping the ones already present.
+// Keys with several values are repeated (a=1&a=2), everything is escaped
+func AppendQuery(rawURL string, query url.Values) (string, error) {
+	if len(query) == 0 {
+		return rawURL, nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url [%s] - [%w]", rawURL, err)
+	}
+
+	values := parsed.Query()
+	for key, vs := range query {
+		for _, v := range vs {
+			values.Add(key, v)
+		}
+	}
+	parsed.RawQuery = values.Encode()
+
+	return parsed.String(), nil
+}
+
+// EncodeQuery - query parameters of the fields of struct v tagged `query:"name"`, untagged
+// exported fields use their name and `query:"-"` skips a field. Options after the name:
+// omitempty skips zero values, comma joins slices (a=1,2) instead of repeating the key
+// (a=1&a=2), unix and unixmilli encode times as epoch. Times use the layout of a
+// `layout:"2006-01-02"` tag or RFC 3339, encoding.TextMarshaler values their text
+func EncodeQuery(v interface{}) (url.Values, error) {
+	return encodeValues(v, "query")
+}
+
+// QueryMap - url.Values with a single value per key
+func QueryMap(params map[string]string) url.Values {
+	values := make(url.Values, len(params))
+	for key, v := range params {
+		values.Set(key, v)
+	}
+	return values
+}
+
+// DoWithQuery - execute a request adding query parameters to url
+func (c *Client) DoWithQuery(ctx context.Context, method, url string, query url.Values, payload []byte, headers ...HeaderParameters) (*Response, error) {
+	url, err := AppendQuery(url, query)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(ctx, method, url, payload, headers...)
+}
+
+// GetWithQuery - execute a GET request adding query parameters to url
+func (c *Client) GetWithQuery(ctx context.Context, url string, query url.Values, headers ...HeaderParameters) (*Response, error) {
+	return c.DoWithQuery(ctx, http.MethodGet, url, query, nil, headers...)
+}
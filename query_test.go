@@ -0,0 +1,55 @@
+package client_http_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+type searchQuery struct {
+	Term     string        `query:"q"`
+	Page     int           `query:"page,omitempty"`
+	Tags     []string      `query:"tag"`
+	Fields   []string      `query:"fields,comma"`
+	Since    time.Time     `query:"since,unix"`
+	Day      time.Time     `query:"day" layout:"2006-01-02"`
+	Timeout  time.Duration `query:"timeout"`
+	Addr     net.IP        `query:"addr"`
+	Limit    *int          `query:"limit"`
+	Internal string        `query:"-"`
+	Verbose  bool
+	hidden   string
+}
+
+func TestEncodeQuery(t *testing.T) {
+	day := time.Date(2024, 3, 1, 15, 4, 5, 0, time.UTC)
+	values, err := client_http.EncodeQuery(&searchQuery{
+		Term:     "go http",
+		Tags:     []string{"a", "b"},
+		Fields:   []string{"id", "name"},
+		Since:    day,
+		Day:      day,
+		Timeout:  1500 * time.Millisecond,
+		Addr:     net.ParseIP("10.0.0.1"),
+		Internal: "skipped",
+		Verbose:  true,
+		hidden:   "skipped",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Verbose=true&addr=10.0.0.1&day=2024-03-01&fields=id%2Cname&q=go+http&since=1709305445&tag=a&tag=b&timeout=1.5s"
+	if got := values.Encode(); got != want {
+		t.Fatalf("query\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := client_http.EncodeQuery(map[string]string{"a": "b"}); err == nil {
+		t.Error("EncodeQuery of a map = nil error, want a struct expected")
+	}
+	if values, err := client_http.EncodeQuery((*searchQuery)(nil)); err != nil || len(values) != 0 {
+		t.Errorf("EncodeQuery(nil) = %v %v, want no values", values, err)
+	}
+}
@@ -0,0 +1,305 @@
+package client_http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job - request submitted to the delivery queue
+type Job struct {
+	ID      string
+	Method  string
+	URL     string
+	Payload []byte
+	Headers []HeaderParameters
+	// Attempts - failed deliveries so far
+	Attempts int
+	// NextAttempt - time of the next delivery
+	NextAttempt time.Time
+	// LastError - error of the last failed delivery
+	LastError string
+	CreatedAt time.Time
+}
+
+// DeliveryQueue - settings of WithDeliveryQueue
+type DeliveryQueue struct {
+	// Workers - deliveries in flight, 4 by default
+	Workers int
+	// Store - persistence of the pending jobs, NewMemoryJobStore() by default. Jobs of a
+	// durable store left by a previous process are delivered on start
+	Store JobStore
+	// MaxAttempts - deliveries of a job before it is dead lettered, 10 by default
+	MaxAttempts int
+	// BaseDelay - wait before the first redelivery, doubled on every failure, 1s by default
+	BaseDelay time.Duration
+	// MaxDelay - cap of the wait between deliveries, 10m by default
+	MaxDelay time.Duration
+	// DeadLetter - called with the jobs given up and the error of their last delivery,
+	// after MaxAttempts failures or a 4xx response other than 408 and 429
+	DeadLetter func(job *Job, err error)
+}
+
+// WithDeliveryQueue - deliver the requests of Submit in background with at least once
+// semantics: a job is stored before Submit returns and only removed once a 2xx response
+// is received or it is dead lettered. Failed deliveries are retried with exponential
+// backoff. Workers run until Close, jobs in flight on Close are delivered again later
+func WithDeliveryQueue(config DeliveryQueue) Option {
+	return func(c *Client) error {
+		if config.Workers == 0 {
+			config.Workers = 4
+		}
+		if config.Store == nil {
+			config.Store = NewMemoryJobStore()
+		}
+		if config.MaxAttempts == 0 {
+			config.MaxAttempts = 10
+		}
+		if config.BaseDelay == 0 {
+			config.BaseDelay = time.Second
+		}
+		if config.MaxDelay == 0 {
+			config.MaxDelay = 10 * time.Minute
+		}
+		if config.Workers < 1 || config.MaxAttempts < 1 || config.BaseDelay < 0 || config.MaxDelay < 0 {
+			return fmt.Errorf("invalid delivery queue [%d] workers - [%d] attempts", config.Workers, config.MaxAttempts)
+		}
+
+		c.queue = &deliveryQueue{config: config, client: c, wake: make(chan struct{}, 1), queued: map[string]bool{}}
+		c.background = append(c.background, c.queue.run)
+		return nil
+	}
+}
+
+// Submit - store a request for background delivery, see WithDeliveryQueue. It returns
+// the id of the job, or ErrClientClosed once the client is closed
+func (c *Client) Submit(ctx context.Context, method, url string, payload []byte, headers ...HeaderParameters) (string, error) {
+	if c.queue == nil {
+		return "", fmt.Errorf("delivery queue not configured, use WithDeliveryQueue")
+	}
+	if c.lifetime.Err() != nil {
+		return "", ErrClientClosed
+	}
+	now := time.Now()
+	job := &Job{
+		ID:          randomNonce(),
+		Method:      method,
+		URL:         url,
+		Payload:     payload,
+		Headers:     headers,
+		NextAttempt: now,
+		CreatedAt:   now,
+	}
+	// a job saved while the stored jobs are replayed could be queued twice
+	c.queue.replay.RLock()
+	defer c.queue.replay.RUnlock()
+	if err := c.queue.config.Store.Save(ctx, job); err != nil {
+		return "", fmt.Errorf("error storing job [%w]", err)
+	}
+	c.queue.add(job)
+	return job.ID, nil
+}
+
+// deliveryQueue - pending jobs ordered by next attempt
+type deliveryQueue struct {
+	config DeliveryQueue
+	client *Client
+	// wake - signals the dispatcher that the pending jobs changed
+	wake chan struct{}
+	// replay - held by Submit while saving and queueing a job, and by run while
+	// loading the stored jobs
+	replay sync.RWMutex
+
+	mu      sync.Mutex
+	pending []*Job
+	// queued - ids of the jobs pending or in delivery
+	queued map[string]bool
+}
+
+// add - queue a new job unless a job with its id is already queued, the stored jobs
+// replayed on start include the jobs submitted before
+func (q *deliveryQueue) add(job *Job) {
+	q.mu.Lock()
+	if q.queued[job.ID] {
+		q.mu.Unlock()
+		return
+	}
+	q.queued[job.ID] = true
+	q.mu.Unlock()
+	q.push(job)
+}
+
+// finish - forget the job with id once delivered or dead lettered
+func (q *deliveryQueue) finish(id string) {
+	q.mu.Lock()
+	delete(q.queued, id)
+	q.mu.Unlock()
+}
+
+// push - add a queued job to the pending ones
+func (q *deliveryQueue) push(job *Job) {
+	q.mu.Lock()
+	i := sort.Search(len(q.pending), func(i int) bool { return q.pending[i].NextAttempt.After(job.NextAttempt) })
+	q.pending = append(q.pending, nil)
+	copy(q.pending[i+1:], q.pending[i:])
+	q.pending[i] = job
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// first - job with the earliest next attempt, nil when there are none
+func (q *deliveryQueue) first() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return nil
+	}
+	return q.pending[0]
+}
+
+// remove - drop job from the pending ones
+func (q *deliveryQueue) remove(job *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, pending := range q.pending {
+		if pending == job {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// run - load the stored jobs and hand the due ones to the workers until ctx is done
+func (q *deliveryQueue) run(ctx context.Context) {
+	q.replay.Lock()
+	stored, err := q.config.Store.List(ctx)
+	if err != nil {
+		q.client.logger.Log(LevelError, "error loading stored jobs", map[string]interface{}{"error": err.Error()})
+	}
+	for _, job := range stored {
+		q.add(job)
+	}
+	q.replay.Unlock()
+
+	ready := make(chan *Job)
+	var wg sync.WaitGroup
+	for w := 0; w < q.config.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range ready {
+				q.deliver(ctx, job)
+			}
+		}()
+	}
+	defer func() {
+		close(ready)
+		wg.Wait()
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		job := q.first()
+		var due <-chan time.Time
+		var send chan<- *Job
+		if job != nil {
+			if wait := time.Until(job.NextAttempt); wait > 0 {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(wait)
+				due = timer.C
+			} else {
+				send = ready
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-due:
+		case send <- job:
+			q.remove(job)
+		}
+	}
+}
+
+// deliver - send job, then delete it, schedule it again or dead letter it
+func (q *deliveryQueue) deliver(ctx context.Context, job *Job) {
+	err := q.send(ctx, job)
+	if ctx.Err() != nil {
+		// stopped, the stored job is delivered on the next start
+		return
+	}
+	if err == nil {
+		if err := q.config.Store.Delete(ctx, job.ID); err != nil {
+			q.client.logger.Log(LevelError, "error deleting delivered job", map[string]interface{}{"job": job.ID, "error": err.Error()})
+		}
+		q.finish(job.ID)
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	var httpErr *HTTPError
+	permanent := errors.As(err, &httpErr) && httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 &&
+		httpErr.StatusCode != http.StatusRequestTimeout && httpErr.StatusCode != http.StatusTooManyRequests
+	if permanent || job.Attempts >= q.config.MaxAttempts {
+		q.client.logger.Log(LevelError, "job dead lettered", map[string]interface{}{"job": job.ID, "attempts": job.Attempts, "error": err.Error()})
+		if q.config.DeadLetter != nil {
+			q.config.DeadLetter(job, err)
+		}
+		if err := q.config.Store.Delete(ctx, job.ID); err != nil {
+			q.client.logger.Log(LevelError, "error deleting dead job", map[string]interface{}{"job": job.ID, "error": err.Error()})
+		}
+		q.finish(job.ID)
+		return
+	}
+
+	job.NextAttempt = time.Now().Add(q.backoff(job.Attempts))
+	if err := q.config.Store.Save(ctx, job); err != nil {
+		q.client.logger.Log(LevelError, "error storing job", map[string]interface{}{"job": job.ID, "error": err.Error()})
+	}
+	q.push(job)
+}
+
+// send - deliver job once, non-2xx responses are returned as *HTTPError
+func (q *deliveryQueue) send(ctx context.Context, job *Job) error {
+	c := q.client
+	request, err := c.newRequest(ctx, job.Method, job.URL, job.Payload, job.Headers)
+	if err != nil {
+		return err
+	}
+	response, err := c.execute(request)
+	if err != nil {
+		return err
+	}
+	if !isSuccess(response.StatusCode) {
+		return c.newHTTPError(request, response)
+	}
+	return nil
+}
+
+// backoff - wait before the delivery following the given failed attempts
+func (q *deliveryQueue) backoff(attempts int) time.Duration {
+	delay := float64(q.config.BaseDelay) * math.Pow(2, float64(attempts-1))
+	if delay > float64(q.config.MaxDelay) {
+		delay = float64(q.config.MaxDelay)
+	}
+	delay -= delay * 0.2 * randomFloat()
+	return time.Duration(delay)
+}
@@ -0,0 +1,193 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+// waitFor - poll cond until it holds or the timeout expires
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDeliveryQueueRetriesAndDeadLetters(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	store := client_http.NewMemoryJobStore()
+	dead := make(chan *client_http.Job, 1)
+	c, err := client_http.NewHttpClient(client_http.WithLogger(client_http.NopLogger()), client_http.WithDeliveryQueue(client_http.DeliveryQueue{
+		Store:     store,
+		BaseDelay: time.Millisecond,
+		MaxDelay:  5 * time.Millisecond,
+		DeadLetter: func(job *client_http.Job, err error) {
+			dead <- job
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if _, err := c.Submit(ctx, http.MethodPost, server.URL+"/flaky", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	id, err := c.Submit(ctx, http.MethodPost, server.URL+"/invalid", []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case job := <-dead:
+		if job.ID != id || job.Attempts != 1 {
+			t.Fatalf("dead job = %s after %d attempts, want %s after 1", job.ID, job.Attempts, id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("job not dead lettered")
+	}
+	waitFor(t, 2*time.Second, func() bool {
+		jobs, _ := store.List(ctx)
+		return len(jobs) == 0
+	})
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("flaky endpoint called %d times, want 3", got)
+	}
+}
+
+func TestDeliveryQueueSubmitAfterClose(t *testing.T) {
+	c, err := client_http.NewHttpClient(client_http.WithDeliveryQueue(client_http.DeliveryQueue{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Close()
+
+	_, err = c.Submit(context.Background(), http.MethodPost, "http://127.0.0.1:1/", nil)
+	if !errors.Is(err, client_http.ErrClientClosed) {
+		t.Fatalf("error = %v, want ErrClientClosed", err)
+	}
+}
+
+// racingStore - store whose List waits for a Save, and whose Save waits for a delivery,
+// so the startup replay sees a job still being submitted
+type racingStore struct {
+	*client_http.MemoryJobStore
+	saved     chan struct{}
+	delivered chan struct{}
+	once      sync.Once
+}
+
+func (s *racingStore) Save(ctx context.Context, job *client_http.Job) error {
+	if err := s.MemoryJobStore.Save(ctx, job); err != nil {
+		return err
+	}
+	s.once.Do(func() {
+		close(s.saved)
+		select {
+		case <-s.delivered:
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+	return nil
+}
+
+func (s *racingStore) List(ctx context.Context) ([]*client_http.Job, error) {
+	select {
+	case <-s.saved:
+	case <-time.After(200 * time.Millisecond):
+	}
+	return s.MemoryJobStore.List(ctx)
+}
+
+func TestDeliveryQueueSubmitDuringReplay(t *testing.T) {
+	delivered := make(chan struct{})
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(delivered)
+		}
+	}))
+	defer server.Close()
+
+	store := &racingStore{MemoryJobStore: client_http.NewMemoryJobStore(), saved: make(chan struct{}), delivered: delivered}
+	c, err := client_http.NewHttpClient(client_http.WithDeliveryQueue(client_http.DeliveryQueue{Store: store}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.Submit(context.Background(), http.MethodPost, server.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 2*time.Second, func() bool { return atomic.LoadInt32(&calls) > 0 })
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("job delivered %d times, want 1", got)
+	}
+}
+
+func TestFileJobStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "jobs")
+	store, err := client_http.NewFileJobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	job := &client_http.Job{ID: "job-1", Method: http.MethodPost, URL: "http://example.com", Payload: []byte("payload")}
+	if err := store.Save(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	job.Attempts = 2
+	if err := store.Save(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "job-1" || jobs[0].Attempts != 2 || string(jobs[0].Payload) != "payload" {
+		t.Fatalf("jobs = %+v, want job-1 after 2 attempts", jobs)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("%d files in the job dir, want 1", len(entries))
+	}
+
+	if err := store.Delete(ctx, "job-1"); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, _ := store.List(ctx); len(jobs) != 0 {
+		t.Fatalf("%d jobs after delete, want 0", len(jobs))
+	}
+}
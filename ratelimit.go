@@ -0,0 +1,135 @@
+package client_http
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenBucket - allows rate events per second with bursts of up to burst events
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket - full bucket
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve - take a token and return how long to wait until it is available.
+// Tokens can go negative, so waiting callers are served in order
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel - give back a reserved token that was not used
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+// wait - block until a token is available or the request context is done
+func (b *tokenBucket) wait(request *http.Request) error {
+	delay := b.reserve()
+	if delay == 0 {
+		return nil
+	}
+	if err := sleep(request.Context(), delay); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
+
+// hostBuckets - one token bucket per host
+type hostBuckets struct {
+	rate  float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// bucket - bucket of host, created on first use
+func (h *hostBuckets) bucket(host string) *tokenBucket {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, ok := h.buckets[host]
+	if !ok {
+		b = newTokenBucket(h.rate, h.burst)
+		h.buckets[host] = b
+	}
+	return b
+}
+
+// WithRateLimit - throttle all requests of the client to rps requests per second with
+// bursts of up to burst requests. Retries are throttled too
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) error {
+		if err := validateRate(rps, burst); err != nil {
+			return err
+		}
+		bucket := newTokenBucket(rps, burst)
+		c.internalMiddlewares = append(c.internalMiddlewares, rateLimitMiddleware(func(*http.Request) *tokenBucket {
+			return bucket
+		}))
+		return nil
+	}
+}
+
+// WithPerHostRateLimit - throttle requests of each host separately to rps requests per
+// second with bursts of up to burst requests, can be combined with WithRateLimit
+func WithPerHostRateLimit(rps float64, burst int) Option {
+	return func(c *Client) error {
+		if err := validateRate(rps, burst); err != nil {
+			return err
+		}
+		hosts := &hostBuckets{rate: rps, burst: burst, buckets: map[string]*tokenBucket{}}
+		c.internalMiddlewares = append(c.internalMiddlewares, rateLimitMiddleware(func(request *http.Request) *tokenBucket {
+			return hosts.bucket(request.URL.Host)
+		}))
+		return nil
+	}
+}
+
+// rateLimitMiddleware - wait for a token of the request bucket before sending it
+func rateLimitMiddleware(bucket func(*http.Request) *tokenBucket) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			if err := bucket(request).wait(request); err != nil {
+				return nil, fmt.Errorf("rate limit wait cancelled [%w]", err)
+			}
+			return next(request)
+		}
+	}
+}
+
+// validateRate - rps and burst must be positive
+func validateRate(rps float64, burst int) error {
+	if rps <= 0 || burst < 1 {
+		return fmt.Errorf("invalid rate limit [%v] rps - [%d] burst", rps, burst)
+	}
+	return nil
+}
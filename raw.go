@@ -0,0 +1,28 @@
+package client_http
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// DoRaw - execute a request returning the unread *http.Response, for trailers, protocol
+// upgrades (the body of a 101 response is the connection) and custom streaming. Every
+// status is returned as is, WithFailOnErrorStatus and the error decoder don't apply while
+// retries, middlewares, decompression and WithMaxResponseBytes do. The caller must close
+// the body
+func (c *Client) DoRaw(ctx context.Context, method, url string, body io.Reader, headers ...HeaderParameters) (*http.Response, error) {
+	request, err := c.newStreamRequest(ctx, method, url, body, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.SendRaw(request)
+}
+
+// SendRaw - execute a request built by the caller with the client settings, returning the
+// unread *http.Response as DoRaw
+func (c *Client) SendRaw(request *http.Request) (*http.Response, error) {
+	response, _, err := c.roundTrip(request)
+	return response, err
+}
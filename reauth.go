@@ -0,0 +1,51 @@
+package client_http
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Reauthenticator - called when a request gets 401, it refreshes the credentials and
+// sets them on retry, a copy of the rejected request. Returning an error gives up and
+// the 401 response is returned
+type Reauthenticator func(retry *http.Request, response *http.Response) error
+
+// WithReauthentication - on 401 run reauthenticator and send the request once more
+// with the new credentials. Requests with a body that can't be rewound are not replayed
+func WithReauthentication(reauthenticator Reauthenticator) Option {
+	return func(c *Client) error {
+		if reauthenticator == nil {
+			return fmt.Errorf("reauthenticator can't be nil")
+		}
+		c.internalMiddlewares = append(c.internalMiddlewares, reauthMiddleware(reauthenticator))
+		return nil
+	}
+}
+
+// reauthMiddleware - replay 401 responses once after reauthenticator succeeds
+func reauthMiddleware(reauthenticator Reauthenticator) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			response, err := next(request)
+			if err != nil || response.StatusCode != http.StatusUnauthorized || !rewindable(request) {
+				return response, err
+			}
+
+			retry, err := rewind(request)
+			if err != nil {
+				return response, nil
+			}
+			if err := reauthenticator(retry, response); err != nil {
+				return response, nil
+			}
+
+			// discard the rejected attempt so the connection can be reused
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+			_ = response.Body.Close()
+
+			return next(retry)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package client_http
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// DefaultRequestIDHeader - header of WithRequestID when none is given
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// requestIDKey - context key of the request id
+type requestIDKey struct{}
+
+// MarkRequestID - mark ctx so requests using it are sent with id instead of a generated
+// one, to propagate the id of an incoming request
+func MarkRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom - request id marked in ctx, empty when there is none
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithRequestID - send a request id in header (X-Request-ID when empty) on every call,
+// taken from the context (see MarkRequestID) or generated. Retries keep the id of the
+// call. The id is set on Response, StreamResponse, *HTTPError, errors and request logs
+func WithRequestID(header string) Option {
+	return func(c *Client) error {
+		if header == "" {
+			header = DefaultRequestIDHeader
+		}
+		c.requestIDHeader = http.CanonicalHeaderKey(header)
+		return nil
+	}
+}
+
+// setRequestID - set the request id header unless the call already did
+func (c *Client) setRequestID(request *http.Request) {
+	if c.requestIDHeader == "" || request.Header.Get(c.requestIDHeader) != "" {
+		return
+	}
+	id := RequestIDFrom(request.Context())
+	if id == "" {
+		id = newRequestID()
+	}
+	request.Header.Set(c.requestIDHeader, id)
+}
+
+// requestID - request id of request, empty when WithRequestID is not used
+func (c *Client) requestID(request *http.Request) string {
+	if c.requestIDHeader == "" {
+		return ""
+	}
+	return request.Header.Get(c.requestIDHeader)
+}
+
+// newRequestID - random UUID v4
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,92 @@
+package client_http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type Response struct {
+	Body       []byte
+	Status     string
+	StatusCode int
+	Headers    http.Header
+	// ContentEncoding - encoding the body was sent with before WithDecompression decoded it
+	ContentEncoding string
+	// Timings - duration of the request phases, nil unless WithTimings is used
+	Timings *Timings
+	// RequestID - id sent with the request, empty unless WithRequestID is used
+	RequestID string
+	// Trailers - trailers sent by the server after the body, nil when there are none
+	Trailers http.Header
+
+	// contentLength - length reported by the server, -1 when unknown
+	contentLength int64
+	// decoders - decoders of the client, used by Decode
+	decoders *decoderRegistry
+}
+
+// Timings - duration of the phases of a request, DNS, Connect and TLS are zero when
+// a pooled connection was reused
+type Timings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// FirstByte - time from the start of the request to the first response byte
+	FirstByte time.Duration
+	// Total - time from the start of the request until the body was read
+	Total time.Duration
+	// Reused - the request was sent on a pooled connection
+	Reused bool
+}
+
+// Header - first value of the response header key, empty if not present
+func (r *Response) Header(key string) string {
+	return r.Headers.Get(key)
+}
+
+// ContentType - value of the Content-Type header
+func (r *Response) ContentType() string {
+	return r.Headers.Get("Content-Type")
+}
+
+// ContentLength - length of the body reported by the server, -1 when unknown
+// (chunked or transparently decompressed responses)
+func (r *Response) ContentLength() int64 {
+	return r.contentLength
+}
+
+// String - body as text
+func (r *Response) String() string {
+	return string(r.Body)
+}
+
+// Map - JSON object body as a map, numbers are float64 as with encoding/json
+func (r *Response) Map() (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(r.Body, &m); err != nil {
+		return nil, fmt.Errorf("error decoding json response [%w]", err)
+	}
+	return m, nil
+}
+
+// IsSuccess - 2xx status
+func (r *Response) IsSuccess() bool {
+	return isSuccess(r.StatusCode)
+}
+
+// IsRedirect - 3xx status, returned when redirects are not followed or for 304
+func (r *Response) IsRedirect() bool {
+	return r.StatusCode >= 300 && r.StatusCode <= 399
+}
+
+// IsClientError - 4xx status
+func (r *Response) IsClientError() bool {
+	return r.StatusCode >= 400 && r.StatusCode <= 499
+}
+
+// IsServerError - 5xx status
+func (r *Response) IsServerError() bool {
+	return isServerError(r.StatusCode)
+}
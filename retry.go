@@ -0,0 +1,339 @@
+package client_http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryConfig - retry behaviour of the Client for transient failures
+type RetryConfig struct {
+	// MaxAttempts - total attempts including the first one, 1 or less disables retries
+	MaxAttempts int
+	// BaseDelay - delay before the first retry, doubled on each attempt
+	BaseDelay time.Duration
+	// MaxDelay - cap of the delay between attempts
+	MaxDelay time.Duration
+	// Jitter - fraction (0 to 1) of the delay randomized to avoid retry storms
+	Jitter float64
+	// RetryableStatusCodes - response status codes that trigger a retry
+	RetryableStatusCodes []int
+	// RetryNetworkErrors - retry on timeouts, connection resets and refused connections
+	RetryNetworkErrors bool
+	// RespectRetryAfter - retry 429 and 503 responses with a Retry-After header waiting
+	// the time requested by the server instead of the backoff
+	RespectRetryAfter bool
+	// MaxRetryAfter - longest Retry-After wait accepted, responses asking for more are
+	// returned without retrying. Zero means no cap
+	MaxRetryAfter time.Duration
+	// Policy - decides which attempts are retried instead of RetryableStatusCodes and
+	// RetryNetworkErrors, MaxAttempts still applies
+	Policy RetryPolicy
+	// RetryNonIdempotent - also retry POST, PATCH and other non-idempotent requests. By
+	// default they are only retried when they have an Idempotency-Key header or their
+	// context is marked with MarkIdempotent, so a retry never repeats a side effect
+	RetryNonIdempotent bool
+}
+
+// RetryPolicy - decides if the attempt (1 for the first one) ending with response or err
+// is retried, and after which delay. A zero delay uses Retry-After or the backoff
+type RetryPolicy func(response *http.Response, err error, attempt int) (bool, time.Duration)
+
+// retryKey, idempotentKey - context keys of the per request retry settings
+type (
+	retryKey      struct{}
+	idempotentKey struct{}
+)
+
+// RetryOverride - mark ctx so requests using it retry following config instead of the
+// client configuration
+func RetryOverride(ctx context.Context, config RetryConfig) context.Context {
+	return context.WithValue(ctx, retryKey{}, &config)
+}
+
+// NoRetry - mark ctx so requests using it are sent once
+func NoRetry(ctx context.Context) context.Context {
+	return RetryOverride(ctx, RetryConfig{MaxAttempts: 1})
+}
+
+// MarkIdempotent - mark ctx so non-idempotent requests using it can be retried, see
+// RetryConfig.RetryNonIdempotent
+func MarkIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// DefaultRetryConfig - 3 attempts with exponential backoff from 100ms to 5s on
+// network errors and 429, 502, 503, 504 responses of idempotent requests
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.5,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryNetworkErrors: true,
+		RespectRetryAfter:  true,
+		MaxRetryAfter:      30 * time.Second,
+	}
+}
+
+// WithRetry - retry failed requests following config
+func WithRetry(config RetryConfig) Option {
+	return func(c *Client) error {
+		if config.BaseDelay < 0 || config.MaxDelay < 0 {
+			return fmt.Errorf("invalid retry delays [%v] - [%v]", config.BaseDelay, config.MaxDelay)
+		}
+		if config.Jitter < 0 || config.Jitter > 1 {
+			return fmt.Errorf("invalid retry jitter [%v], must be between 0 and 1", config.Jitter)
+		}
+		c.retry = &config
+		return nil
+	}
+}
+
+// WithRetryAfter - retry 429 and 503 responses waiting the Retry-After time requested by
+// the server, up to maxWait. It enables RespectRetryAfter on the WithRetry configuration,
+// or retries up to 3 attempts when WithRetry is not used
+func WithRetryAfter(maxWait time.Duration) Option {
+	return func(c *Client) error {
+		if maxWait < 0 {
+			return fmt.Errorf("invalid retry after wait [%v]", maxWait)
+		}
+		c.retryAfter = &maxWait
+		return nil
+	}
+}
+
+// retryConfig - effective retry configuration once all options are applied
+func (c *Client) retryConfig() *RetryConfig {
+	if c.retryAfter == nil {
+		return c.retry
+	}
+
+	config := RetryConfig{MaxAttempts: 3}
+	if c.retry != nil {
+		config = *c.retry
+	}
+	config.RespectRetryAfter = true
+	config.MaxRetryAfter = *c.retryAfter
+	return &config
+}
+
+// retryFor - retry configuration of request, nil when it is sent once
+func (c *Client) retryFor(request *http.Request) *RetryConfig {
+	config := c.retry
+	if p := c.profile(request); p != nil && p.Retry != nil {
+		config = p.Retry
+	}
+	if override, ok := request.Context().Value(retryKey{}).(*RetryConfig); ok {
+		config = override
+	}
+	if config == nil || config.MaxAttempts <= 1 {
+		return nil
+	}
+	if !config.RetryNonIdempotent && !idempotent(request) {
+		return nil
+	}
+	return config
+}
+
+// idempotent - request can be sent again without side effects
+func idempotent(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if request.Header.Get("Idempotency-Key") != "" || request.Header.Get("X-Idempotency-Key") != "" {
+		return true
+	}
+	marked, _ := request.Context().Value(idempotentKey{}).(bool)
+	return marked
+}
+
+// retry - true if the attempt result must be retried, with the delay before the next one.
+// False when the server asks to wait longer than MaxRetryAfter
+func (r *RetryConfig) retry(attempt int, response *http.Response, err error) (bool, time.Duration) {
+	if r.Policy != nil {
+		retry, delay := r.Policy(response, err, attempt)
+		if !retry || delay > 0 {
+			return retry, delay
+		}
+	} else if !r.retryable(response, err) {
+		return false, 0
+	}
+	delay, ok := r.delay(attempt, response)
+	return ok, delay
+}
+
+// retryable - true if the attempt result must be retried
+func (r *RetryConfig) retryable(response *http.Response, err error) bool {
+	if err != nil {
+		return r.RetryNetworkErrors && isTransientError(err)
+	}
+	if r.RespectRetryAfter && retryAfterStatus(response.StatusCode) && response.Header.Get("Retry-After") != "" {
+		return true
+	}
+	for _, code := range r.RetryableStatusCodes {
+		if response.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff - delay before the given retry (1 for the first retry)
+func (r *RetryConfig) backoff(retry int) time.Duration {
+	delay := float64(r.BaseDelay) * math.Pow(2, float64(retry-1))
+	if r.MaxDelay > 0 && delay > float64(r.MaxDelay) {
+		delay = float64(r.MaxDelay)
+	}
+	delay -= delay * r.Jitter * randomFloat()
+	return time.Duration(delay)
+}
+
+// delay - wait before the given retry (1 for the first retry), false when the server
+// asks to wait longer than MaxRetryAfter
+func (r *RetryConfig) delay(retry int, response *http.Response) (time.Duration, bool) {
+	if r.RespectRetryAfter && response != nil && retryAfterStatus(response.StatusCode) {
+		if wait, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			if r.MaxRetryAfter > 0 && wait > r.MaxRetryAfter {
+				return 0, false
+			}
+			return wait, true
+		}
+	}
+	return r.backoff(retry), true
+}
+
+// retryAfterStatus - status codes where Retry-After is honored
+func retryAfterStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// parseRetryAfter - Retry-After in seconds or as http date, past dates mean no wait
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// isTransientError - network errors worth retrying, caller cancellation is never retried
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// send - do request through the middleware chain retrying transient failures
+func (c *Client) send(request *http.Request) (*http.Response, error) {
+	config := c.retryFor(request)
+	if config == nil {
+		return c.attempt(request, 1)
+	}
+
+	attemptRequest := request
+	for attempt := 1; ; attempt++ {
+		response, err := c.attempt(attemptRequest, attempt)
+		if attempt >= config.MaxAttempts || !rewindable(request) {
+			return response, err
+		}
+
+		retry, delay := config.retry(attempt, response, err)
+		if !retry {
+			return response, err
+		}
+		c.retrying(attemptRequest, attempt, response, err, delay)
+
+		// discard the failed attempt so the connection can be reused
+		if response != nil {
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+			_ = response.Body.Close()
+		}
+
+		if err := sleep(request.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if attemptRequest, err = rewind(request); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rewindable - true if the request body can be sent again
+func rewindable(request *http.Request) bool {
+	return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+// rewind - copy of request with a fresh body for a new attempt
+func rewind(request *http.Request) (*http.Request, error) {
+	clone := request.Clone(request.Context())
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("error rewinding request body [%w]", err)
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// sleep - wait for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var (
+	randomMu     sync.Mutex
+	randomSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// randomFloat - pseudo-random number in [0, 1), safe for concurrent use
+func randomFloat() float64 {
+	randomMu.Lock()
+	defer randomMu.Unlock()
+	return randomSource.Float64()
+}
@@ -0,0 +1,110 @@
+package client_http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+	"github.com/erikwco/client_http/clienthttptest"
+)
+
+func TestRetry(t *testing.T) {
+	config := client_http.RetryConfig{
+		MaxAttempts:          3,
+		BaseDelay:            time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}
+	nonIdempotent := config
+	nonIdempotent.RetryNonIdempotent = true
+
+	tests := []struct {
+		name     string
+		config   client_http.RetryConfig
+		method   string
+		ctx      context.Context
+		headers  []client_http.HeaderParameters
+		attempts int
+	}{
+		{name: "get", config: config, method: http.MethodGet, ctx: context.Background(), attempts: 3},
+		{name: "post", config: config, method: http.MethodPost, ctx: context.Background(), attempts: 1},
+		{name: "patch", config: config, method: http.MethodPatch, ctx: context.Background(), attempts: 1},
+		{
+			name: "post with idempotency key", config: config, method: http.MethodPost, ctx: context.Background(),
+			headers: []client_http.HeaderParameters{{Key: "Idempotency-Key", Value: "k1"}}, attempts: 3,
+		},
+		{name: "post marked idempotent", config: config, method: http.MethodPost, ctx: client_http.MarkIdempotent(context.Background()), attempts: 3},
+		{name: "post with RetryNonIdempotent", config: nonIdempotent, method: http.MethodPost, ctx: context.Background(), attempts: 3},
+		{name: "no retry", config: config, method: http.MethodGet, ctx: client_http.NoRetry(context.Background()), attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := clienthttptest.NewMockTransport()
+			transport.On(tt.method, "http://api.test/items").ReplyString(http.StatusServiceUnavailable, "unavailable")
+
+			c, err := clienthttptest.NewClient(transport, client_http.WithRetry(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			response, err := c.Do(tt.ctx, tt.method, "http://api.test/items", []byte(`{}`), tt.headers...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != http.StatusServiceUnavailable {
+				t.Fatalf("status = %d, want 503", response.StatusCode)
+			}
+			if got := transport.CallCount(tt.method, "http://api.test/items"); got != tt.attempts {
+				t.Fatalf("%d attempts, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/long" {
+			atomic.AddInt32(&attempts, 1)
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithRetryAfter(2 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	start := time.Now()
+	response, err := c.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusOK || atomic.LoadInt32(&attempts) != 2 {
+		t.Fatalf("status = %d after %d attempts, want 200 after 2", response.StatusCode, atomic.LoadInt32(&attempts))
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("retried after %v, want the 1s Retry-After", elapsed)
+	}
+
+	atomic.StoreInt32(&attempts, 0)
+	response, err = c.Get(context.Background(), server.URL+"/long")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusTooManyRequests || atomic.LoadInt32(&attempts) != 1 {
+		t.Fatalf("status = %d after %d attempts, want 429 without retry beyond the 2s cap", response.StatusCode, atomic.LoadInt32(&attempts))
+	}
+}
@@ -0,0 +1,153 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Schedule - send request at the given time in background, a past time sends it right
+// away. Cancel ctx or Close the client to abort it, the future then gets the error.
+// Scheduling on a closed client gets ErrClientClosed
+func (c *Client) Schedule(ctx context.Context, request BatchRequest, at time.Time) *Future {
+	f := &Future{done: make(chan struct{})}
+	ctx, cancel := c.scheduleContext(ctx)
+
+	err := c.goBackground(func() {
+		defer cancel()
+		defer close(f.done)
+		if f.err = sleep(ctx, time.Until(at)); f.err != nil {
+			return
+		}
+		f.response, f.err = c.Do(ctx, request.Method, request.URL, request.Payload, request.Headers...)
+	})
+	if err != nil {
+		cancel()
+		f.err = err
+		close(f.done)
+	}
+	return f
+}
+
+// ScheduleCron - send request on every time of the cron expression spec (see ParseCron)
+// in the local time zone, calling handler with every outcome. Runs never overlap, a run
+// still in flight when the next one is due delays it. It runs until ctx is done, Stop
+// is called or the client is closed, ErrClientClosed is returned once it is closed
+func (c *Client) ScheduleCron(ctx context.Context, spec string, request BatchRequest, handler func(response *Response, err error)) (*ScheduledJob, error) {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.scheduleContext(ctx)
+	job := &ScheduledJob{spec: spec, cancel: cancel, done: make(chan struct{})}
+
+	err = c.goBackground(func() {
+		defer cancel()
+		defer close(job.done)
+		for {
+			next := schedule.Next(time.Now())
+			if next.IsZero() {
+				c.logger.Log(LevelError, "cron schedule has no next run", map[string]interface{}{"spec": spec})
+				return
+			}
+			job.scheduled(next)
+			if err := sleep(ctx, time.Until(next)); err != nil {
+				return
+			}
+
+			response, err := c.Do(ctx, request.Method, request.URL, request.Payload, request.Headers...)
+			if ctx.Err() != nil {
+				return
+			}
+			job.ran(err)
+			if err != nil {
+				c.logger.Log(LevelError, "scheduled request failed", map[string]interface{}{"spec": spec, "url": c.redactURL(request.URL), "error": err.Error()})
+			}
+			if handler != nil {
+				handler(response, err)
+			}
+		}
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return job, nil
+}
+
+// scheduleContext - ctx also cancelled when the client is closed
+func (c *Client) scheduleContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-c.lifetime.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// ScheduledJob - recurring request of ScheduleCron
+type ScheduledJob struct {
+	spec   string
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu    sync.Mutex
+	stats ScheduleStats
+}
+
+// ScheduleStats - runs of a ScheduledJob
+type ScheduleStats struct {
+	Runs     int
+	Failures int
+	LastRun  time.Time
+	// LastError - error of the last run, nil when it succeeded
+	LastError error
+	NextRun   time.Time
+}
+
+// Stop - cancel the job, a run in flight is aborted
+func (j *ScheduledJob) Stop() {
+	j.cancel()
+	<-j.done
+}
+
+// Done - closed once the job stopped
+func (j *ScheduledJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Stats - snapshot of the runs of the job
+func (j *ScheduledJob) Stats() ScheduleStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.stats
+}
+
+// String - cron expression of the job
+func (j *ScheduledJob) String() string {
+	return fmt.Sprintf("scheduled job [%s]", j.spec)
+}
+
+// scheduled - record the time of the next run
+func (j *ScheduledJob) scheduled(next time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.stats.NextRun = next
+}
+
+// ran - record the outcome of a run
+func (j *ScheduledJob) ran(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.stats.Runs++
+	if err != nil {
+		j.stats.Failures++
+	}
+	j.stats.LastRun = time.Now()
+	j.stats.LastError = err
+}
@@ -0,0 +1,135 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestSchedule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	start := time.Now()
+	future := c.Schedule(context.Background(), client_http.BatchRequest{Method: http.MethodGet, URL: server.URL}, start.Add(50*time.Millisecond))
+	response, err := future.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", response.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("sent after %v, want at least 50ms", elapsed)
+	}
+}
+
+func TestScheduleAbortedByClose(t *testing.T) {
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	future := c.Schedule(context.Background(), client_http.BatchRequest{Method: http.MethodGet, URL: "http://127.0.0.1:1/"}, time.Now().Add(time.Hour))
+	_ = c.Close()
+	if _, err := future.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+}
+
+func TestScheduleAfterClose(t *testing.T) {
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Close()
+
+	request := client_http.BatchRequest{Method: http.MethodGet, URL: "http://127.0.0.1:1/"}
+	if _, err := c.Schedule(context.Background(), request, time.Now()).Wait(); !errors.Is(err, client_http.ErrClientClosed) {
+		t.Fatalf("Schedule error = %v, want ErrClientClosed", err)
+	}
+	if _, err := c.ScheduleCron(context.Background(), "@every 1s", request, nil); !errors.Is(err, client_http.ErrClientClosed) {
+		t.Fatalf("ScheduleCron error = %v, want ErrClientClosed", err)
+	}
+}
+
+func TestScheduleCron(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient(client_http.WithFailOnErrorStatus(), client_http.WithLogger(client_http.NopLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var mu sync.Mutex
+	var outcomes []error
+	job, err := c.ScheduleCron(context.Background(), "@every 20ms", client_http.BatchRequest{Method: http.MethodGet, URL: server.URL},
+		func(response *client_http.Response, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			outcomes = append(outcomes, err)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, 2*time.Second, func() bool { return job.Stats().Runs >= 3 })
+	job.Stop()
+
+	stats := job.Stats()
+	if stats.Failures != 1 {
+		t.Fatalf("failures = %d, want 1", stats.Failures)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(outcomes) != stats.Runs || outcomes[0] != nil || outcomes[1] == nil {
+		t.Fatalf("outcomes = %v for %d runs, want the second run failed", outcomes, stats.Runs)
+	}
+	select {
+	case <-job.Done():
+	default:
+		t.Fatal("job not done after Stop")
+	}
+}
+
+func TestScheduleCloseDuringSchedule(t *testing.T) {
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := client_http.BatchRequest{Method: http.MethodGet, URL: "http://127.0.0.1:1/"}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = c.ScheduleCron(context.Background(), "@hourly", request, nil)
+			_ = c.Schedule(context.Background(), request, time.Now().Add(time.Hour))
+		}()
+	}
+	_ = c.Close()
+	wg.Wait()
+}
@@ -0,0 +1,126 @@
+package client_http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// SchemaValidator - checks a JSON document decoded with json.Decoder.UseNumber, see the
+// clienthttpschema module for JSON Schema validators
+type SchemaValidator interface {
+	Validate(document interface{}) error
+}
+
+// SchemaError - 2xx JSON response not matching the schema of its request
+type SchemaError struct {
+	URL        string
+	StatusCode int
+	// Err - violations returned by the validator
+	Err error
+}
+
+// Error - url and violations
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("response of [%s] does not match its schema [%v]", e.URL, e.Err)
+}
+
+// Unwrap - violations returned by the validator
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// schemaRoute - validator of the responses of the requests matching method and pattern
+type schemaRoute struct {
+	// method - empty for any method
+	method    string
+	pattern   string
+	validator SchemaValidator
+}
+
+// schemaKey - context key of the request schema
+type schemaKey struct{}
+
+// MarkSchema - mark ctx so the 2xx JSON responses of the requests using it are checked by
+// validator, it takes precedence over WithSchema
+func MarkSchema(ctx context.Context, validator SchemaValidator) context.Context {
+	return context.WithValue(ctx, schemaKey{}, validator)
+}
+
+// WithSchema - check the 2xx JSON responses of the requests matching route with
+// validator, failing with *SchemaError, to catch upstream contract drift. route is an
+// optional method and a path.Match pattern of the url path, "GET /users/*" or
+// "/health". The first matching route is used. Checked responses are read in memory
+func WithSchema(route string, validator SchemaValidator) Option {
+	return func(c *Client) error {
+		if validator == nil {
+			return fmt.Errorf("schema validator of route [%s] can't be nil", route)
+		}
+		method, pattern, found := strings.Cut(strings.TrimSpace(route), " ")
+		if !found {
+			method, pattern = "", method
+		}
+		pattern = strings.TrimSpace(pattern)
+		if _, err := path.Match(pattern, "/"); err != nil {
+			return fmt.Errorf("invalid schema route [%s] - [%w]", route, err)
+		}
+		c.schemas = append(c.schemas, schemaRoute{method: strings.ToUpper(method), pattern: pattern, validator: validator})
+		return nil
+	}
+}
+
+// schema - validator of request, nil when it has none
+func (c *Client) schema(request *http.Request) SchemaValidator {
+	if validator, ok := request.Context().Value(schemaKey{}).(SchemaValidator); ok {
+		return validator
+	}
+	for _, route := range c.schemas {
+		if route.method != "" && route.method != request.Method {
+			continue
+		}
+		if matched, _ := path.Match(route.pattern, request.URL.Path); matched {
+			return route.validator
+		}
+	}
+	return nil
+}
+
+// validateSchema - check the body of a 2xx JSON response with the validator of request,
+// the body is replaced by an unread copy
+func (c *Client) validateSchema(request *http.Request, response *http.Response) error {
+	validator := c.schema(request)
+	if validator == nil || !isSuccess(response.StatusCode) || response.StatusCode == http.StatusNoContent ||
+		request.Method == http.MethodHead || !isJSON(response.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("error reading response body [%w]", err)
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return &SchemaError{URL: c.redactURL(request.URL.String()), StatusCode: response.StatusCode, Err: err}
+	}
+	if err := validator.Validate(document); err != nil {
+		return &SchemaError{URL: c.redactURL(request.URL.String()), StatusCode: response.StatusCode, Err: err}
+	}
+	return nil
+}
+
+// isJSON - contentType is application/json or a +json media type
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == contentTypeJSON || strings.HasSuffix(mediaType, "+json"))
+}
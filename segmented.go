@@ -0,0 +1,196 @@
+package client_http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// segment - byte range [start, end] of a file
+type segment struct {
+	start int64
+	end   int64
+}
+
+// probeRanges - size and headers of url when the server accepts byte ranges
+func (c *Client) probeRanges(ctx context.Context, url string, headers []HeaderParameters) (int64, http.Header, bool) {
+	probeHeaders := append(append([]HeaderParameters{}, headers...), HeaderParameters{Key: "Accept-Encoding", Value: "identity"})
+	request, err := c.newRequest(ctx, http.MethodHead, url, nil, probeHeaders)
+	if err != nil {
+		return 0, nil, false
+	}
+
+	response, _, err := c.roundTrip(request)
+	if err != nil {
+		return 0, nil, false
+	}
+	_ = response.Body.Close()
+
+	if !isSuccess(response.StatusCode) || response.ContentLength <= 0 ||
+		!strings.EqualFold(response.Header.Get("Accept-Ranges"), "bytes") {
+		return 0, nil, false
+	}
+
+	return response.ContentLength, response.Header, true
+}
+
+// downloadSegmented - download size bytes of url with parallel range requests, each one
+// written at its offset of the file. The first failing segment cancels the others
+func (c *Client) downloadSegmented(ctx context.Context, url, path string, size int64, headers http.Header, opts *DownloadOptions) (int64, error) {
+	etag := headers.Get("ETag")
+
+	segmentSize := opts.SegmentSize
+	if segmentSize <= 0 {
+		segmentSize = (size + int64(opts.Segments) - 1) / int64(opts.Segments)
+	}
+
+	segments := make(chan segment)
+	go func() {
+		defer close(segments)
+		for start := int64(0); start < size; start += segmentSize {
+			end := start + segmentSize - 1
+			if end >= size {
+				end = size - 1
+			}
+			select {
+			case segments <- segment{start: start, end: end}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return writeFileAtomic(path, opts.FileMode, func(file *os.File) (int64, error) {
+		if err := file.Truncate(size); err != nil {
+			return 0, fmt.Errorf("error allocating file [%s] - [%w]", path, err)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		progress := &sharedProgress{progress: opts.Progress, total: size}
+
+		var (
+			wg       sync.WaitGroup
+			errOnce  sync.Once
+			firstErr error
+		)
+		for w := 0; w < opts.Segments; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for s := range segments {
+					if err := c.downloadSegment(ctx, url, etag, s, file, progress, opts.Headers); err != nil {
+						errOnce.Do(func() {
+							firstErr = err
+							cancel()
+						})
+						// drain so the producer finishes
+						for range segments {
+						}
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if firstErr != nil {
+			return progress.written(), firstErr
+		}
+		if err := ctx.Err(); err != nil {
+			return progress.written(), err
+		}
+		return size, verifyChecksum(file, opts, headers)
+	})
+}
+
+// downloadSegment - request the range of s and write it at its offset of file
+func (c *Client) downloadSegment(ctx context.Context, url, etag string, s segment, file *os.File, progress *sharedProgress, headers []HeaderParameters) error {
+	rangeHeaders := append([]HeaderParameters{}, headers...)
+	rangeHeaders = append(rangeHeaders,
+		HeaderParameters{Key: "Range", Value: fmt.Sprintf("bytes=%d-%d", s.start, s.end)},
+		// ranges are offsets of the stored representation, never compress them
+		HeaderParameters{Key: "Accept-Encoding", Value: "identity"},
+	)
+	if etag != "" {
+		// the server answers 200 with the whole file if it changed since the probe
+		rangeHeaders = append(rangeHeaders, HeaderParameters{Key: "If-Range", Value: etag})
+	}
+
+	request, err := c.newRequest(ctx, http.MethodGet, url, nil, rangeHeaders)
+	if err != nil {
+		return err
+	}
+
+	response, _, err := c.roundTrip(request)
+	if err != nil {
+		return err
+	}
+	defer Defer(func() {
+		_ = response.Body.Close()
+	})
+
+	if response.StatusCode != http.StatusPartialContent {
+		if !isSuccess(response.StatusCode) {
+			return c.streamError(request, response)
+		}
+		return fmt.Errorf("range [%d-%d] of [%s] not honored, status [%s]", s.start, s.end, request.URL, response.Status)
+	}
+
+	expected := s.end - s.start + 1
+	written, err := io.Copy(&offsetWriter{file: file, offset: s.start, progress: progress}, io.LimitReader(response.Body, expected))
+	if err != nil {
+		return fmt.Errorf("error downloading range [%d-%d] - [%w]", s.start, s.end, err)
+	}
+	if written != expected {
+		return fmt.Errorf("incomplete range [%d-%d], [%d] of [%d] bytes", s.start, s.end, written, expected)
+	}
+	return nil
+}
+
+// offsetWriter - writes sequentially into file from offset
+type offsetWriter struct {
+	file     *os.File
+	offset   int64
+	progress *sharedProgress
+}
+
+// Write - write p at the current offset
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.file.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	w.progress.add(int64(n))
+	return n, err
+}
+
+// sharedProgress - progress of several concurrent writers
+type sharedProgress struct {
+	progress ProgressFunc
+	total    int64
+
+	mu          sync.Mutex
+	transferred int64
+}
+
+// add - count n transferred bytes and report progress
+func (p *sharedProgress) add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.transferred += n
+	if p.progress != nil {
+		p.progress(Progress{Transferred: p.transferred, Total: p.total})
+	}
+}
+
+// written - bytes transferred so far
+func (p *sharedProgress) written() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.transferred
+}
@@ -0,0 +1,213 @@
+package client_http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials - access key used to sign requests, SessionToken is set for
+// temporary credentials (STS, instance roles)
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSSigV4Config - AWS Signature Version 4 signing
+type AWSSigV4Config struct {
+	// Region - region of the endpoint, us-east-1
+	Region string
+	// Service - signing name of the service, s3, execute-api, es...
+	Service string
+	// Credentials - static credentials, ignored when CredentialsProvider is set
+	Credentials AWSCredentials
+	// CredentialsProvider - credentials of every request, for rotating credentials
+	CredentialsProvider func(ctx context.Context) (AWSCredentials, error)
+	// UnsignedPayload - do not hash the body, allowed by S3 for large uploads
+	UnsignedPayload bool
+}
+
+const (
+	sigV4Algorithm       = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat      = "20060102T150405Z"
+	sigV4UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// WithAWSSigV4 - sign every request attempt with AWS Signature Version 4, to call S3,
+// API Gateway and other AWS endpoints without the AWS SDK. Signing runs after every
+// other client feature (compression, headers) whatever the order of the options
+func WithAWSSigV4(config AWSSigV4Config) Option {
+	return func(c *Client) error {
+		if config.Region == "" || config.Service == "" {
+			return fmt.Errorf("sigv4 region and service are required")
+		}
+		if config.CredentialsProvider == nil && (config.Credentials.AccessKeyID == "" || config.Credentials.SecretAccessKey == "") {
+			return fmt.Errorf("sigv4 credentials are required")
+		}
+		c.signingMiddlewares = append(c.signingMiddlewares, sigV4Middleware(config))
+		return nil
+	}
+}
+
+// sigV4Middleware - sign a copy of every request
+func sigV4Middleware(config AWSSigV4Config) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			credentials := config.Credentials
+			if config.CredentialsProvider != nil {
+				var err error
+				if credentials, err = config.CredentialsProvider(request.Context()); err != nil {
+					return nil, fmt.Errorf("error getting aws credentials [%w]", err)
+				}
+			}
+
+			payloadHash := sigV4UnsignedPayload
+			if !config.UnsignedPayload {
+				body, err := peekBody(request)
+				if err != nil {
+					return nil, err
+				}
+				payloadHash = sha256Hex(body)
+			}
+
+			request = request.Clone(request.Context())
+			signSigV4(request, config, credentials, payloadHash, time.Now().UTC())
+			return next(request)
+		}
+	}
+}
+
+// signSigV4 - set the date, token and Authorization headers of request
+func signSigV4(request *http.Request, config AWSSigV4Config, credentials AWSCredentials, payloadHash string, now time.Time) {
+	amzDate := now.Format(sigV4TimeFormat)
+	date := amzDate[:8]
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if credentials.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	host := request.Host
+	if host == "" {
+		host = request.URL.Host
+	}
+	host = stripDefaultPort(host, request.URL.Scheme)
+
+	// canonical headers: host, content-type and every x-amz-* header
+	headers := map[string]string{"host": host}
+	for name, values := range request.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[lower] = strings.Join(trimmed, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		sigV4CanonicalURI(request.URL.Path, config.Service != "s3"),
+		sigV4CanonicalQuery(request),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + config.Region + "/" + config.Service + "/aws4_request"
+	stringToSign := sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	key = hmacSHA256(key, config.Region)
+	key = hmacSHA256(key, config.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// sigV4CanonicalURI - path with every segment uri encoded, twice for services other than S3
+func sigV4CanonicalURI(path string, doubleEncode bool) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		s = sigV4Encode(s)
+		if doubleEncode {
+			s = sigV4Encode(s)
+		}
+		segments[i] = s
+	}
+	return strings.Join(segments, "/")
+}
+
+// sigV4CanonicalQuery - query parameters encoded and sorted by name and value
+func sigV4CanonicalQuery(request *http.Request) string {
+	query := request.URL.Query()
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, sigV4Encode(name)+"="+sigV4Encode(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// sigV4Encode - encode every byte except the unreserved characters A-Z a-z 0-9 - _ . ~
+func sigV4Encode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// stripDefaultPort - host without :80 for http and :443 for https
+func stripDefaultPort(host, scheme string) string {
+	if (scheme == "http" && strings.HasSuffix(host, ":80")) || (scheme == "https" && strings.HasSuffix(host, ":443")) {
+		return host[:strings.LastIndexByte(host, ':')]
+	}
+	return host
+}
+
+// sha256Hex - hex encoded sha256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 - hmac of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,135 @@
+package client_http_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+var testAWSCredentials = client_http.AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+// verifySigV4 - error unless request carries a valid signature of its received headers
+// and body, for simple paths
+func verifySigV4(request *http.Request, body []byte, region, service, secret string) error {
+	authorization := request.Header.Get("Authorization")
+	var credential, signedHeaders, signature string
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, "AWS4-HMAC-SHA256 "), ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		switch name {
+		case "Credential":
+			credential = value
+		case "SignedHeaders":
+			signedHeaders = value
+		case "Signature":
+			signature = value
+		}
+	}
+	if signature == "" {
+		return fmt.Errorf("missing signature in [%s]", authorization)
+	}
+
+	hash := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(hash[:])
+	if got := request.Header.Get("X-Amz-Content-Sha256"); got != payloadHash {
+		return fmt.Errorf("payload hash [%s], body hash [%s]", got, payloadHash)
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := request.Header.Get(name)
+		if name == "host" {
+			value = request.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + value + "\n")
+	}
+	query := request.URL.Query()
+	var pairs []string
+	for name := range query {
+		pairs = append(pairs, name+"="+query.Get(name))
+	}
+	sort.Strings(pairs)
+
+	canonicalRequest := strings.Join([]string{request.Method, request.URL.Path, strings.Join(pairs, "&"),
+		canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+	amzDate := request.Header.Get("X-Amz-Date")
+	scope := amzDate[:8] + "/" + region + "/" + service + "/aws4_request"
+	if credential != testAWSCredentials.AccessKeyID+"/"+scope {
+		return fmt.Errorf("credential [%s], scope [%s]", credential, scope)
+	}
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + secret)
+	for _, part := range []string{amzDate[:8], region, service, "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	if want := hex.EncodeToString(key); signature != want {
+		return fmt.Errorf("signature [%s], want [%s]", signature, want)
+	}
+	return nil
+}
+
+func TestAWSSigV4(t *testing.T) {
+	sigV4 := client_http.WithAWSSigV4(client_http.AWSSigV4Config{Region: "eu-west-1", Service: "execute-api", Credentials: testAWSCredentials})
+	tests := []struct {
+		name     string
+		options  []client_http.Option
+		method   string
+		url      string
+		body     []byte
+		encoding string
+	}{
+		{name: "get with query", options: []client_http.Option{sigV4}, method: http.MethodGet, url: "/items?b=2&a=1"},
+		{name: "post", options: []client_http.Option{sigV4}, method: http.MethodPost, url: "/items", body: []byte(`{"id":1}`)},
+		{
+			name:     "compression registered after signing",
+			options:  []client_http.Option{sigV4, client_http.WithRequestCompression(16)},
+			method:   http.MethodPost,
+			url:      "/items",
+			body:     bytes.Repeat([]byte(`{"id":1}`), 64),
+			encoding: "gzip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				if got := r.Header.Get("Content-Encoding"); got != tt.encoding {
+					t.Errorf("Content-Encoding = %q, want %q", got, tt.encoding)
+				}
+				if err := verifySigV4(r, body, "eu-west-1", "execute-api", testAWSCredentials.SecretAccessKey); err != nil {
+					t.Error(err)
+					w.WriteHeader(http.StatusForbidden)
+				}
+			}))
+			defer server.Close()
+
+			c, err := client_http.NewHttpClient(tt.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			response, err := c.Do(context.Background(), tt.method, server.URL+tt.url, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", response.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package client_http
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const soapEnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+
+// SOAPRequest - SOAP 1.1 call, Header and Body are marshalled with encoding/xml
+type SOAPRequest struct {
+	// Action - value of the SOAPAction header
+	Action string
+	// Header - optional header block (WS-Security...)
+	Header interface{}
+	Body   interface{}
+}
+
+// SOAPFault - fault returned by the service
+type SOAPFault struct {
+	Code   string          `xml:"faultcode"`
+	String string          `xml:"faultstring"`
+	Actor  string          `xml:"faultactor"`
+	Detail SOAPFaultDetail `xml:"detail"`
+}
+
+// SOAPFaultDetail - raw xml of the fault detail, decode it with xml.Unmarshal
+type SOAPFaultDetail struct {
+	Content string `xml:",innerxml"`
+}
+
+// Error - fault code and message
+func (f *SOAPFault) Error() string {
+	return fmt.Sprintf("soap fault [%s] - [%s]", f.Code, f.String)
+}
+
+// soapEnvelope - outgoing envelope
+type soapEnvelope struct {
+	XMLName xml.Name    `xml:"soap:Envelope"`
+	Soap    string      `xml:"xmlns:soap,attr"`
+	Header  *soapHeader `xml:"soap:Header,omitempty"`
+	Body    soapBody    `xml:"soap:Body"`
+}
+
+// soapHeader, soapBody - envelope elements wrapping Content, which is marshalled as an
+// element named by its XMLName field or its type name
+type soapHeader struct {
+	Content interface{}
+}
+
+type soapBody struct {
+	Content interface{}
+}
+
+// MarshalXML - write the header element with its content
+func (h soapHeader) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalSOAPElement(e, start, h.Content)
+}
+
+// MarshalXML - write the body element with its content
+func (b soapBody) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalSOAPElement(e, start, b.Content)
+}
+
+// marshalSOAPElement - write start, content and the end of start
+func marshalSOAPElement(e *xml.Encoder, start xml.StartElement, content interface{}) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if content != nil {
+		if err := e.Encode(content); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// soapResponseEnvelope - incoming envelope, matched by local names
+type soapResponseEnvelope struct {
+	Body struct {
+		Fault   *SOAPFault `xml:"Fault"`
+		Content []byte     `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// SOAP - post request wrapped in a SOAP 1.1 envelope and decode the content of the
+// response body into result (can be nil). Faults are returned as *SOAPFault
+func (c *Client) SOAP(ctx context.Context, url string, request SOAPRequest, result interface{}, headers ...HeaderParameters) (*Response, error) {
+	envelope := soapEnvelope{Soap: soapEnvelopeNamespace, Body: soapBody{Content: request.Body}}
+	if request.Header != nil {
+		envelope.Header = &soapHeader{Content: request.Header}
+	}
+
+	payload, err := xml.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding soap envelope [%w]", err)
+	}
+	payload = append([]byte(xml.Header), payload...)
+
+	soapHeaders := []HeaderParameters{
+		{Key: "Content-Type", Value: "text/xml; charset=utf-8"},
+		{Key: "Accept", Value: "text/xml"},
+		{Key: "SOAPAction", Value: `"` + request.Action + `"`},
+	}
+
+	response, err := c.Do(ctx, http.MethodPost, url, payload, append(soapHeaders, headers...)...)
+	if err != nil {
+		// faults come with status 500, keep them when WithFailOnErrorStatus is enabled
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			if fault := parseSOAPFault(httpErr.Body); fault != nil {
+				return nil, fault
+			}
+		}
+		return nil, err
+	}
+
+	var decoded soapResponseEnvelope
+	if err := xml.Unmarshal(response.Body, &decoded); err != nil {
+		if !isSuccess(response.StatusCode) {
+			return response, fmt.Errorf("soap call returned status [%s]", response.Status)
+		}
+		return response, fmt.Errorf("error decoding soap envelope [%w]", err)
+	}
+	if decoded.Body.Fault != nil {
+		return response, decoded.Body.Fault
+	}
+
+	if result != nil && len(bytes.TrimSpace(decoded.Body.Content)) > 0 {
+		if err := xml.Unmarshal(decoded.Body.Content, result); err != nil {
+			return response, fmt.Errorf("error decoding soap body [%w]", err)
+		}
+	}
+
+	return response, nil
+}
+
+// parseSOAPFault - fault of an envelope, nil when there is none
+func parseSOAPFault(body []byte) *SOAPFault {
+	var decoded soapResponseEnvelope
+	if err := xml.Unmarshal(body, &decoded); err != nil {
+		return nil
+	}
+	return decoded.Body.Fault
+}
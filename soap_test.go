@@ -0,0 +1,75 @@
+package client_http_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+type getPrice struct {
+	Item string
+}
+
+type auth struct {
+	XMLName xml.Name `xml:"urn:auth Auth"`
+	Token   string
+}
+
+type getPriceResponse struct {
+	Price float64
+}
+
+func TestSOAP(t *testing.T) {
+	const want = `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">` +
+		`<soap:Header><Auth xmlns="urn:auth"><Token>secret</Token></Auth></soap:Header>` +
+		`<soap:Body><getPrice><Item>apple</Item></getPrice></soap:Body>` +
+		`</soap:Envelope>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != want {
+			t.Errorf("envelope\n%s\nwant\n%s", body, want)
+		}
+		if got := r.Header.Get("SOAPAction"); got != `"urn:GetPrice"` {
+			t.Errorf("SOAPAction = %s", got)
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		if r.URL.Path == "/fault" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+				`<soap:Fault><faultcode>soap:Client</faultcode><faultstring>unknown item</faultstring></soap:Fault></soap:Body></soap:Envelope>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+			`<getPriceResponse><Price>1.5</Price></getPriceResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	request := client_http.SOAPRequest{Action: "urn:GetPrice", Header: auth{Token: "secret"}, Body: getPrice{Item: "apple"}}
+	var result getPriceResponse
+	if _, err := c.SOAP(context.Background(), server.URL, request, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Price != 1.5 {
+		t.Fatalf("price = %v, want 1.5", result.Price)
+	}
+
+	_, err = c.SOAP(context.Background(), server.URL+"/fault", request, &result)
+	var fault *client_http.SOAPFault
+	if !errors.As(err, &fault) || fault.Code != "soap:Client" || fault.String != "unknown item" {
+		t.Fatalf("error = %v, want the soap fault", err)
+	}
+}
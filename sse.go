@@ -0,0 +1,169 @@
+package client_http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSSERetry - reconnection delay until the server sends a retry field
+const defaultSSERetry = 3 * time.Second
+
+// Event - server-sent event
+type Event struct {
+	ID string
+	// Event - event type, message when the server does not set one
+	Event string
+	Data  string
+	// Retry - reconnection delay requested by the server, zero when not sent
+	Retry time.Duration
+}
+
+// EventHandler - called for every event, returning an error ends the subscription
+type EventHandler func(event Event) error
+
+// SubscribeSSE - listen to the server-sent events of url calling handler for each one.
+// The connection is opened again when it drops, waiting the retry delay sent by the server
+// (3s by default) and sending Last-Event-ID so the stream resumes where it stopped. It runs
+// until ctx is done, the handler fails, the server answers 204 No Content (nil error) or
+// another non-2xx status (*HTTPError). The client timeout closes long lived connections,
+// which are then reopened, use WithTimeout(0) to avoid it
+func (c *Client) SubscribeSSE(ctx context.Context, url string, handler EventHandler, headers ...HeaderParameters) error {
+	retry := defaultSSERetry
+	lastEventID := ""
+
+	for {
+		sseHeaders := append([]HeaderParameters{
+			{Key: "Accept", Value: "text/event-stream"},
+			{Key: "Cache-Control", Value: "no-cache"},
+		}, headers...)
+		if lastEventID != "" {
+			sseHeaders = append(sseHeaders, HeaderParameters{Key: "Last-Event-ID", Value: lastEventID})
+		}
+
+		request, err := c.newRequest(ctx, http.MethodGet, url, nil, sseHeaders)
+		if err != nil {
+			return err
+		}
+
+		response, err := c.stream(request)
+		if err == nil {
+			if response.StatusCode == http.StatusNoContent {
+				_ = response.Close()
+				return nil
+			}
+			if !isSuccess(response.StatusCode) {
+				return c.streamHTTPError(request, &http.Response{
+					Status:     response.Status,
+					StatusCode: response.StatusCode,
+					Header:     response.Headers,
+					Body:       response.Body,
+				})
+			}
+
+			err = readEvents(response.Body, func(event Event) error {
+				if event.Retry > 0 {
+					retry = event.Retry
+				}
+				if event.ID != "" {
+					lastEventID = event.ID
+				}
+				if event.Data == "" {
+					return nil
+				}
+				return handler(event)
+			})
+			_ = response.Close()
+
+			var handlerErr *handlerError
+			if errors.As(err, &handlerErr) {
+				return handlerErr.err
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := sleep(ctx, retry); err != nil {
+			return err
+		}
+	}
+}
+
+// handlerError - error returned by the event handler, ends the subscription
+type handlerError struct {
+	err error
+}
+
+// Error - message of the handler error
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+// readEvents - parse the event stream of body calling dispatch for every event and for
+// field only blocks (id, retry). It returns when the stream ends or dispatch fails
+func readEvents(body io.Reader, dispatch func(event Event) error) error {
+	reader := bufio.NewReader(body)
+
+	var (
+		event   Event
+		data    []string
+		pending bool
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		// blank line, dispatch the event
+		if line == "" {
+			if pending {
+				event.Data = strings.Join(data, "\n")
+				if event.Event == "" && event.Data != "" {
+					event.Event = "message"
+				}
+				if dispatchErr := dispatch(event); dispatchErr != nil {
+					return &handlerError{err: dispatchErr}
+				}
+			}
+			event, data, pending = Event{}, nil, false
+			continue
+		}
+
+		// comment
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			event.Event, pending = value, true
+		case "data":
+			data, pending = append(data, value), true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				event.ID, pending = value, true
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				event.Retry, pending = time.Duration(ms)*time.Millisecond, true
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
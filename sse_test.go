@@ -0,0 +1,112 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestSubscribeSSE(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			_, _ = w.Write([]byte(": welcome\n\nretry: 10\n\n" +
+				"id: 1\nevent: update\ndata: first\ndata:  line\n\n" +
+				"event: ping\n\n" +
+				"data: no id\r\n\r\n" +
+				"data: never dispatched"))
+		case 2:
+			if got := r.Header.Get("Last-Event-ID"); got != "1" {
+				t.Errorf("Last-Event-ID = %q, want 1", got)
+			}
+			_, _ = w.Write([]byte("id: 2\ndata: second\n\n"))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var events []client_http.Event
+	start := time.Now()
+	err = c.SubscribeSSE(context.Background(), server.URL, func(event client_http.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []client_http.Event{
+		{ID: "1", Event: "update", Data: "first\n line"},
+		{Event: "message", Data: "no id"},
+		{ID: "2", Event: "message", Data: "second"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+	// reconnected with the 10ms retry sent by the server instead of 3s
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("subscription took %v, want the 10ms retry", elapsed)
+	}
+}
+
+func TestSubscribeSSEHandlerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: one\n\ndata: two\n\n"))
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	stop := errors.New("stop")
+	calls := 0
+	err = c.SubscribeSSE(context.Background(), server.URL, func(event client_http.Event) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Fatalf("error = %v after %d events, want the handler error after 1", err, calls)
+	}
+}
+
+func TestSubscribeSSEStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	c, err := client_http.NewHttpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	err = c.SubscribeSSE(context.Background(), server.URL, func(event client_http.Event) error { return nil })
+	var httpErr *client_http.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("error = %v, want 403 *HTTPError", err)
+	}
+}
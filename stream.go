@@ -0,0 +1,126 @@
+package client_http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// StreamResponse - response whose body is read by the caller instead of being buffered.
+// Body must be closed, WriteTo closes it after copying.
+// The client timeout also applies to reading the body, use WithTimeout(0) and a
+// context deadline for long downloads
+type StreamResponse struct {
+	Body       io.ReadCloser
+	Status     string
+	StatusCode int
+	Headers    http.Header
+	// ContentLength - length reported by the server, -1 when unknown
+	ContentLength int64
+	// ContentEncoding - encoding the body was sent with before WithDecompression decoded it
+	ContentEncoding string
+	// RequestID - id sent with the request, empty unless WithRequestID is used
+	RequestID string
+	// Trailers - trailers announced by the server, their values are only set once Body
+	// was read to the end
+	Trailers http.Header
+
+	// logger - client logger
+	logger Logger
+}
+
+// Header - first value of the response header key, empty if not present
+func (s *StreamResponse) Header(key string) string {
+	return s.Headers.Get(key)
+}
+
+// ContentType - value of the Content-Type header
+func (s *StreamResponse) ContentType() string {
+	return s.Headers.Get("Content-Type")
+}
+
+// Close - close the response body
+func (s *StreamResponse) Close() error {
+	return s.Body.Close()
+}
+
+// WriteTo - copy the body into w and close it
+func (s *StreamResponse) WriteTo(w io.Writer) (int64, error) {
+	defer Defer(s.closeBody)
+
+	written, err := io.Copy(w, s.Body)
+	if err != nil {
+		var tooLarge *ResponseTooLargeError
+		if errors.As(err, &tooLarge) {
+			return written, tooLarge
+		}
+		return written, fmt.Errorf("error reading response body [%w]", err)
+	}
+	return written, nil
+}
+
+// closeBody - close the body logging errors
+func (s *StreamResponse) closeBody() {
+	if err := s.Body.Close(); err != nil && s.logger != nil {
+		s.logger.Log(LevelError, "error closing response body", map[string]interface{}{"error": err})
+	}
+}
+
+// DoStream - execute a request returning the unread response body
+func (c *Client) DoStream(ctx context.Context, method, url string, payload []byte, headers ...HeaderParameters) (*StreamResponse, error) {
+	request, err := c.newRequest(ctx, method, url, payload, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.stream(request)
+}
+
+// GetStream - execute a GET request returning the unread response body
+func (c *Client) GetStream(ctx context.Context, url string, headers ...HeaderParameters) (*StreamResponse, error) {
+	return c.DoStream(ctx, http.MethodGet, url, nil, headers...)
+}
+
+// stream - send request returning the unread response body
+func (c *Client) stream(request *http.Request) (*StreamResponse, error) {
+	response, encoding, err := c.roundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// error status, keep a body snippet and release the connection
+	if c.failOnErrorStatus && !isSuccess(response.StatusCode) {
+		return nil, c.streamError(request, response)
+	}
+
+	// mapped by the error decoder, the snippet is kept in the body otherwise
+	if c.errorDecoder != nil && !isSuccess(response.StatusCode) {
+		snippet, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+		if err := c.errorDecoder(response.StatusCode, response.Header, snippet); err != nil {
+			_ = response.Body.Close()
+			return nil, err
+		}
+		response.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(snippet), response.Body), response.Body}
+	}
+
+	return &StreamResponse{
+		Body:          response.Body,
+		Status:        response.Status,
+		StatusCode:    response.StatusCode,
+		Headers:       response.Header,
+		ContentLength: response.ContentLength,
+
+		ContentEncoding: encoding,
+		RequestID:       c.requestID(request),
+		Trailers:        response.Trailer,
+
+		logger: c.logger,
+	}, nil
+}
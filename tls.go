@@ -0,0 +1,223 @@
+package client_http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithRootCAs - verify servers with pool instead of the system roots.
+// To trust internal CAs on top of the system ones start from x509.SystemCertPool
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *Client) error {
+		if pool == nil {
+			return fmt.Errorf("root CA pool can't be nil")
+		}
+		c.tlsConfig().RootCAs = pool
+		return nil
+	}
+}
+
+// WithRootCAFile - verify servers with the PEM certificates in path, can be used several times
+func WithRootCAFile(path string) Option {
+	return func(c *Client) error {
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading root CA file [%s] - [%w]", path, err)
+		}
+		return c.appendRootCAs(pem)
+	}
+}
+
+// WithRootCAPEM - verify servers with the PEM encoded certificates, can be used several times
+func WithRootCAPEM(pem []byte) Option {
+	return func(c *Client) error {
+		return c.appendRootCAs(pem)
+	}
+}
+
+// WithMinTLSVersion - lowest TLS version accepted, for example tls.VersionTLS12
+func WithMinTLSVersion(version uint16) Option {
+	return func(c *Client) error {
+		if version < tls.VersionTLS10 || version > tls.VersionTLS13 {
+			return fmt.Errorf("invalid TLS version [%#x]", version)
+		}
+		c.tlsConfig().MinVersion = version
+		return nil
+	}
+}
+
+// WithCipherSuites - enabled cipher suites for TLS 1.2 and lower, TLS 1.3 suites are not configurable
+func WithCipherSuites(suites ...uint16) Option {
+	return func(c *Client) error {
+		known := map[uint16]bool{}
+		for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			known[s.ID] = true
+		}
+		for _, s := range suites {
+			if !known[s] {
+				return fmt.Errorf("unknown cipher suite [%#x]", s)
+			}
+		}
+		c.tlsConfig().CipherSuites = suites
+		return nil
+	}
+}
+
+// WithTLSKeyLog - write the TLS session keys of every connection to w in NSS key log
+// format (SSLKEYLOGFILE), so tools like Wireshark can decrypt the traffic. Anyone with
+// the keys can read the traffic, only enable it while debugging
+func WithTLSKeyLog(w io.Writer) Option {
+	return func(c *Client) error {
+		if w == nil {
+			return fmt.Errorf("tls key log writer can't be nil")
+		}
+		c.tlsConfig().KeyLogWriter = w
+		c.logger.Log(LevelWarn, "tls key log enabled, traffic of this client can be decrypted", nil)
+		return nil
+	}
+}
+
+// WithTLSKeyLogFile - append the TLS session keys to the key log file at path,
+// see WithTLSKeyLog. The file is closed by Client.Close
+func WithTLSKeyLogFile(path string) Option {
+	return func(c *Client) error {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("error opening tls key log file [%s] - [%w]", path, err)
+		}
+		keyLog := &keyLogFile{file: file}
+		c.closers = append(c.closers, keyLog)
+		return WithTLSKeyLog(keyLog)(c)
+	}
+}
+
+// keyLogFile - key log file of WithTLSKeyLogFile, keys of connections made after Close
+// are dropped so the handshakes don't fail
+type keyLogFile struct {
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+}
+
+// Write - append the keys to the file
+func (f *keyLogFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return len(p), nil
+	}
+	return f.file.Write(p)
+}
+
+// Close - close the file
+func (f *keyLogFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	return f.file.Close()
+}
+
+// appendRootCAs - add PEM certificates to the root pool of the client
+func (c *Client) appendRootCAs(pem []byte) error {
+	config := c.tlsConfig()
+	if config.RootCAs == nil {
+		config.RootCAs = x509.NewCertPool()
+	}
+	if !config.RootCAs.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no valid certificates found in root CA PEM")
+	}
+	return nil
+}
+
+// WithClientCertificate - present the certificate in certFile and keyFile for mutual TLS.
+// The files are checked on every handshake and reloaded when they change, so
+// rotated certificates are used without restarting
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(c *Client) error {
+		reloader := &certReloader{certFile: certFile, keyFile: keyFile}
+		if _, err := reloader.certificate(); err != nil {
+			return err
+		}
+		config := c.tlsConfig()
+		config.Certificates = nil
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.certificate()
+		}
+		return nil
+	}
+}
+
+// WithClientCertificatePEM - present the PEM encoded certificate and key for mutual TLS
+func WithClientCertificatePEM(certPEM, keyPEM []byte) Option {
+	return func(c *Client) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("error loading client certificate [%w]", err)
+		}
+		config := c.tlsConfig()
+		config.GetClientCertificate = nil
+		config.Certificates = []tls.Certificate{cert}
+		return nil
+	}
+}
+
+// certReloader - client certificate loaded from files, reloaded when they are modified
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modified time.Time
+}
+
+// certificate - current certificate, reloaded if one of the files changed.
+// When a reload fails the previous certificate is kept
+func (r *certReloader) certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modified, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil && r.cert == nil {
+		return nil, err
+	}
+	if r.cert != nil && (err != nil || !modified.After(r.modified)) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("error loading client certificate [%s] - [%w]", r.certFile, err)
+	}
+
+	r.cert = &cert
+	r.modified = modified
+	return r.cert, nil
+}
+
+// latestModTime - most recent modification time of files
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("error reading client certificate [%s] - [%w]", f, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
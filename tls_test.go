@@ -0,0 +1,75 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	client_http "github.com/erikwco/client_http"
+)
+
+func TestTLSKeyLogFile(t *testing.T) {
+	server, pool, _ := newTLSServer(t)
+	path := filepath.Join(t.TempDir(), "keys.log")
+	logs := &logRecorder{}
+
+	c, err := client_http.NewHttpClient(
+		client_http.WithLogger(logs),
+		client_http.WithRootCAs(pool),
+		client_http.WithTLSKeyLogFile(path),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(context.Background(), server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(keys), "CLIENT_") {
+		t.Fatalf("key log = %q, want session keys", keys)
+	}
+	if got := logs.String(); !strings.HasPrefix(got, "warn tls key log enabled") {
+		t.Errorf("logs = %q, want a warning", got)
+	}
+
+	// new connections after Close still work, their keys are not written
+	if _, err := c.Get(context.Background(), server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile(path); len(after) != len(keys) {
+		t.Errorf("key log grew after Close")
+	}
+}
+
+func TestTLSKeyLogFileClosedOnOptionError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.log")
+	failing := func(c *client_http.Client) error { return errors.New("invalid option") }
+
+	before := openFiles(t)
+	if _, err := client_http.NewHttpClient(client_http.WithLogger(client_http.NopLogger()), client_http.WithTLSKeyLogFile(path), failing); err == nil {
+		t.Fatal("error = nil, want the option error")
+	}
+	if after := openFiles(t); after != before {
+		t.Fatalf("%d open files, want %d", after, before)
+	}
+}
+
+// openFiles - file descriptors of the process, skips the test where they can't be listed
+func openFiles(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("open files can't be listed")
+	}
+	return len(entries)
+}
@@ -0,0 +1,107 @@
+package client_http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// connTrace - timestamps of the connection phases of a request attempt
+type connTrace struct {
+	// onGotConn - called once the attempt has a connection
+	onGotConn func(t *connTrace)
+
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+	wasIdle      bool
+	idleTime     time.Duration
+}
+
+// trace - attach a new connTrace to request, hooks already in the context still run
+func trace(request *http.Request, onGotConn func(t *connTrace)) (*http.Request, *connTrace) {
+	t := &connTrace{start: time.Now(), onGotConn: onGotConn}
+	ctx := httptrace.WithClientTrace(request.Context(), &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) },
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// several addresses can be dialed in parallel, keep the first start
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone:       func(string, string, error) { t.mark(&t.connectDone) },
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { t.mark(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.gotConn = time.Now()
+			t.reused = info.Reused
+			t.wasIdle = info.WasIdle
+			t.idleTime = info.IdleTime
+			t.mu.Unlock()
+			if t.onGotConn != nil {
+				t.onGotConn(t)
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.mark(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	})
+	return request.WithContext(ctx), t
+}
+
+// connMetric - connection phases of the attempt to host
+func (t *connTrace) connMetric(host string) ConnMetric {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return ConnMetric{
+		Host:     host,
+		Reused:   t.reused,
+		IdleTime: t.idleTime,
+		DNS:      since(t.dnsStart, t.dnsDone),
+		Connect:  since(t.connectStart, t.connectDone),
+		TLS:      since(t.tlsStart, t.tlsDone),
+	}
+}
+
+// timings - phases of the request, total is measured until now
+func (t *connTrace) timings() *Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &Timings{
+		DNS:       since(t.dnsStart, t.dnsDone),
+		Connect:   since(t.connectStart, t.connectDone),
+		TLS:       since(t.tlsStart, t.tlsDone),
+		FirstByte: since(t.start, t.firstByte),
+		Total:     time.Since(t.start),
+		Reused:    t.reused,
+	}
+}
+
+// mark - set field to the current time
+func (t *connTrace) mark(field *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*field = time.Now()
+}
+
+// since - time between start and end, zero when one of them was not reached
+func since(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
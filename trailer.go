@@ -0,0 +1,76 @@
+package client_http
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RequestTrailers - trailers sent after a request body (gRPC status, checksums of
+// streamed content). The body is sent chunked
+type RequestTrailers struct {
+	// Keys - trailer names announced in the Trailer header before the body
+	Keys []string
+	// Values - called once the body was read to the end, values of keys not announced
+	// are ignored
+	Values func() http.Header
+}
+
+// trailersKey - context key of the request trailers
+type trailersKey struct{}
+
+// MarkTrailers - mark ctx so requests using it send trailers after their body, Values is
+// called once per attempt
+func MarkTrailers(ctx context.Context, trailers RequestTrailers) context.Context {
+	return context.WithValue(ctx, trailersKey{}, trailers)
+}
+
+// withTrailers - copy of request announcing its trailers, filled once the body is sent.
+// It runs right before the transport so every attempt and clone of the chain gets them
+func withTrailers(request *http.Request) *http.Request {
+	trailers, ok := request.Context().Value(trailersKey{}).(RequestTrailers)
+	if !ok || len(trailers.Keys) == 0 || trailers.Values == nil {
+		return request
+	}
+
+	clone := request.Clone(request.Context())
+	clone.Trailer = http.Header{}
+	for _, key := range trailers.Keys {
+		clone.Trailer[http.CanonicalHeaderKey(key)] = nil
+	}
+
+	body := request.Body
+	if body == nil || body == http.NoBody {
+		body = ioutil.NopCloser(strings.NewReader(""))
+	}
+	clone.Body = &trailerBody{ReadCloser: body, trailer: clone.Trailer, values: trailers.Values}
+	clone.ContentLength = -1
+	return clone
+}
+
+// trailerBody - request body filling trailer at the end
+type trailerBody struct {
+	io.ReadCloser
+	trailer http.Header
+	values  func() http.Header
+	once    sync.Once
+}
+
+// Read - read the body, trailer values are set on io.EOF
+func (b *trailerBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(func() {
+			for key, values := range b.values() {
+				key = http.CanonicalHeaderKey(key)
+				if _, announced := b.trailer[key]; announced {
+					b.trailer[key] = values
+				}
+			}
+		})
+	}
+	return n, err
+}
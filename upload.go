@@ -0,0 +1,86 @@
+package client_http
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// UploadOptions - settings of streamed uploads, all fields are optional
+type UploadOptions struct {
+	Headers []HeaderParameters
+	// ContentLength - size of the body, 0 or negative when unknown and the body is sent
+	// with chunked transfer encoding
+	ContentLength int64
+	Progress      ProgressFunc
+}
+
+// DoUploadStream - execute a request streaming body instead of holding it in memory.
+// Bodies implementing io.Seeker (files) are rewound for retries and are not closed by the
+// client, other streamed bodies and files that can't seek like pipes can't be replayed
+// so these requests are not retried
+func (c *Client) DoUploadStream(ctx context.Context, method, url string, body io.Reader, opts *UploadOptions) (*Response, error) {
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+
+	total := opts.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+
+	request, err := c.newStreamRequest(ctx, method, url, &progressReader{reader: body, progress: opts.Progress, total: total}, opts.Headers)
+	if err != nil {
+		return nil, err
+	}
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		getBody := seekBody(seeker, func(r io.Reader) io.Reader {
+			return &progressReader{reader: r, progress: opts.Progress, total: total}
+		})
+		if getBody != nil {
+			request.GetBody = getBody
+			request.Body, _ = getBody()
+		}
+	}
+	if total > 0 {
+		request.ContentLength = total
+	}
+
+	return c.execute(request)
+}
+
+// PostStream - POST body streaming it, see DoUploadStream
+func (c *Client) PostStream(ctx context.Context, url string, body io.Reader, opts *UploadOptions) (*Response, error) {
+	return c.DoUploadStream(ctx, http.MethodPost, url, body, opts)
+}
+
+// PutStream - PUT body streaming it, see DoUploadStream
+func (c *Client) PutStream(ctx context.Context, url string, body io.Reader, opts *UploadOptions) (*Response, error) {
+	return c.DoUploadStream(ctx, http.MethodPut, url, body, opts)
+}
+
+// progressReader - reader reporting progress after every read
+type progressReader struct {
+	reader   io.Reader
+	progress ProgressFunc
+	total    int64
+	read     int64
+}
+
+// Read - read into p and report progress
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if n > 0 && r.progress != nil {
+		r.progress(Progress{Transferred: r.read, Total: r.total})
+	}
+	return n, err
+}
+
+// Close - close the wrapped reader when it is a ReadCloser
+func (r *progressReader) Close() error {
+	if closer, ok := r.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
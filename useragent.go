@@ -0,0 +1,30 @@
+package client_http
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Version - version of the library, sent in the default User-Agent
+const Version = "1.0.0"
+
+// DefaultUserAgent - User-Agent of requests without one, instead of Go's default
+// which some WAFs block
+var DefaultUserAgent = fmt.Sprintf("client_http/%s (%s)", Version, runtime.Version())
+
+// WithUserAgent - User-Agent of every request unless set by the call, the client name
+// and version of the application are recommended ("billing-service/2.3")
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) error {
+		if userAgent == "" {
+			return fmt.Errorf("user agent can't be empty")
+		}
+		c.userAgent = userAgent
+		return nil
+	}
+}
+
+// UserAgentHeader - User-Agent header for a single request
+func UserAgentHeader(userAgent string) HeaderParameters {
+	return HeaderParameters{Key: "User-Agent", Value: userAgent}
+}
@@ -0,0 +1,332 @@
+package client_http
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valueOptions - options of a struct tag like `query:"name,omitempty,comma"`
+type valueOptions struct {
+	omitEmpty bool
+	// comma - join slices with commas instead of repeating the key
+	comma bool
+	// unix, unixMilli - encode times as epoch seconds or milliseconds
+	unix      bool
+	unixMilli bool
+	// layout - time layout of the `layout` tag, RFC 3339 by default
+	layout string
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// encodeValues - url values of the fields of struct v tagged with tag, untagged fields use
+// their name. Embedded structs are flattened, nested structs and maps use bracket keys
+// (address[city]) and slices of them indexes (items[0][id])
+func encodeValues(v interface{}, tag string) (url.Values, error) {
+	values := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can't encode [%T] as %s values, a struct is expected", v, tag)
+	}
+	if err := encodeStruct(values, rv, tag, ""); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// encodeStruct - add the fields of rv to values, nested under prefix when not empty
+func encodeStruct(values url.Values, rv reflect.Value, tag, prefix string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, opts, skip := parseValueTag(field, tag)
+		if skip {
+			continue
+		}
+		fv := rv.Field(i)
+
+		if field.Anonymous && field.Tag.Get(tag) == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				if err := encodeStruct(values, fv, tag, prefix); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if opts.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "[" + name + "]"
+		}
+		if err := encodeField(values, name, fv, opts, tag); err != nil {
+			return fmt.Errorf("error encoding field [%s] - [%w]", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// parseValueTag - key and options of field, skip for `tag:"-"`
+func parseValueTag(field reflect.StructField, tag string) (string, valueOptions, bool) {
+	value := field.Tag.Get(tag)
+	if value == "-" {
+		return "", valueOptions{}, true
+	}
+	name, rest, _ := strings.Cut(value, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	opts := valueOptions{layout: field.Tag.Get("layout")}
+	for _, option := range strings.Split(rest, ",") {
+		switch option {
+		case "omitempty":
+			opts.omitEmpty = true
+		case "comma":
+			opts.comma = true
+		case "unix":
+			opts.unix = true
+		case "unixmilli":
+			opts.unixMilli = true
+		}
+	}
+	return name, opts, false
+}
+
+// encodeField - add the value of fv under key, slices add one value per element
+func encodeField(values url.Values, key string, fv reflect.Value, opts valueOptions, tag string) error {
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	if nested(fv.Type()) {
+		if fv.Kind() == reflect.Struct {
+			return encodeStruct(values, fv, tag, key)
+		}
+		keys := fv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			if err := encodeField(values, key+"["+fmt.Sprint(k)+"]", fv.MapIndex(k), opts, tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Type().Elem().Kind() != reflect.Uint8 {
+		if elem := indirectType(fv.Type().Elem()); nested(elem) {
+			for i := 0; i < fv.Len(); i++ {
+				if err := encodeField(values, key+"["+strconv.Itoa(i)+"]", fv.Index(i), opts, tag); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		items := make([]string, 0, fv.Len())
+		for i := 0; i < fv.Len(); i++ {
+			item, ok, err := encodeScalar(fv.Index(i), opts)
+			if err != nil {
+				return err
+			}
+			if ok {
+				items = append(items, item)
+			}
+		}
+		if opts.comma {
+			values.Add(key, strings.Join(items, ","))
+			return nil
+		}
+		for _, item := range items {
+			values.Add(key, item)
+		}
+		return nil
+	}
+
+	item, ok, err := encodeScalar(fv, opts)
+	if err != nil {
+		return err
+	}
+	if ok {
+		values.Add(key, item)
+	}
+	return nil
+}
+
+// nested - values of t are encoded field by field, structs other than times and text
+// marshalers and maps
+func nested(t reflect.Type) bool {
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return false
+	}
+	return (t.Kind() == reflect.Struct && t != timeType) || t.Kind() == reflect.Map
+}
+
+// indirectType - type pointed by t
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// encodeScalar - text of a single value, false for nil pointers
+func encodeScalar(fv reflect.Value, opts valueOptions) (string, bool, error) {
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return "", false, nil
+		}
+		fv = fv.Elem()
+	}
+
+	if fv.Type() == timeType {
+		t := fv.Interface().(time.Time)
+		switch {
+		case opts.unix:
+			return strconv.FormatInt(t.Unix(), 10), true, nil
+		case opts.unixMilli:
+			return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10), true, nil
+		case opts.layout != "":
+			return t.Format(opts.layout), true, nil
+		}
+		return t.Format(time.RFC3339), true, nil
+	}
+	if fv.Type().Implements(textMarshalerType) {
+		text, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil, err
+	}
+	if fv.CanAddr() && reflect.PtrTo(fv.Type()).Implements(textMarshalerType) {
+		text, err := fv.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil, err
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+			return time.Duration(fv.Int()).String(), true, nil
+		}
+		return strconv.FormatInt(fv.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, fv.Type().Bits()), true, nil
+	case reflect.Slice:
+		// []byte
+		return string(fv.Bytes()), true, nil
+	}
+	return "", false, fmt.Errorf("unsupported type [%s]", fv.Type())
+}
+
+// decodeScalar - set fv from text, the reverse of encodeScalar. Empty text leaves fv
+// unchanged
+func decodeScalar(fv reflect.Value, text string, opts valueOptions) error {
+	if text == "" {
+		return nil
+	}
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return decodeScalar(fv.Elem(), text, opts)
+	}
+
+	if fv.Type() == timeType {
+		var t time.Time
+		var err error
+		switch {
+		case opts.unix || opts.unixMilli:
+			var n int64
+			if n, err = strconv.ParseInt(text, 10, 64); err == nil {
+				if opts.unix {
+					t = time.Unix(n, 0)
+				} else {
+					t = time.Unix(0, n*int64(time.Millisecond))
+				}
+			}
+		case opts.layout != "":
+			t, err = time.Parse(opts.layout, text)
+		default:
+			t, err = time.Parse(time.RFC3339, text)
+		}
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if fv.CanAddr() && reflect.PtrTo(fv.Type()).Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(text)
+			if err != nil {
+				return err
+			}
+			fv.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(text, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type [%s]", fv.Type())
+	}
+	return nil
+}